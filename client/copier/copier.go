@@ -0,0 +1,431 @@
+// Package copier copies objects of any size on the server side.
+package copier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/client/internal/workgroup"
+	"github.com/qingstor/qingstor-sdk-go/v4/client/progress"
+	"github.com/qingstor/qingstor-sdk-go/v4/internal/multipart"
+	"github.com/qingstor/qingstor-sdk-go/v4/log"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+	"github.com/qingstor/qingstor-sdk-go/v4/tracing"
+)
+
+// DefaultPartSize is the default size of parts copied.
+const DefaultPartSize = 1024 * 1024 * 64
+
+// DefaultConcurrency is the default number of parts copied at the same time.
+const DefaultConcurrency = 4
+
+const smallestPartSize int = 1024 * 1024 * 4
+
+// ErrVerifyFailed is returned when the copied object mismatches the source.
+var ErrVerifyFailed = errors.New("the copied object mismatches the source")
+
+// Metadata directives of Options.
+const (
+	// MetadataCopy copies the content type and metadata of source.
+	MetadataCopy = "COPY"
+	// MetadataReplace replaces the content type and metadata with Options.
+	MetadataReplace = "REPLACE"
+)
+
+// Copier struct provides a struct to copy objects into bucket
+type Copier struct {
+	bucket   *service.Bucket
+	partSize int
+
+	// Concurrency is the max number of parts copied at the same time.
+	Concurrency int
+
+	// Progress receives the progress events of copies, nil means no report.
+	Progress progress.Listener
+}
+
+// Options are the headers of the copied object and the keys of source.
+type Options struct {
+	// MetadataDirective is MetadataCopy or MetadataReplace, default to MetadataCopy.
+	MetadataDirective string
+	// Content-Type of the object, used by MetadataReplace
+	ContentType *string
+	// User-defined metadata, used by MetadataReplace
+	XQSMetaData *map[string]string
+	// Storage class of the object, default to the storage class of source
+	XQSStorageClass *string
+
+	// Encryption algorithm of the object
+	XQSEncryptionCustomerAlgorithm *string
+	// Encryption key of the object
+	XQSEncryptionCustomerKey *string
+	// MD5sum of encryption key
+	XQSEncryptionCustomerKeyMD5 *string
+
+	// Encryption algorithm of the source
+	XQSCopySourceEncryptionCustomerAlgorithm *string
+	// Encryption key of the source
+	XQSCopySourceEncryptionCustomerKey *string
+	// MD5sum of encryption key of the source
+	XQSCopySourceEncryptionCustomerKeyMD5 *string
+}
+
+// Init creates a copier struct which copies objects into bucket,
+// DefaultPartSize is used if partSize is not positive.
+func Init(bucket *service.Bucket, partSize int) *Copier {
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	return &Copier{
+		bucket:      bucket,
+		partSize:    partSize,
+		Concurrency: DefaultConcurrency,
+	}
+}
+
+// Copy copies the sourceKey in source bucket to objectKey.
+func (c *Copier) Copy(source *service.Bucket, sourceKey, objectKey string) error {
+	return c.CopyWithContext(context.Background(), source, sourceKey, objectKey)
+}
+
+// CopyWithContext add support for context
+func (c *Copier) CopyWithContext(ctx context.Context, source *service.Bucket, sourceKey, objectKey string) error {
+	return c.CopyWithOptions(ctx, source, sourceKey, objectKey, nil)
+}
+
+// CopyWithOptions copies the sourceKey in source bucket to objectKey with
+// opts, opts can be nil. The source bucket can be in another zone. Sources
+// smaller than the part size are copied by PutObject, the others are copied
+// by parts concurrently. The source is pinned by its ETag, and the result is
+// verified by HeadObject.
+func (c *Copier) CopyWithOptions(ctx context.Context, source *service.Bucket, sourceKey, objectKey string, opts *Options) (err error) {
+	ctx, span := tracing.Start(ctx, c.bucket.Config.Tracer, "Copy",
+		tracing.String(tracing.AttrBucket, service.StringValue(c.bucket.Properties.BucketName)),
+		tracing.String(tracing.AttrZone, service.StringValue(c.bucket.Properties.Zone)),
+		tracing.String(tracing.AttrKey, objectKey),
+	)
+	defer func() {
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}()
+
+	ctx = log.ContextWithDefaultLogger(ctx, c.bucket.Logger)
+	logger := log.FromContext(ctx)
+	src, err := c.headSource(ctx, source, sourceKey, opts)
+	if err != nil {
+		logger.Error("head source object", zap.String("key", sourceKey), zap.Error(err))
+		return err
+	}
+	copySource := "/" + service.StringValue(source.Properties.BucketName) + "/" + sourceKey
+
+	tracker := progress.NewTracker(c.Progress, src.size, 0)
+	tracker.Start()
+	defer func() {
+		tracker.Complete(err)
+	}()
+
+	if src.size < int64(c.partSize) {
+		_, err = c.bucket.PutObjectWithContext(ctx, objectKey, opts.putObjectInput(copySource, src))
+		if err != nil {
+			logger.Error("copy object", zap.String("key", objectKey), zap.Error(err))
+			return err
+		}
+		tracker.Add(src.size)
+	} else {
+		if c.partSize < smallestPartSize {
+			logger.Error("part size too small")
+			return errors.New("the part size is too small")
+		}
+		if err = c.copyMultipart(ctx, copySource, src, objectKey, opts, tracker); err != nil {
+			return err
+		}
+	}
+
+	if err = c.verify(ctx, objectKey, src, opts); err != nil {
+		logger.Error("verify copied object", zap.String("key", objectKey), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// sourceInfo is the object to copy.
+type sourceInfo struct {
+	size         int64
+	etag         string
+	contentType  *string
+	metadata     *map[string]string
+	storageClass *string
+}
+
+// headSource gets the information of source, ETag is not in the output of
+// HeadObject, so it's read from the response.
+func (c *Copier) headSource(ctx context.Context, source *service.Bucket, sourceKey string, opts *Options) (*sourceInfo, error) {
+	input := &service.HeadObjectInput{}
+	if opts != nil {
+		input.XQSEncryptionCustomerAlgorithm = opts.XQSCopySourceEncryptionCustomerAlgorithm
+		input.XQSEncryptionCustomerKey = opts.XQSCopySourceEncryptionCustomerKey
+		input.XQSEncryptionCustomerKeyMD5 = opts.XQSCopySourceEncryptionCustomerKeyMD5
+	}
+	r, output, err := source.HeadObjectRequest(sourceKey, input)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.SendWithContext(ctx); err != nil {
+		return nil, err
+	}
+	return &sourceInfo{
+		size:         service.Int64Value(output.ContentLength),
+		etag:         r.HTTPResponse.Header.Get("ETag"),
+		contentType:  output.ContentType,
+		metadata:     output.XQSMetaData,
+		storageClass: output.XQSStorageClass,
+	}, nil
+}
+
+func (c *Copier) copyMultipart(ctx context.Context, copySource string, src *sourceInfo, objectKey string, opts *Options, tracker *progress.Tracker) (err error) {
+	logger := log.FromContext(ctx)
+	output, err := c.bucket.InitiateMultipartUploadWithContext(ctx, objectKey, opts.initiateMultipartUploadInput(src))
+	if err != nil {
+		logger.Error("init multipart upload", zap.String("key", objectKey), zap.Error(err))
+		return err
+	}
+	uploadID := output.UploadID
+	defer func() {
+		if err != nil {
+			c.abort(ctx, objectKey, uploadID)
+		}
+	}()
+
+	parts, err := c.copyParts(ctx, copySource, src, objectKey, uploadID, opts, tracker)
+	if err != nil {
+		logger.Error("copy part",
+			zap.String("upload id", *uploadID), zap.String("key", objectKey), zap.Error(err))
+		return err
+	}
+
+	_, err = c.bucket.CompleteMultipartUploadWithContext(ctx, objectKey, opts.completeMultipartUploadInput(uploadID, parts))
+	if err != nil {
+		logger.Error("complete upload",
+			zap.String("upload id", *uploadID), zap.String("key", objectKey), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (c *Copier) copyParts(ctx context.Context, copySource string, src *sourceInfo, objectKey string, uploadID *string, opts *Options, tracker *progress.Tracker) ([]*service.ObjectPartType, error) {
+	group, ctx := workgroup.New(ctx, c.Concurrency)
+
+	var (
+		mu    sync.Mutex
+		parts []*service.ObjectPartType
+	)
+	for partNumber, start := 0, int64(0); start < src.size && group.Acquire(); partNumber, start = partNumber+1, start+int64(c.partSize) {
+		end := start + int64(c.partSize) - 1
+		if end >= src.size {
+			end = src.size - 1
+		}
+		partNumber, start, end := partNumber, start, end
+		group.Go(func() error {
+			tracker.PartStarted(partNumber)
+			etag, err := c.copyPart(ctx, objectKey, opts.uploadMultipartInput(uploadID, partNumber, copySource, src.etag, start, end))
+			if err != nil {
+				tracker.PartFailed(partNumber, err)
+				return err
+			}
+			tracker.Add(end - start + 1)
+			tracker.PartCompleted(partNumber)
+
+			mu.Lock()
+			defer mu.Unlock()
+			parts = append(parts, &service.ObjectPartType{
+				PartNumber: service.Int(partNumber),
+				Etag:       etag,
+			})
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return *parts[i].PartNumber < *parts[j].PartNumber
+	})
+	return parts, nil
+}
+
+// copyPart copies a part in a span which is the child of the copy span.
+func (c *Copier) copyPart(ctx context.Context, objectKey string, input *service.UploadMultipartInput) (*string, error) {
+	ctx, span := tracing.Start(ctx, c.bucket.Config.Tracer, "Copy Part",
+		tracing.String(tracing.AttrKey, objectKey),
+		tracing.String(tracing.AttrUploadID, *input.UploadID),
+		tracing.Int(tracing.AttrPartNumber, *input.PartNumber),
+	)
+	defer span.End()
+
+	output, err := c.bucket.UploadMultipartWithContext(ctx, objectKey, input)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+	return output.ETag, nil
+}
+
+// abort aborts the failed copy even if the copy context is canceled, the
+// failure of abort is only logged.
+func (c *Copier) abort(ctx context.Context, objectKey string, uploadID *string) {
+	logger := log.FromContext(ctx)
+	err := multipart.Abort(ctx, func(ctx context.Context) error {
+		_, err := c.bucket.AbortMultipartUploadWithContext(ctx, objectKey, &service.AbortMultipartUploadInput{
+			UploadID: uploadID,
+		})
+		return err
+	})
+	if err != nil {
+		logger.Error("abort multipart upload",
+			zap.String("upload id", *uploadID), zap.String("key", objectKey), zap.Error(err))
+	}
+}
+
+// verify checks the size of copied object, and the ETag if it's copied by
+// PutObject which keeps the ETag of source. ETags of objects encrypted by
+// customer keys are not the MD5 of content, they are not compared.
+func (c *Copier) verify(ctx context.Context, objectKey string, src *sourceInfo, opts *Options) error {
+	input := &service.HeadObjectInput{}
+	if opts != nil {
+		input.XQSEncryptionCustomerAlgorithm = opts.XQSEncryptionCustomerAlgorithm
+		input.XQSEncryptionCustomerKey = opts.XQSEncryptionCustomerKey
+		input.XQSEncryptionCustomerKeyMD5 = opts.XQSEncryptionCustomerKeyMD5
+	}
+	r, output, err := c.bucket.HeadObjectRequest(objectKey, input)
+	if err != nil {
+		return err
+	}
+	if err = r.SendWithContext(ctx); err != nil {
+		return err
+	}
+
+	if size := service.Int64Value(output.ContentLength); size != src.size {
+		return fmt.Errorf("%w: size %d, expected %d", ErrVerifyFailed, size, src.size)
+	}
+	etag := r.HTTPResponse.Header.Get("ETag")
+	if !opts.encrypted() && !strings.Contains(src.etag, "-") && !strings.Contains(etag, "-") &&
+		src.etag != "" && etag != "" && etag != src.etag {
+		return fmt.Errorf("%w: etag %s, expected %s", ErrVerifyFailed, etag, src.etag)
+	}
+	return nil
+}
+
+func (o *Options) replaceMetadata() bool {
+	return o != nil && o.MetadataDirective == MetadataReplace
+}
+
+// encrypted checks whether the source or the copied object is encrypted by
+// customer keys.
+func (o *Options) encrypted() bool {
+	return o != nil && (o.XQSEncryptionCustomerKey != nil || o.XQSCopySourceEncryptionCustomerKey != nil)
+}
+
+// putObjectInput defaults the storage class to the storage class of source,
+// the same as initiateMultipartUploadInput.
+func (o *Options) putObjectInput(copySource string, src *sourceInfo) *service.PutObjectInput {
+	input := &service.PutObjectInput{
+		XQSCopySource:        service.String(copySource),
+		XQSCopySourceIfMatch: nilIfEmpty(src.etag),
+		XQSMetadataDirective: service.String(MetadataCopy),
+		XQSStorageClass:      src.storageClass,
+		ContentLength:        service.Int64(0),
+	}
+	if o == nil {
+		return input
+	}
+	if o.replaceMetadata() {
+		input.XQSMetadataDirective = service.String(MetadataReplace)
+		input.ContentType = o.ContentType
+		input.XQSMetaData = o.XQSMetaData
+	}
+	if o.XQSStorageClass != nil {
+		input.XQSStorageClass = o.XQSStorageClass
+	}
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	input.XQSCopySourceEncryptionCustomerAlgorithm = o.XQSCopySourceEncryptionCustomerAlgorithm
+	input.XQSCopySourceEncryptionCustomerKey = o.XQSCopySourceEncryptionCustomerKey
+	input.XQSCopySourceEncryptionCustomerKeyMD5 = o.XQSCopySourceEncryptionCustomerKeyMD5
+	return input
+}
+
+// initiateMultipartUploadInput copies the content type, metadata and storage
+// class of source explicitly, as the multipart upload creates a new object.
+func (o *Options) initiateMultipartUploadInput(src *sourceInfo) *service.InitiateMultipartUploadInput {
+	input := &service.InitiateMultipartUploadInput{
+		ContentType:     src.contentType,
+		XQSMetaData:     src.metadata,
+		XQSStorageClass: src.storageClass,
+	}
+	if o == nil {
+		return input
+	}
+	if o.replaceMetadata() {
+		input.ContentType = o.ContentType
+		input.XQSMetaData = o.XQSMetaData
+	}
+	if o.XQSStorageClass != nil {
+		input.XQSStorageClass = o.XQSStorageClass
+	}
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	return input
+}
+
+func (o *Options) uploadMultipartInput(uploadID *string, partNumber int, copySource, etag string, start, end int64) *service.UploadMultipartInput {
+	input := &service.UploadMultipartInput{
+		UploadID:             uploadID,
+		PartNumber:           service.Int(partNumber),
+		ContentLength:        service.Int64(0),
+		XQSCopySource:        service.String(copySource),
+		XQSCopyRange:         service.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		XQSCopySourceIfMatch: nilIfEmpty(etag),
+	}
+	if o == nil {
+		return input
+	}
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	input.XQSCopySourceEncryptionCustomerAlgorithm = o.XQSCopySourceEncryptionCustomerAlgorithm
+	input.XQSCopySourceEncryptionCustomerKey = o.XQSCopySourceEncryptionCustomerKey
+	input.XQSCopySourceEncryptionCustomerKeyMD5 = o.XQSCopySourceEncryptionCustomerKeyMD5
+	return input
+}
+
+func (o *Options) completeMultipartUploadInput(uploadID *string, parts []*service.ObjectPartType) *service.CompleteMultipartUploadInput {
+	input := &service.CompleteMultipartUploadInput{
+		UploadID:    uploadID,
+		ObjectParts: parts,
+	}
+	if o == nil {
+		return input
+	}
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	return input
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return service.String(s)
+}
@@ -0,0 +1,219 @@
+package copier
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/qingstortest"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// newTestBuckets creates the destination bucket, and the source bucket holding
+// content at "source-key" in server, faults can be injected into the requests of
+// dst through the returned transport.
+func newTestBuckets(t *testing.T, server *qingstortest.Server, content []byte, input *service.PutObjectInput) (dst, src *service.Bucket, transport *qingstortest.FaultTransport) {
+	dst, transport = qingstortest.NewTestBucket(t, server)
+	src, _ = qingstortest.NewTestBucket(t, server, qingstortest.WithBucketName("source"))
+
+	if input == nil {
+		input = &service.PutObjectInput{}
+	}
+	input.ContentType = service.String("text/plain")
+	input.XQSMetaData = &map[string]string{"X-QS-Meta-Origin": "source"}
+	input.Body = bytes.NewReader(content)
+	_, err := src.PutObject("source-key", input)
+	assert.Nil(t, err)
+	return dst, src, transport
+}
+
+// customerKey returns the key and key MD5 of SSE-C made of c.
+func customerKey(c byte) (key, keyMD5 *string) {
+	k := bytes.Repeat([]byte{c}, 32)
+	sum := md5.Sum(k)
+	return service.String(base64.StdEncoding.EncodeToString(k)), service.String(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+// requestsTo returns the requests of method to the copied object.
+func requestsTo(server *qingstortest.Server, method string) []*http.Request {
+	var requests []*http.Request
+	for _, r := range server.Requests() {
+		if r.Method == method && r.URL.Path == "/bucket/key" {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+func TestCopySmallObject(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	content := qingstortest.Content(100)
+	dst, src, _ := newTestBuckets(t, server, content, nil)
+
+	c := Init(dst, 0)
+	err := c.CopyWithOptions(nil, src, "source-key", "key", &Options{
+		XQSStorageClass: service.String("STANDARD_IA"),
+	})
+	assert.Nil(t, err)
+	copied, _ := server.Object("bucket", "key")
+	assert.Equal(t, content, copied)
+
+	head, err := src.HeadObject("source-key", nil)
+	assert.Nil(t, err)
+	put := requestsTo(server, http.MethodPut)[0]
+	assert.Equal(t, service.StringValue(head.ETag), put.Header.Get("X-QS-Copy-Source-If-Match"))
+	assert.Equal(t, MetadataCopy, put.Header.Get("X-QS-Metadata-Directive"))
+	assert.Equal(t, "STANDARD_IA", put.Header.Get("X-QS-Storage-Class"))
+}
+
+func TestCopySmallObjectStorageClass(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	dst, src, _ := newTestBuckets(t, server, qingstortest.Content(100), &service.PutObjectInput{
+		XQSStorageClass: service.String("STANDARD_IA"),
+	})
+
+	c := Init(dst, 0)
+	err := c.Copy(src, "source-key", "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "STANDARD_IA", requestsTo(server, http.MethodPut)[0].Header.Get("X-QS-Storage-Class"))
+}
+
+func TestCopySmallObjectEncrypted(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	dst, src, transport := newTestBuckets(t, server, qingstortest.Content(100), nil)
+	// The ETag of encrypted objects is not the MD5 of content.
+	transport.SetFaults(qingstortest.Fault{
+		Kind:      qingstortest.FaultHeader,
+		Operation: "HEAD Object",
+		Key:       "key",
+		Header:    http.Header{"ETag": {`"encryptedetag"`}},
+	})
+
+	c := Init(dst, 0)
+	err := c.Copy(src, "source-key", "key")
+	assert.True(t, errors.Is(err, ErrVerifyFailed))
+
+	key, keyMD5 := customerKey('k')
+	err = c.CopyWithOptions(nil, src, "source-key", "key", &Options{
+		XQSEncryptionCustomerAlgorithm: service.String("AES256"),
+		XQSEncryptionCustomerKey:       key,
+		XQSEncryptionCustomerKeyMD5:    keyMD5,
+	})
+	assert.Nil(t, err)
+}
+
+func TestCopyMultipart(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	sourceKey, sourceKeyMD5 := customerKey('s')
+	content := qingstortest.Content(smallestPartSize*2 + 100)
+	dst, src, _ := newTestBuckets(t, server, content, &service.PutObjectInput{
+		XQSEncryptionCustomerAlgorithm: service.String("AES256"),
+		XQSEncryptionCustomerKey:       sourceKey,
+		XQSEncryptionCustomerKeyMD5:    sourceKeyMD5,
+	})
+
+	key, keyMD5 := customerKey('k')
+	c := Init(dst, smallestPartSize)
+	err := c.CopyWithOptions(nil, src, "source-key", "key", &Options{
+		XQSEncryptionCustomerAlgorithm:           service.String("AES256"),
+		XQSEncryptionCustomerKey:                 key,
+		XQSEncryptionCustomerKeyMD5:              keyMD5,
+		XQSCopySourceEncryptionCustomerAlgorithm: service.String("AES256"),
+		XQSCopySourceEncryptionCustomerKey:       sourceKey,
+		XQSCopySourceEncryptionCustomerKeyMD5:    sourceKeyMD5,
+	})
+	assert.Nil(t, err)
+	copied, _ := server.Object("bucket", "key")
+	assert.Equal(t, content, copied)
+
+	var copyRanges []string
+	for _, r := range requestsTo(server, http.MethodPut) {
+		copyRanges = append(copyRanges, r.Header.Get("X-QS-Copy-Range"))
+	}
+	assert.ElementsMatch(t, []string{
+		fmt.Sprintf("bytes=0-%d", smallestPartSize-1),
+		fmt.Sprintf("bytes=%d-%d", smallestPartSize, smallestPartSize*2-1),
+		fmt.Sprintf("bytes=%d-%d", smallestPartSize*2, smallestPartSize*2+99),
+	}, copyRanges)
+
+	// The metadata of source is preserved, and the copy is encrypted by key.
+	head, err := dst.HeadObject("key", &service.HeadObjectInput{
+		XQSEncryptionCustomerAlgorithm: service.String("AES256"),
+		XQSEncryptionCustomerKey:       key,
+		XQSEncryptionCustomerKeyMD5:    keyMD5,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "text/plain", service.StringValue(head.ContentType))
+	assert.Equal(t, "source", (*head.XQSMetaData)["x-qs-meta-origin"])
+	assert.Equal(t, "STANDARD", service.StringValue(head.XQSStorageClass))
+	_, err = dst.HeadObject("key", nil)
+	assert.NotNil(t, err)
+}
+
+func TestCopyMultipartReplaceMetadata(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	dst, src, _ := newTestBuckets(t, server, qingstortest.Content(smallestPartSize+100), nil)
+
+	c := Init(dst, smallestPartSize)
+	err := c.CopyWithOptions(nil, src, "source-key", "key", &Options{
+		MetadataDirective: MetadataReplace,
+		ContentType:       service.String("image/png"),
+		XQSMetaData:       &map[string]string{"X-QS-Meta-Origin": "replaced"},
+		XQSStorageClass:   service.String("STANDARD_IA"),
+	})
+	assert.Nil(t, err)
+	head, err := dst.HeadObject("key", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", service.StringValue(head.ContentType))
+	assert.Equal(t, "replaced", (*head.XQSMetaData)["x-qs-meta-origin"])
+	assert.Equal(t, "STANDARD_IA", service.StringValue(head.XQSStorageClass))
+}
+
+func TestCopyMultipartFailed(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	dst, src, transport := newTestBuckets(t, server, qingstortest.Content(smallestPartSize*3+100), nil)
+	transport.SetFaults(qingstortest.Fault{
+		Kind:       qingstortest.FaultStatus,
+		Operation:  "Upload Multipart",
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "invalid_request",
+	})
+
+	c := Init(dst, smallestPartSize)
+	err := c.Copy(src, "source-key", "key")
+	assert.NotNil(t, err)
+	// The multipart upload is aborted.
+	uploads, err := dst.ListMultipartUploads(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, uploads.Uploads)
+	_, ok := server.Object("bucket", "key")
+	assert.False(t, ok)
+}
+
+func TestCopyVerifyFailed(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	dst, src, transport := newTestBuckets(t, server, qingstortest.Content(smallestPartSize+100), nil)
+	transport.SetFaults(qingstortest.Fault{
+		Kind:      qingstortest.FaultHeader,
+		Operation: "HEAD Object",
+		Key:       "key",
+		Header:    http.Header{"Content-Length": {"1"}},
+	})
+
+	c := Init(dst, smallestPartSize)
+	err := c.Copy(src, "source-key", "key")
+	assert.True(t, errors.Is(err, ErrVerifyFailed))
+}
@@ -0,0 +1,316 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/client/internal/workgroup"
+	"github.com/qingstor/qingstor-sdk-go/v4/client/progress"
+	"github.com/qingstor/qingstor-sdk-go/v4/log"
+	"github.com/qingstor/qingstor-sdk-go/v4/request"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+	"github.com/qingstor/qingstor-sdk-go/v4/tracing"
+)
+
+// DefaultPartSize is the default size of ranges downloaded.
+const DefaultPartSize = 1024 * 1024 * 8
+
+// DefaultConcurrency is the default number of ranges downloaded at the same time.
+const DefaultConcurrency = 4
+
+// ErrObjectChanged is returned when the object changed during download.
+var ErrObjectChanged = errors.New("the object changed during download")
+
+// Downloader struct provides a struct to download objects by ranges
+type Downloader struct {
+	bucket   *service.Bucket
+	partSize int
+
+	// Concurrency is the max number of ranges downloaded at the same time.
+	Concurrency int
+
+	// Progress receives the progress events of downloads, nil means no report.
+	Progress progress.Listener
+}
+
+// Options are the headers sent with every request of a download.
+type Options struct {
+	// Encryption algorithm of the object
+	XQSEncryptionCustomerAlgorithm *string
+	// Encryption key of the object
+	XQSEncryptionCustomerKey *string
+	// MD5sum of encryption key
+	XQSEncryptionCustomerKeyMD5 *string
+}
+
+// Init creates a downloader struct, DefaultPartSize is used if partSize is
+// not positive.
+func Init(bucket *service.Bucket, partSize int) *Downloader {
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	return &Downloader{
+		bucket:      bucket,
+		partSize:    partSize,
+		Concurrency: DefaultConcurrency,
+	}
+}
+
+// Download downloads the object into w, and returns the size of object.
+func (d *Downloader) Download(w io.WriterAt, objectKey string) (int64, error) {
+	return d.DownloadWithContext(context.Background(), w, objectKey)
+}
+
+// DownloadWithContext add support for context
+func (d *Downloader) DownloadWithContext(ctx context.Context, w io.WriterAt, objectKey string) (int64, error) {
+	return d.DownloadWithOptions(ctx, w, objectKey, nil)
+}
+
+// DownloadWithOptions downloads the object with headers in opts, opts can be nil.
+// Ranges are written into w concurrently.
+func (d *Downloader) DownloadWithOptions(ctx context.Context, w io.WriterAt, objectKey string, opts *Options) (int64, error) {
+	return d.download(ctx, w, objectKey, opts)
+}
+
+// DownloadSequential downloads the object into w in order, which is useful
+// for writers like pipes.
+func (d *Downloader) DownloadSequential(w io.Writer, objectKey string) (int64, error) {
+	return d.DownloadSequentialWithOptions(context.Background(), w, objectKey, nil)
+}
+
+// DownloadSequentialWithOptions downloads the object into w in order with
+// headers in opts. Ranges are still downloaded concurrently, but each range
+// waits for the ranges before it, so no range is buffered in memory.
+func (d *Downloader) DownloadSequentialWithOptions(ctx context.Context, w io.Writer, objectKey string, opts *Options) (int64, error) {
+	return d.download(ctx, newSequentialWriter(w), objectKey, opts)
+}
+
+func (d *Downloader) download(ctx context.Context, w io.WriterAt, objectKey string, opts *Options) (size int64, err error) {
+	ctx, span := d.startSpan(ctx, objectKey)
+	defer func() {
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}()
+
+	ctx = log.ContextWithDefaultLogger(ctx, d.bucket.Logger)
+	logger := log.FromContext(ctx)
+	info, err := d.head(ctx, objectKey, opts)
+	if err != nil {
+		logger.Error("head object", zap.String("key", objectKey), zap.Error(err))
+		return 0, err
+	}
+
+	tracker := progress.NewTracker(d.Progress, info.size, 0)
+	tracker.Start()
+	defer func() {
+		tracker.Complete(err)
+	}()
+
+	if err = d.downloadRanges(ctx, w, objectKey, info, opts, tracker, nil); err != nil {
+		return 0, err
+	}
+	return info.size, nil
+}
+
+func (d *Downloader) startSpan(ctx context.Context, objectKey string) (context.Context, tracing.Span) {
+	return tracing.Start(ctx, d.bucket.Config.Tracer, "Download",
+		tracing.String(tracing.AttrBucket, service.StringValue(d.bucket.Properties.BucketName)),
+		tracing.String(tracing.AttrZone, service.StringValue(d.bucket.Properties.Zone)),
+		tracing.String(tracing.AttrKey, objectKey),
+	)
+}
+
+// downloadRanges downloads all the ranges of object into w, the ranges
+// completed in st are skipped, and the newly completed ones are recorded.
+func (d *Downloader) downloadRanges(ctx context.Context, w io.WriterAt, objectKey string, info *objectInfo, opts *Options, tracker *progress.Tracker, st *state) error {
+	logger := log.FromContext(ctx)
+	group, ctx := workgroup.New(ctx, d.Concurrency)
+	// Unblock the ranges waiting for the failed one.
+	if s, ok := w.(*sequentialWriter); ok {
+		group.OnFail = s.abort
+	}
+
+	for partNumber, start := 0, int64(0); start < info.size && group.Acquire(); partNumber, start = partNumber+1, start+int64(d.partSize) {
+		end := start + int64(d.partSize) - 1
+		if end >= info.size {
+			end = info.size - 1
+		}
+		if st.completed(start, end) {
+			tracker.Add(end - start + 1)
+			tracker.PartCompleted(partNumber)
+			group.Release()
+			continue
+		}
+
+		partNumber, start, end := partNumber, start, end
+		group.Go(func() error {
+			tracker.PartStarted(partNumber)
+			err := d.downloadRange(ctx, w, objectKey, partNumber, start, end, info.etag, opts, tracker)
+			if err != nil {
+				logger.Error("download range", zap.String("key", objectKey),
+					zap.Int64("start", start), zap.Int64("end", end), zap.Error(err))
+				tracker.PartFailed(partNumber, err)
+				return err
+			}
+			if err = st.record(start, end); err != nil {
+				logger.Error("save download state", zap.String("state", st.path), zap.Error(err))
+				return err
+			}
+			tracker.PartCompleted(partNumber)
+			return nil
+		})
+	}
+	return group.Wait()
+}
+
+// objectInfo is the object to download.
+type objectInfo struct {
+	size         int64
+	etag         string
+	lastModified time.Time
+}
+
+// head gets the size, ETag and last modified time of the object, ETag is not
+// in the output of HeadObject, so it's read from the response.
+func (d *Downloader) head(ctx context.Context, objectKey string, opts *Options) (*objectInfo, error) {
+	r, output, err := d.bucket.HeadObjectRequest(objectKey, opts.headObjectInput())
+	if err != nil {
+		return nil, err
+	}
+	if err = r.SendWithContext(ctx); err != nil {
+		return nil, err
+	}
+	return &objectInfo{
+		size:         service.Int64Value(output.ContentLength),
+		etag:         r.HTTPResponse.Header.Get("ETag"),
+		lastModified: service.TimeValue(output.LastModified),
+	}, nil
+}
+
+// downloadRange downloads bytes in [start, end] of the object. Failed
+// requests are retried by GetObject, a failure of reading body is resumed
+// from the last written byte here.
+func (d *Downloader) downloadRange(ctx context.Context, w io.WriterAt, objectKey string, partNumber int, start, end int64, etag string, opts *Options, tracker *progress.Tracker) (err error) {
+	ctx, span := tracing.Start(ctx, d.bucket.Config.Tracer, "Download Part",
+		tracing.String(tracing.AttrKey, objectKey),
+		tracing.Int(tracing.AttrPartNumber, partNumber),
+	)
+	defer func() {
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}()
+
+	logger := log.FromContext(ctx)
+	settings := d.bucket.Config.RetrySettings
+	var written int64
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			tracker.PartRetried(partNumber)
+			if err = request.WaitForRetry(ctx, settings, attempt); err != nil {
+				return err
+			}
+		}
+
+		var n int64
+		n, err = d.getRange(ctx, w, objectKey, start+written, end, etag, opts, tracker)
+		written += n
+		if err == nil {
+			return nil
+		}
+		e, ok := err.(*readError)
+		if !ok {
+			return err
+		}
+		if attempt >= settings.MaxRetries || !request.IsRetryable(e.err) {
+			return e.err
+		}
+
+		logger.Warn("retry range",
+			zap.String("key", objectKey),
+			zap.Int64("offset", start+written),
+			zap.Int("attempt", attempt+1),
+			zap.Error(e.err),
+		)
+	}
+}
+
+// readError is the error of reading the body of a range, which is retried
+// by resuming the range.
+type readError struct {
+	err error
+}
+
+func (e *readError) Error() string {
+	return e.err.Error()
+}
+
+// getRange writes bytes in [start, end] of the object into w, and returns
+// the count of bytes written. Failures of reading body are returned as
+// readError, and failures of writing are returned as they are.
+func (d *Downloader) getRange(ctx context.Context, w io.WriterAt, objectKey string, start, end int64, etag string, opts *Options, tracker *progress.Tracker) (int64, error) {
+	input := opts.getObjectInput()
+	input.Range = service.String(fmt.Sprintf("bytes=%d-%d", start, end))
+	if etag != "" {
+		input.IfMatch = service.String(etag)
+	}
+
+	output, err := d.bucket.GetObjectWithContext(ctx, objectKey, input)
+	if err != nil {
+		return 0, err
+	}
+	defer output.Body.Close()
+
+	switch service.IntValue(output.StatusCode) {
+	case http.StatusPartialContent:
+	case http.StatusPreconditionFailed:
+		return 0, ErrObjectChanged
+	default:
+		return 0, fmt.Errorf("unexpected status code %d of range %d-%d",
+			service.IntValue(output.StatusCode), start, end)
+	}
+
+	ow := &offsetWriter{w: w, offset: start, tracker: tracker}
+	n, err := io.Copy(ow, output.Body)
+	if ow.err != nil {
+		return n, ow.err
+	}
+	if err == nil && n != end-start+1 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return n, &readError{err: err}
+	}
+	return n, nil
+}
+
+func (o *Options) headObjectInput() *service.HeadObjectInput {
+	input := &service.HeadObjectInput{}
+	if o == nil {
+		return input
+	}
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	return input
+}
+
+func (o *Options) getObjectInput() *service.GetObjectInput {
+	input := &service.GetObjectInput{}
+	if o == nil {
+		return input
+	}
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	return input
+}
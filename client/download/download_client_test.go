@@ -0,0 +1,313 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/client/progress"
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/metrics"
+	"github.com/qingstor/qingstor-sdk-go/v4/qingstortest"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// getRequests returns the GET Object requests in requests.
+func getRequests(requests []*http.Request) []*http.Request {
+	var gets []*http.Request
+	for _, r := range requests {
+		if r.Method == http.MethodGet && r.URL.Path == "/bucket/key" {
+			gets = append(gets, r)
+		}
+	}
+	return gets
+}
+
+// ranges returns the ranges of the GET Object requests in requests.
+func ranges(requests []*http.Request) []string {
+	var ranges []string
+	for _, r := range getRequests(requests) {
+		ranges = append(ranges, r.Header.Get("Range"))
+	}
+	return ranges
+}
+
+// failAfter makes the ranges after partNumber fail with 403.
+func failAfter(transport *qingstortest.FaultTransport, partNumber int) progress.Listener {
+	return progress.ListenerFunc(func(e progress.Event) {
+		if e.Type == progress.PartCompleted && e.PartNumber == partNumber {
+			transport.SetFaults(qingstortest.Fault{
+				Kind:       qingstortest.FaultStatus,
+				Operation:  "GET Object",
+				StatusCode: http.StatusForbidden,
+				ErrorCode:  "permission_denied",
+			})
+		}
+	})
+}
+
+func TestDownload(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	content := qingstortest.Content(1000)
+	bucket, _ := qingstortest.NewTestBucket(t, server, qingstortest.WithObject("key", content))
+
+	f, err := ioutil.TempFile("", "download")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var last progress.Event
+	d := Init(bucket, 100)
+	d.Progress = progress.ListenerFunc(func(e progress.Event) {
+		last = e
+	})
+	n, err := d.Download(f, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), n)
+	gets := getRequests(server.Requests())
+	assert.Equal(t, 10, len(gets))
+	head, err := bucket.HeadObject("key", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, service.StringValue(head.ETag), gets[0].Header.Get("If-Match"))
+	assert.Equal(t, progress.TransferCompleted, last.Type)
+	assert.Equal(t, int64(1000), last.TransferredBytes)
+
+	downloaded, err := ioutil.ReadFile(f.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, content, downloaded)
+}
+
+func TestDownloadSequential(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	content := qingstortest.Content(1050)
+	bucket, _ := qingstortest.NewTestBucket(t, server, qingstortest.WithObject("key", content))
+
+	buffer := &bytes.Buffer{}
+	d := Init(bucket, 100)
+	d.Concurrency = 8
+	n, err := d.DownloadSequential(buffer, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1050), n)
+	assert.Equal(t, content, buffer.Bytes())
+}
+
+func TestDownloadRetryRange(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	content := qingstortest.Content(300)
+	bucket, transport := qingstortest.NewTestBucket(t, server, qingstortest.WithObject("key", content))
+	transport.SetFaults(qingstortest.Fault{
+		Kind:      qingstortest.FaultTruncate,
+		Operation: "GET Object",
+		Offset:    50,
+	})
+
+	buffer := &bytes.Buffer{}
+	d := Init(bucket, 100)
+	d.Concurrency = 1
+	_, err := d.DownloadSequential(buffer, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, content, buffer.Bytes())
+	// The truncated ranges are resumed from the last byte received.
+	assert.Equal(t, []string{
+		"bytes=0-99", "bytes=50-99",
+		"bytes=100-199", "bytes=150-199",
+		"bytes=200-299", "bytes=250-299",
+	}, ranges(server.Requests()))
+}
+
+func TestDownloadRetryFailedRequest(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	sink := metrics.NewMemory()
+	bucket, transport := qingstortest.NewTestBucket(t, server,
+		qingstortest.WithConfig(func(conf *config.Config) {
+			conf.Metrics = sink
+			conf.RetrySettings.MaxRetries = 2
+		}),
+		qingstortest.WithObject("key", qingstortest.Content(300)))
+	transport.SetFaults(qingstortest.Fault{
+		Kind:       qingstortest.FaultStatus,
+		Operation:  "GET Object",
+		StatusCode: http.StatusServiceUnavailable,
+		ErrorCode:  "service_unavailable",
+	})
+
+	d := Init(bucket, 100)
+	d.Concurrency = 1
+	_, err := d.DownloadSequential(ioutil.Discard, "key")
+	assert.NotNil(t, err)
+	// Failed requests are retried by GetObject only.
+	stats := sink.Stats("GET Object")
+	assert.Equal(t, int64(1), stats.Requests)
+	assert.Equal(t, int64(3), stats.Attempts)
+}
+
+func TestDownloadObjectChanged(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server, qingstortest.WithObject("key", qingstortest.Content(300)))
+
+	d := Init(bucket, 100)
+	d.Concurrency = 1
+	// The object is overwritten after the first range.
+	overwritten := false
+	w := &hookWriter{hook: func() {
+		if !overwritten {
+			overwritten = true
+			_, err := bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader([]byte("changed"))})
+			assert.Nil(t, err)
+		}
+	}}
+	_, err := d.DownloadSequential(w, "key")
+	assert.Equal(t, ErrObjectChanged, err)
+}
+
+func TestDownloadWithOptions(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+
+	key := bytes.Repeat([]byte("k"), 32)
+	sum := md5.Sum(key)
+	opts := &Options{
+		XQSEncryptionCustomerAlgorithm: service.String("AES256"),
+		XQSEncryptionCustomerKey:       service.String(base64.StdEncoding.EncodeToString(key)),
+		XQSEncryptionCustomerKeyMD5:    service.String(base64.StdEncoding.EncodeToString(sum[:])),
+	}
+	content := qingstortest.Content(10)
+	_, err := bucket.PutObject("key", &service.PutObjectInput{
+		XQSEncryptionCustomerAlgorithm: opts.XQSEncryptionCustomerAlgorithm,
+		XQSEncryptionCustomerKey:       opts.XQSEncryptionCustomerKey,
+		XQSEncryptionCustomerKeyMD5:    opts.XQSEncryptionCustomerKeyMD5,
+		Body:                           bytes.NewReader(content),
+	})
+	assert.Nil(t, err)
+
+	buffer := &bytes.Buffer{}
+	d := Init(bucket, 0)
+	_, err = d.DownloadSequentialWithOptions(context.Background(), buffer, "key", opts)
+	assert.Nil(t, err)
+	assert.Equal(t, content, buffer.Bytes())
+	// The object can't be read without the key.
+	_, err = d.DownloadSequential(ioutil.Discard, "key")
+	assert.NotNil(t, err)
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	content := qingstortest.Content(500)
+	bucket, transport := qingstortest.NewTestBucket(t, server, qingstortest.WithObject("key", content))
+
+	dir, err := ioutil.TempDir("", "download")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "object")
+
+	d := Init(bucket, 100)
+	d.Concurrency = 1
+	d.Progress = failAfter(transport, 2)
+	_, err = d.DownloadFile(path, "key")
+	assert.NotNil(t, err)
+	st, err := loadState(path + PartialSuffix + StateSuffix)
+	assert.Nil(t, err)
+	assert.Equal(t, []byteRange{{0, 99}, {100, 199}, {200, 299}}, st.Ranges)
+
+	transport.SetFaults()
+	// The completed ranges are counted once on resume.
+	var last progress.Event
+	d.Progress = progress.ListenerFunc(func(e progress.Event) {
+		last = e
+	})
+	served := len(server.Requests())
+	n, err := d.DownloadFile(path, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(500), n)
+	assert.Equal(t, progress.TransferCompleted, last.Type)
+	assert.Equal(t, last.TotalBytes, last.TransferredBytes)
+	assert.Equal(t, []string{"bytes=300-399", "bytes=400-499"}, ranges(server.Requests()[served:]))
+
+	downloaded, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, content, downloaded)
+	_, err = os.Stat(path + PartialSuffix)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(path + PartialSuffix + StateSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadFileObjectChanged(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server, qingstortest.WithObject("key", qingstortest.Content(300)))
+
+	dir, err := ioutil.TempDir("", "download")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "object")
+
+	d := Init(bucket, 100)
+	d.Concurrency = 1
+	d.Progress = failAfter(transport, 1)
+	_, err = d.DownloadFile(path, "key")
+	assert.NotNil(t, err)
+
+	changed := qingstortest.Content(300)
+	changed[0]++
+	_, err = bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader(changed)})
+	assert.Nil(t, err)
+
+	transport.SetFaults()
+	d.Progress = nil
+	served := len(server.Requests())
+	_, err = d.DownloadFile(path, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(ranges(server.Requests()[served:])))
+	downloaded, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, changed, downloaded)
+}
+
+func TestDownloadFileChecksumMismatch(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server, qingstortest.WithObject("key", qingstortest.Content(300)))
+	transport.SetFaults(qingstortest.Fault{
+		Kind:      qingstortest.FaultCorrupt,
+		Operation: "GET Object",
+		Offset:    10,
+	})
+
+	dir, err := ioutil.TempDir("", "download")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "object")
+
+	d := Init(bucket, 100)
+	_, err = d.DownloadFile(path, "key")
+	assert.Equal(t, ErrChecksumMismatch, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(path + PartialSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+type hookWriter struct {
+	hook func()
+}
+
+func (w *hookWriter) Write(p []byte) (int, error) {
+	w.hook()
+	return len(p), nil
+}
@@ -0,0 +1,175 @@
+package download
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/client/progress"
+	"github.com/qingstor/qingstor-sdk-go/v4/log"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+const (
+	// PartialSuffix is appended to the path of file being downloaded.
+	PartialSuffix = ".download"
+	// StateSuffix is appended to the path of partial file for the state file
+	// which records the completed ranges.
+	StateSuffix = ".state"
+)
+
+// ErrChecksumMismatch is returned when the MD5 of downloaded file mismatches
+// the ETag of object, the partial file is removed.
+var ErrChecksumMismatch = errors.New("the md5 of downloaded file mismatches the etag")
+
+// md5ETag matches the ETag of objects uploaded in single part.
+var md5ETag = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+
+// DownloadFile downloads the object into file at path.
+func (d *Downloader) DownloadFile(path string, objectKey string) (int64, error) {
+	return d.DownloadFileWithOptions(context.Background(), path, objectKey, nil)
+}
+
+// DownloadFileWithOptions downloads the object into file at path with headers
+// in opts. The object is written into path+PartialSuffix first, and the
+// completed ranges are recorded in path+PartialSuffix+StateSuffix, so a
+// failed download is resumed by the next call with the same path, unless the
+// ETag or last modified time of object changed. When all ranges completed,
+// the file is verified with the ETag if it's a MD5, and renamed to path.
+func (d *Downloader) DownloadFileWithOptions(ctx context.Context, path string, objectKey string, opts *Options) (size int64, err error) {
+	ctx, span := d.startSpan(ctx, objectKey)
+	defer func() {
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}()
+
+	ctx = log.ContextWithDefaultLogger(ctx, d.bucket.Logger)
+	logger := log.FromContext(ctx)
+	info, err := d.head(ctx, objectKey, opts)
+	if err != nil {
+		logger.Error("head object", zap.String("key", objectKey), zap.Error(err))
+		return 0, err
+	}
+
+	partial := path + PartialSuffix
+	f, st, err := d.openPartial(ctx, partial, objectKey, info)
+	if err != nil {
+		logger.Error("open partial file", zap.String("path", partial), zap.Error(err))
+		return 0, err
+	}
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	tracker := progress.NewTracker(d.Progress, info.size, 0)
+	tracker.Start()
+	defer func() {
+		tracker.Complete(err)
+	}()
+
+	if err = d.downloadRanges(ctx, f, objectKey, info, opts, tracker, st); err != nil {
+		return 0, err
+	}
+
+	if md5ETag.MatchString(strings.Trim(info.etag, `"`)) && !opts.encrypted() {
+		if err = verifyMD5(f, strings.Trim(info.etag, `"`)); err != nil {
+			logger.Error("verify downloaded file", zap.String("path", partial), zap.Error(err))
+			f.Close()
+			f = nil
+			os.Remove(partial)
+			st.remove()
+			return 0, err
+		}
+	}
+
+	if err = f.Sync(); err != nil {
+		return 0, err
+	}
+	err = f.Close()
+	f = nil
+	if err != nil {
+		return 0, err
+	}
+	if err = os.Rename(partial, path); err != nil {
+		return 0, err
+	}
+	if err := st.remove(); err != nil {
+		logger.Warn("remove download state", zap.String("state", st.path), zap.Error(err))
+	}
+	return info.size, nil
+}
+
+// openPartial opens the partial file and its state, the partial file is
+// truncated if the state is missing or belongs to another version of object.
+func (d *Downloader) openPartial(ctx context.Context, partial string, objectKey string, info *objectInfo) (*os.File, *state, error) {
+	logger := log.FromContext(ctx)
+	bucketName := service.StringValue(d.bucket.Properties.BucketName)
+
+	st, err := loadState(partial + StateSuffix)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st != nil && !st.matches(bucketName, objectKey, info) {
+		logger.Info("object changed, download again", zap.String("key", objectKey))
+		st = nil
+	}
+	if st != nil {
+		if _, err := os.Stat(partial); err != nil {
+			st = nil
+		}
+	}
+	if st != nil {
+		f, err := os.OpenFile(partial, os.O_RDWR, 0644)
+		return f, st, err
+	}
+
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	st = &state{
+		path:         partial + StateSuffix,
+		done:         map[byteRange]bool{},
+		Bucket:       bucketName,
+		Key:          objectKey,
+		Size:         info.size,
+		ETag:         info.etag,
+		LastModified: info.lastModified,
+	}
+	if err = st.save(); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, st, nil
+}
+
+func verifyMD5(f *os.File, expected string) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), expected) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// encrypted checks whether the object is encrypted with customer key, whose
+// ETag is not the MD5 of content.
+func (o *Options) encrypted() bool {
+	return o != nil && o.XQSEncryptionCustomerKey != nil
+}
@@ -0,0 +1,101 @@
+package download
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/client/internal/statefile"
+)
+
+// state records the completed ranges of a download into file, so that the
+// download can be resumed after the process restarts.
+type state struct {
+	path string
+	mu   sync.Mutex
+	done map[byteRange]bool
+
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	// Ranges are the completed ranges in the order of completion.
+	Ranges []byteRange `json:"ranges"`
+}
+
+// byteRange is the range [Start, End] of object.
+type byteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// loadState reads the state from path, it returns nil if the state doesn't
+// exist or is broken.
+func loadState(path string) (*state, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	st := &state{path: path}
+	if err = json.Unmarshal(content, st); err != nil {
+		// The partial file is downloaded again.
+		return nil, nil
+	}
+	st.done = map[byteRange]bool{}
+	for _, r := range st.Ranges {
+		st.done[r] = true
+	}
+	return st, nil
+}
+
+// matches checks whether the state belongs to the same version of object.
+func (st *state) matches(bucket, key string, info *objectInfo) bool {
+	return st.Bucket == bucket &&
+		st.Key == key &&
+		st.Size == info.size &&
+		st.ETag == info.etag &&
+		st.LastModified.Equal(info.lastModified)
+}
+
+// completed checks whether the range is downloaded.
+func (st *state) completed(start, end int64) bool {
+	if st == nil {
+		return false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.done[byteRange{Start: start, End: end}]
+}
+
+// record saves the completed range into state.
+func (st *state) record(start, end int64) error {
+	if st == nil {
+		return nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	r := byteRange{Start: start, End: end}
+	st.done[r] = true
+	st.Ranges = append(st.Ranges, r)
+	return st.saveLocked()
+}
+
+func (st *state) save() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.saveLocked()
+}
+
+func (st *state) saveLocked() error {
+	return statefile.Save(st.path, st)
+}
+
+func (st *state) remove() error {
+	return statefile.Remove(st.path)
+}
@@ -0,0 +1,73 @@
+package download
+
+import (
+	"io"
+	"sync"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/client/progress"
+)
+
+// offsetWriter writes into w from offset, and adds the written bytes to
+// tracker. The error of w is kept to tell it from the errors of body.
+type offsetWriter struct {
+	w       io.WriterAt
+	offset  int64
+	tracker *progress.Tracker
+
+	err error
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.offset)
+	o.offset += int64(n)
+	o.tracker.Add(int64(n))
+	if err != nil {
+		o.err = err
+	}
+	return n, err
+}
+
+// sequentialWriter turns io.Writer into io.WriterAt by blocking writes
+// until all the bytes before their offsets are written.
+type sequentialWriter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	w      io.Writer
+	offset int64
+	err    error
+}
+
+func newSequentialWriter(w io.Writer) *sequentialWriter {
+	s := &sequentialWriter{w: w}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+func (s *sequentialWriter) WriteAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for off != s.offset && s.err == nil {
+		s.cond.Wait()
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	n, err := s.w.Write(p)
+	s.offset += int64(n)
+	if err != nil {
+		s.err = err
+	}
+	s.cond.Broadcast()
+	return n, err
+}
+
+// abort fails all the pending and later writes with err.
+func (s *sequentialWriter) abort(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.cond.Broadcast()
+}
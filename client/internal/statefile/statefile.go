@@ -0,0 +1,32 @@
+// Package statefile saves the states of transfers which can be resumed after
+// the process restarts.
+package statefile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// Save writes v in JSON into a temporary file and renames it to path, so
+// that a crash never leaves a broken state.
+func Save(path string, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Remove removes the state at path, it's not an error if it doesn't exist.
+func Remove(path string) error {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
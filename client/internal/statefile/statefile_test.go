@@ -0,0 +1,33 @@
+package statefile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveAndRemove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statefile")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state")
+
+	assert.Nil(t, Save(path, map[string]int{"parts": 1}))
+	assert.Nil(t, Save(path, map[string]int{"parts": 2}))
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	var v map[string]int
+	assert.Nil(t, json.Unmarshal(content, &v))
+	assert.Equal(t, 2, v["parts"])
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Nil(t, Remove(path))
+	assert.Nil(t, Remove(path))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
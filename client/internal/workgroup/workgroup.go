@@ -0,0 +1,105 @@
+// Package workgroup runs the parts of a transfer concurrently.
+package workgroup
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs functions in goroutines, at most limit of them at the same
+// time. The first failure cancels the context of group, so the remaining
+// parts stop early.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	tokens chan struct{}
+	wg     sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+
+	// OnFail is called once with the first error if it's not nil.
+	OnFail func(err error)
+}
+
+// New creates a group running at most limit functions at the same time,
+// limit is 1 if it's not positive. The returned context is canceled at the
+// first failure.
+func New(ctx context.Context, limit int) (*Group, context.Context) {
+	if limit <= 0 {
+		limit = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		tokens: make(chan struct{}, limit),
+	}, ctx
+}
+
+// Limit returns the max number of functions running at the same time.
+func (g *Group) Limit() int {
+	return cap(g.tokens)
+}
+
+// Acquire waits for a free slot, which must be passed to Go or freed by
+// Release. It returns false if the context of group is done.
+func (g *Group) Acquire() bool {
+	select {
+	case g.tokens <- struct{}{}:
+		if g.ctx.Err() != nil {
+			g.Release()
+			return false
+		}
+		return true
+	case <-g.ctx.Done():
+		return false
+	}
+}
+
+// Release frees the slot acquired.
+func (g *Group) Release() {
+	<-g.tokens
+}
+
+// Go runs fn in a goroutine in the slot acquired, the slot is freed when fn
+// returns. The error of fn fails the group.
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.Release()
+		if err := fn(); err != nil {
+			g.Fail(err)
+		}
+	}()
+}
+
+// Fail records err and cancels the context of group, only the first error
+// is kept.
+func (g *Group) Fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err != nil {
+		return
+	}
+	g.err = err
+	g.cancel()
+	if g.OnFail != nil {
+		g.OnFail(err)
+	}
+}
+
+// Wait waits for the functions started by Go, and returns the first error,
+// or the error of the parent context if it's done while no function failed.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	defer g.cancel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err != nil {
+		return g.err
+	}
+	return g.ctx.Err()
+}
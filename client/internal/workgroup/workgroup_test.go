@@ -0,0 +1,60 @@
+package workgroup
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupLimit(t *testing.T) {
+	group, _ := New(context.Background(), 2)
+	assert.Equal(t, 2, group.Limit())
+
+	var running, max int32
+	for i := 0; i < 10 && group.Acquire(); i++ {
+		group.Go(func() error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	assert.NoError(t, group.Wait())
+	assert.True(t, max <= 2)
+
+	group, _ = New(context.Background(), 0)
+	assert.Equal(t, 1, group.Limit())
+}
+
+func TestGroupFail(t *testing.T) {
+	var failed []error
+	group, ctx := New(context.Background(), 1)
+	group.OnFail = func(err error) { failed = append(failed, err) }
+
+	first, second := errors.New("first"), errors.New("second")
+	assert.True(t, group.Acquire())
+	group.Go(func() error { return first })
+	<-ctx.Done()
+	group.Fail(second)
+
+	assert.False(t, group.Acquire())
+	assert.Equal(t, first, group.Wait())
+	assert.Equal(t, []error{first}, failed)
+}
+
+func TestGroupParentCanceled(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	group, _ := New(parent, 1)
+	cancel()
+
+	assert.False(t, group.Acquire())
+	assert.Equal(t, context.Canceled, group.Wait())
+}
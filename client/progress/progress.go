@@ -0,0 +1,292 @@
+// Package progress reports the progress of uploads and downloads.
+package progress
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is the default min interval between two bytes events.
+const DefaultInterval = 100 * time.Millisecond
+
+// EventType is the type of progress event.
+type EventType int
+
+const (
+	// TransferStarted is sent once before any bytes are transferred.
+	TransferStarted EventType = iota
+	// BytesTransferred is sent when bytes are transferred, at most once
+	// per interval.
+	BytesTransferred
+	// PartStarted is sent when a part starts transferring.
+	PartStarted
+	// PartCompleted is sent when a part is transferred.
+	PartCompleted
+	// PartRetried is sent when a part is transferred again after a failed
+	// attempt, bytes of the attempt are subtracted if they're sent again.
+	PartRetried
+	// PartFailed is sent when a part fails.
+	PartFailed
+	// TransferCompleted is sent once when the transfer succeeds.
+	TransferCompleted
+	// TransferFailed is sent once when the transfer fails.
+	TransferFailed
+)
+
+var eventTypeNames = map[EventType]string{
+	TransferStarted:   "TransferStarted",
+	BytesTransferred:  "BytesTransferred",
+	PartStarted:       "PartStarted",
+	PartCompleted:     "PartCompleted",
+	PartRetried:       "PartRetried",
+	PartFailed:        "PartFailed",
+	TransferCompleted: "TransferCompleted",
+	TransferFailed:    "TransferFailed",
+}
+
+func (t EventType) String() string {
+	return eventTypeNames[t]
+}
+
+// Event is a progress event of a transfer.
+type Event struct {
+	Type EventType
+	// TransferredBytes is the bytes transferred so far.
+	TransferredBytes int64
+	// TotalBytes is the bytes of the whole transfer, -1 means unknown.
+	TotalBytes int64
+	// PartNumber is the number of part in part events.
+	PartNumber int
+	// Err is the error of failed events.
+	Err error
+}
+
+// Listener receives progress events.
+type Listener interface {
+	OnProgress(e Event)
+}
+
+// ListenerFunc is an adapter to use ordinary function as Listener.
+type ListenerFunc func(e Event)
+
+// OnProgress calls f(e).
+func (f ListenerFunc) OnProgress(e Event) {
+	f(e)
+}
+
+// Tracker tracks the progress of a transfer and reports it to listener.
+// It's safe for concurrent use, and the listener is never called
+// concurrently. All methods of a nil Tracker are no-ops.
+type Tracker struct {
+	mu       sync.Mutex
+	listener Listener
+	interval time.Duration
+
+	total       int64
+	transferred int64
+	reported    int64
+	last        time.Time
+	started     bool
+	done        bool
+}
+
+// NewTracker creates a tracker of a transfer with total bytes, total is -1
+// if unknown. BytesTransferred events are sent at most once per interval,
+// DefaultInterval is used if interval is not positive. It returns nil if
+// listener is nil.
+func NewTracker(listener Listener, total int64, interval time.Duration) *Tracker {
+	if listener == nil {
+		return nil
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Tracker{
+		listener: listener,
+		interval: interval,
+		total:    total,
+	}
+}
+
+// Start sends the TransferStarted event, it's sent only once.
+func (t *Tracker) Start() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startLocked()
+}
+
+// Add adds n bytes to transferred bytes, n can be negative.
+func (t *Tracker) Add(n int64) {
+	if t == nil || n == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startLocked()
+	t.transferred += n
+	if now := time.Now(); now.Sub(t.last) >= t.interval {
+		t.last = now
+		t.sendLocked(Event{Type: BytesTransferred})
+	}
+}
+
+// PartStarted sends the PartStarted event.
+func (t *Tracker) PartStarted(partNumber int) {
+	t.send(Event{Type: PartStarted, PartNumber: partNumber})
+}
+
+// PartCompleted sends the PartCompleted event.
+func (t *Tracker) PartCompleted(partNumber int) {
+	t.send(Event{Type: PartCompleted, PartNumber: partNumber})
+}
+
+// PartRetried sends the PartRetried event, it doesn't change the
+// transferred bytes, call Add with negative n to revert them.
+func (t *Tracker) PartRetried(partNumber int) {
+	t.send(Event{Type: PartRetried, PartNumber: partNumber})
+}
+
+// PartFailed sends the PartFailed event.
+func (t *Tracker) PartFailed(partNumber int, err error) {
+	t.send(Event{Type: PartFailed, PartNumber: partNumber, Err: err})
+}
+
+// Complete sends the pending BytesTransferred event and then the
+// TransferCompleted event, or TransferFailed if err is not nil.
+// Events after Complete are dropped.
+func (t *Tracker) Complete(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return
+	}
+	t.startLocked()
+	if t.transferred != t.reported {
+		t.sendLocked(Event{Type: BytesTransferred})
+	}
+	if err != nil {
+		t.sendLocked(Event{Type: TransferFailed, Err: err})
+	} else {
+		t.sendLocked(Event{Type: TransferCompleted})
+	}
+	t.done = true
+}
+
+// Reader returns a reader which adds the bytes read from r to the tracker.
+// The returned reader is seekable if r is, seeking back after reading
+// subtracts the bytes read, as the body is going to be sent again.
+func (t *Tracker) Reader(r io.Reader) io.Reader {
+	return t.PartReader(-1, r)
+}
+
+// PartReader works like Reader, and sends the PartRetried event when the
+// part is seeked back after reading. partNumber -1 means not a part.
+func (t *Tracker) PartReader(partNumber int, r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	cr := &countingReader{t: t, r: r, partNumber: partNumber}
+	if s, ok := r.(io.ReadSeeker); ok {
+		return &countingReadSeeker{countingReader: cr, s: s}
+	}
+	return cr
+}
+
+// ReadCloser works like Reader, and completes the tracker when rc reaches
+// EOF or fails. It's useful to track the body of GetObject.
+func (t *Tracker) ReadCloser(rc io.ReadCloser) io.ReadCloser {
+	if t == nil {
+		return rc
+	}
+	t.Start()
+	return &trackedReadCloser{
+		Reader: t.Reader(rc),
+		Closer: rc,
+		t:      t,
+	}
+}
+
+func (t *Tracker) send(e Event) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startLocked()
+	t.sendLocked(e)
+}
+
+func (t *Tracker) startLocked() {
+	if t.started {
+		return
+	}
+	t.started = true
+	t.sendLocked(Event{Type: TransferStarted})
+}
+
+func (t *Tracker) sendLocked(e Event) {
+	if t.done {
+		return
+	}
+	e.TransferredBytes = t.transferred
+	e.TotalBytes = t.total
+	if e.Type == BytesTransferred {
+		t.reported = t.transferred
+	}
+	t.listener.OnProgress(e)
+}
+
+type countingReader struct {
+	t          *Tracker
+	r          io.Reader
+	partNumber int
+
+	// read is the bytes read since the last seek.
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	r.t.Add(int64(n))
+	return n, err
+}
+
+type countingReadSeeker struct {
+	*countingReader
+	s io.Seeker
+}
+
+func (r *countingReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if r.read > 0 {
+		r.t.Add(-r.read)
+		if r.partNumber >= 0 {
+			r.t.PartRetried(r.partNumber)
+		}
+		r.read = 0
+	}
+	return r.s.Seek(offset, whence)
+}
+
+type trackedReadCloser struct {
+	io.Reader
+	io.Closer
+	t *Tracker
+}
+
+func (r *trackedReadCloser) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.t.Complete(nil)
+	} else if err != nil {
+		r.t.Complete(err)
+	}
+	return n, err
+}
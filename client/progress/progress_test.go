@@ -0,0 +1,133 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) OnProgress(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) types() []EventType {
+	types := make([]EventType, 0, len(r.events))
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestNilTracker(t *testing.T) {
+	tracker := NewTracker(nil, 10, 0)
+	assert.Nil(t, tracker)
+
+	r := strings.NewReader("content")
+	assert.Equal(t, r, tracker.Reader(r))
+	tracker.Start()
+	tracker.Add(10)
+	tracker.PartStarted(0)
+	tracker.Complete(nil)
+}
+
+func TestTrackerRateLimited(t *testing.T) {
+	rec := &recorder{}
+	tracker := NewTracker(rec, 1000, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				tracker.Add(10)
+			}
+		}()
+	}
+	wg.Wait()
+	tracker.Complete(nil)
+	tracker.Add(10)
+
+	assert.Equal(t, []EventType{TransferStarted, BytesTransferred, BytesTransferred, TransferCompleted}, rec.types())
+	last := rec.events[len(rec.events)-1]
+	assert.Equal(t, int64(1000), last.TransferredBytes)
+	assert.Equal(t, int64(1000), last.TotalBytes)
+}
+
+func TestPartReaderRetry(t *testing.T) {
+	rec := &recorder{}
+	tracker := NewTracker(rec, 7, time.Nanosecond)
+
+	r := tracker.PartReader(3, strings.NewReader("content")).(io.ReadSeeker)
+	_, err := r.Seek(0, io.SeekEnd)
+	assert.Nil(t, err)
+	_, err = r.Seek(0, io.SeekStart)
+	assert.Nil(t, err)
+	content, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "content", string(content))
+
+	_, err = r.Seek(0, io.SeekStart)
+	assert.Nil(t, err)
+	_, err = ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	tracker.PartCompleted(3)
+
+	var retried *Event
+	for i, e := range rec.events {
+		if e.Type == PartRetried {
+			retried = &rec.events[i]
+		}
+	}
+	assert.NotNil(t, retried)
+	assert.Equal(t, 3, retried.PartNumber)
+	assert.Equal(t, int64(0), retried.TransferredBytes)
+	assert.Equal(t, int64(7), rec.events[len(rec.events)-1].TransferredBytes)
+}
+
+func TestReadCloser(t *testing.T) {
+	rec := &recorder{}
+	tracker := NewTracker(rec, 7, time.Hour)
+
+	body := tracker.ReadCloser(ioutil.NopCloser(bytes.NewReader([]byte("content"))))
+	_, ok := body.(io.Seeker)
+	assert.False(t, ok)
+	content, err := ioutil.ReadAll(body)
+	assert.Nil(t, err)
+	assert.Equal(t, "content", string(content))
+	assert.Nil(t, body.Close())
+	assert.Equal(t, TransferCompleted, rec.events[len(rec.events)-1].Type)
+	assert.Equal(t, int64(7), rec.events[len(rec.events)-1].TransferredBytes)
+
+	rec = &recorder{}
+	tracker = NewTracker(rec, -1, time.Hour)
+	failure := errors.New("failure")
+	body = tracker.ReadCloser(ioutil.NopCloser(io.MultiReader(strings.NewReader("con"), &errorReader{failure})))
+	_, err = ioutil.ReadAll(body)
+	assert.Equal(t, failure, err)
+	assert.Equal(t, TransferFailed, rec.events[len(rec.events)-1].Type)
+	assert.Equal(t, failure, rec.events[len(rec.events)-1].Err)
+	assert.Equal(t, int64(-1), rec.events[len(rec.events)-1].TotalBytes)
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,146 @@
+package upload
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/client/internal/statefile"
+)
+
+// ErrSourceChanged is returned when resuming an upload whose source has
+// changed since the checkpoint was written, the stale upload is aborted.
+var ErrSourceChanged = errors.New("the source changed since the upload started")
+
+// fingerprintHeadSize is the size of the head of source used in fingerprint.
+const fingerprintHeadSize = 64 * 1024
+
+// checkpoint records the progress of a multipart upload, so that the upload
+// can be resumed after the process restarts.
+type checkpoint struct {
+	path string
+	mu   sync.Mutex
+
+	Bucket      string      `json:"bucket"`
+	Key         string      `json:"key"`
+	UploadID    string      `json:"upload_id"`
+	PartSize    int         `json:"part_size"`
+	Fingerprint fingerprint `json:"fingerprint"`
+	// Parts maps the number of uploaded parts to their ETags.
+	Parts map[int]string `json:"parts"`
+}
+
+// fingerprint identifies the content of source.
+type fingerprint struct {
+	Size int64 `json:"size"`
+	// ModTime is the modification time in unix nanoseconds of files.
+	ModTime int64 `json:"mod_time,omitempty"`
+	// Head is the md5 of the head of source.
+	Head string `json:"head"`
+}
+
+// newFingerprint builds the fingerprint of seekable source, the offset of
+// source is kept unchanged.
+func newFingerprint(fd io.Reader) (fingerprint, error) {
+	fp := fingerprint{}
+	size, err := getFileSize(fd)
+	if err != nil {
+		return fp, err
+	}
+	fp.Size = size
+
+	if f, ok := fd.(interface{ Stat() (os.FileInfo, error) }); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return fp, err
+		}
+		fp.ModTime = info.ModTime().UnixNano()
+	}
+
+	head := make([]byte, fingerprintHeadSize)
+	if size < fingerprintHeadSize {
+		head = head[:size]
+	}
+	switch r := fd.(type) {
+	case io.ReaderAt:
+		_, err = r.ReadAt(head, 0)
+	case io.ReadSeeker:
+		var pos int64
+		pos, err = r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fp, err
+		}
+		defer r.Seek(pos, io.SeekStart)
+		if _, err = r.Seek(0, io.SeekStart); err != nil {
+			return fp, err
+		}
+		_, err = io.ReadFull(r, head)
+	}
+	if err != nil && err != io.EOF {
+		return fp, err
+	}
+	sum := md5.Sum(head)
+	fp.Head = hex.EncodeToString(sum[:])
+	return fp, nil
+}
+
+// loadCheckpoint reads the checkpoint from path, it returns nil if the
+// checkpoint doesn't exist.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := &checkpoint{path: path}
+	if err = json.Unmarshal(content, cp); err != nil {
+		return nil, err
+	}
+	if cp.Parts == nil {
+		cp.Parts = map[int]string{}
+	}
+	return cp, nil
+}
+
+// etag returns the ETag of the uploaded part.
+func (cp *checkpoint) etag(partNumber int) (string, bool) {
+	if cp == nil {
+		return "", false
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	etag, ok := cp.Parts[partNumber]
+	return etag, ok
+}
+
+// record saves the uploaded part into checkpoint.
+func (cp *checkpoint) record(partNumber int, etag string) error {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.Parts[partNumber] = etag
+	return cp.saveLocked()
+}
+
+func (cp *checkpoint) save() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.saveLocked()
+}
+
+func (cp *checkpoint) saveLocked() error {
+	return statefile.Save(cp.path, cp)
+}
+
+func (cp *checkpoint) remove() error {
+	return statefile.Remove(cp.path)
+}
@@ -9,8 +9,6 @@ import (
 const (
 	// QingStor has a max upload parts limit to 10000.
 	maxUploadParts = 10000
-	// We read from stream for read 1024B.
-	segmentSize = 1024
 )
 
 // chunk provides a struct to read file
@@ -34,10 +32,24 @@ func newChunk(fd io.Reader, partSize int) *chunk {
 
 // nextPart reads the next part of the file
 func (f *chunk) nextPart() (io.ReadSeeker, error) {
-	type readerAtSeeker interface {
-		io.ReaderAt
-		io.ReadSeeker
-	}
+	return f.nextPartWithBuffer(nil)
+}
+
+// isReaderAt checks whether parts are read through section readers, which
+// can be read concurrently without buffering.
+func (f *chunk) isReaderAt() bool {
+	_, ok := f.fd.(readerAtSeeker)
+	return ok
+}
+
+type readerAtSeeker interface {
+	io.ReaderAt
+	io.ReadSeeker
+}
+
+// nextPartWithBuffer reads the next part of the file, parts of plain readers
+// are read into buf, which is allocated if it's smaller than the part size.
+func (f *chunk) nextPartWithBuffer(buf []byte) (io.ReadSeeker, error) {
 	switch r := f.fd.(type) {
 	case readerAtSeeker:
 		var sectionSize int64
@@ -54,26 +66,18 @@ func (f *chunk) nextPart() (io.ReadSeeker, error) {
 		f.cur += sectionSize
 		return seekReader, err
 	case io.Reader:
-		buf := make([]byte, segmentSize)
-		var n, lenBuf int
-		var err error
-		var chunk []byte
-		for {
-			n, _ = r.Read(buf)
-			if n == 0 {
-				if lenBuf == 0 {
-					err = io.EOF
-				}
-				break
-			}
-			lenBuf = lenBuf + n
-			chunk = append(chunk, buf...)
-			if lenBuf == f.partSize {
-				break
-			}
+		if len(buf) < f.partSize {
+			buf = make([]byte, f.partSize)
+		}
+		n, err := io.ReadFull(r, buf[:f.partSize])
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
 		}
-		partBody := bytes.NewReader(chunk[:lenBuf])
-		return partBody, err
+		f.cur += int64(n)
+		return bytes.NewReader(buf[:n]), nil
 	default:
 		return nil, errors.New("file does not support read")
 	}
@@ -104,3 +108,35 @@ func (f *chunk) initSize() {
 		}
 	}
 }
+
+// bufferPool reuses the buffers of parts read from plain readers.
+type bufferPool struct {
+	size    int
+	buffers chan []byte
+}
+
+func newBufferPool(capacity, size int) *bufferPool {
+	return &bufferPool{
+		size:    size,
+		buffers: make(chan []byte, capacity),
+	}
+}
+
+func (p *bufferPool) get() []byte {
+	select {
+	case buf := <-p.buffers:
+		return buf
+	default:
+		return make([]byte, p.size)
+	}
+}
+
+func (p *bufferPool) put(buf []byte) {
+	if buf == nil {
+		return
+	}
+	select {
+	case p.buffers <- buf:
+	default:
+	}
+}
@@ -0,0 +1,99 @@
+package upload
+
+import (
+	"io"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/internal/multipart"
+	"github.com/qingstor/qingstor-sdk-go/v4/request"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// Options are the headers of the uploaded object, they are applied to
+// PutObject of small files and InitiateMultipartUpload of large files alike,
+// the encryption headers are sent with every part as well.
+type Options struct {
+	// Cache-Control of the object
+	CacheControl *string
+	// Content-Encoding of the object
+	ContentEncoding *string
+	// Content-Type of the object
+	ContentType *string
+	// Encryption algorithm of the object
+	XQSEncryptionCustomerAlgorithm *string
+	// Encryption key of the object
+	XQSEncryptionCustomerKey *string
+	// MD5sum of encryption key
+	XQSEncryptionCustomerKeyMD5 *string
+	// User-defined metadata
+	XQSMetaData *map[string]string
+	// Storage class of the object, STANDARD or STANDARD_IA
+	XQSStorageClass *string
+}
+
+func (o *Options) putObjectInput(body io.Reader) *service.PutObjectInput {
+	input := &service.PutObjectInput{Body: body}
+	if o == nil {
+		return input
+	}
+	input.CacheControl = o.CacheControl
+	input.ContentEncoding = o.ContentEncoding
+	input.ContentType = o.ContentType
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	input.XQSMetaData = o.XQSMetaData
+	input.XQSStorageClass = o.XQSStorageClass
+	return input
+}
+
+func (o *Options) initiateMultipartUploadInput() *service.InitiateMultipartUploadInput {
+	input := &service.InitiateMultipartUploadInput{}
+	if o == nil {
+		return input
+	}
+	input.ContentType = o.ContentType
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	input.XQSMetaData = o.XQSMetaData
+	input.XQSStorageClass = o.XQSStorageClass
+	return input
+}
+
+// initiateHeaders returns the handler which sets the headers missing in
+// InitiateMultipartUploadInput.
+func (o *Options) initiateHeaders() request.NamedHandler {
+	if o == nil {
+		return multipart.InitiateHeaders(nil, nil)
+	}
+	return multipart.InitiateHeaders(o.CacheControl, o.ContentEncoding)
+}
+
+func (o *Options) uploadMultipartInput(uploadID *string, partNumber int, body io.Reader) *service.UploadMultipartInput {
+	input := &service.UploadMultipartInput{
+		UploadID:   uploadID,
+		PartNumber: service.Int(partNumber),
+		Body:       body,
+	}
+	if o == nil {
+		return input
+	}
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	return input
+}
+
+func (o *Options) completeMultipartUploadInput(uploadID *string, parts []*service.ObjectPartType) *service.CompleteMultipartUploadInput {
+	input := &service.CompleteMultipartUploadInput{
+		UploadID:    uploadID,
+		ObjectParts: parts,
+	}
+	if o == nil {
+		return input
+	}
+	input.XQSEncryptionCustomerAlgorithm = o.XQSEncryptionCustomerAlgorithm
+	input.XQSEncryptionCustomerKey = o.XQSEncryptionCustomerKey
+	input.XQSEncryptionCustomerKeyMD5 = o.XQSEncryptionCustomerKeyMD5
+	return input
+}
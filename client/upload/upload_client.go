@@ -2,28 +2,62 @@ package upload
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 
+	"github.com/qingstor/qingstor-sdk-go/v4/client/internal/workgroup"
+	"github.com/qingstor/qingstor-sdk-go/v4/client/progress"
+	"github.com/qingstor/qingstor-sdk-go/v4/internal/multipart"
 	"github.com/qingstor/qingstor-sdk-go/v4/log"
+	qserrors "github.com/qingstor/qingstor-sdk-go/v4/request/errors"
 	"github.com/qingstor/qingstor-sdk-go/v4/service"
+	"github.com/qingstor/qingstor-sdk-go/v4/tracing"
 )
 
 // Uploader struct provides a struct to upload
 type Uploader struct {
 	bucket   *service.Bucket
 	partSize int
+
+	// Concurrency is the max number of parts uploaded at the same time.
+	// Parts of plain readers are buffered in memory, which takes up to
+	// Concurrency * part size bytes.
+	Concurrency int
+
+	// CheckpointPath is the path of the checkpoint file which records the
+	// progress of multipart upload, an interrupted upload is resumed from it
+	// by the next upload of the same object. Empty means no checkpoint.
+	CheckpointPath string
+
+	// KeepOnFailure keeps the multipart upload when the upload fails, so that
+	// it can be resumed later. Otherwise the upload is aborted to release the
+	// storage of uploaded parts, unless CheckpointPath is set.
+	KeepOnFailure bool
+
+	// Progress receives the progress events of uploads, nil means no report.
+	Progress progress.Listener
 }
 
 const smallestPartSize int = 1024 * 1024 * 4
 
+// DefaultConcurrency is the default number of parts uploaded at the same time.
+const DefaultConcurrency = 4
+
 // Init creates a uploader struct
 func Init(bucket *service.Bucket, partSize int) *Uploader {
 	return &Uploader{
-		bucket:   bucket,
-		partSize: partSize,
+		bucket:      bucket,
+		partSize:    partSize,
+		Concurrency: DefaultConcurrency,
 	}
 }
 
@@ -34,14 +68,45 @@ func (u *Uploader) Upload(fd io.Reader, objectKey string) error {
 
 // UploadWithContext add support for context
 func (u *Uploader) UploadWithContext(ctx context.Context, fd io.Reader, objectKey string) error {
+	return u.UploadWithOptions(ctx, fd, objectKey, nil)
+}
+
+// UploadWithOptions uploads the object with headers in opts, opts can be nil.
+func (u *Uploader) UploadWithOptions(ctx context.Context, fd io.Reader, objectKey string, opts *Options) (err error) {
+	ctx, span := tracing.Start(ctx, u.bucket.Config.Tracer, "Upload",
+		tracing.String(tracing.AttrBucket, service.StringValue(u.bucket.Properties.BucketName)),
+		tracing.String(tracing.AttrZone, service.StringValue(u.bucket.Properties.Zone)),
+		tracing.String(tracing.AttrKey, objectKey),
+	)
+	defer func() {
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}()
+
+	ctx = log.ContextWithDefaultLogger(ctx, u.bucket.Logger)
 	logger := log.FromContext(ctx)
 	length, err := getFileSize(fd)
 	if err != nil {
 		logger.Error("get file size", zap.Error(err))
 		return err
 	}
+
+	tracker := progress.NewTracker(u.Progress, length, 0)
+	tracker.Start()
+	defer func() {
+		tracker.Complete(err)
+	}()
+
 	if length < int64(smallestPartSize) {
-		_, err := u.bucket.PutObjectWithContext(ctx, objectKey, &service.PutObjectInput{Body: fd})
+		input := opts.putObjectInput(tracker.Reader(fd))
+		input.ContentMD5, err = u.contentMD5(fd.(io.ReadSeeker))
+		if err != nil {
+			logger.Error("hash object", zap.Error(err))
+			return err
+		}
+		_, err = u.bucket.PutObjectWithContext(ctx, objectKey, input)
 		if err != nil {
 			logger.Error("auto switch to put object", zap.Error(err))
 			return err
@@ -53,84 +118,286 @@ func (u *Uploader) UploadWithContext(ctx context.Context, fd io.Reader, objectKe
 		return errors.New("the part size is too small")
 	}
 
-	uploadID, err := u.init(ctx, objectKey)
-	if err != nil {
-		logger.Error("init multipart upload", zap.Error(err))
-		return err
+	var cp *checkpoint
+	if u.CheckpointPath != "" {
+		cp, err = u.resume(ctx, fd, objectKey, opts)
+		if err != nil {
+			logger.Error("resume multipart upload", zap.String("checkpoint", u.CheckpointPath), zap.Error(err))
+			return err
+		}
 	}
 
-	partNumbers, err := u.upload(ctx, fd, uploadID, objectKey)
+	var uploadID *string
+	if cp != nil {
+		uploadID = service.String(cp.UploadID)
+	} else {
+		uploadID, err = u.init(ctx, objectKey, opts)
+		if err != nil {
+			logger.Error("init multipart upload", zap.Error(err))
+			return err
+		}
+	}
+	defer func() {
+		if err != nil && !u.KeepOnFailure && cp == nil {
+			u.abortOnFailure(ctx, objectKey, uploadID)
+		}
+	}()
+
+	partNumbers, err := u.upload(ctx, fd, uploadID, objectKey, cp, opts, tracker)
 	if err != nil {
 		logger.Error("upload part",
 			zap.String("upload id", *uploadID), zap.String("key", objectKey), zap.Error(err))
 		return err
 	}
 
-	err = u.complete(ctx, objectKey, uploadID, partNumbers)
+	err = u.complete(ctx, objectKey, uploadID, partNumbers, opts)
 	if err != nil {
 		logger.Error("complete upload",
 			zap.String("upload id", *uploadID), zap.String("key", objectKey), zap.Error(err))
 		return err
 	}
 
+	if cp != nil {
+		if err := cp.remove(); err != nil {
+			logger.Warn("remove checkpoint", zap.String("checkpoint", cp.path), zap.Error(err))
+		}
+	}
 	return nil
 }
 
-func (u *Uploader) init(ctx context.Context, objectKey string) (*string, error) {
-	output, err := u.bucket.InitiateMultipartUploadWithContext(
+// resume loads the checkpoint and drops the parts which are not found by
+// ListMultipart, a new multipart upload is initiated if there is no
+// checkpoint or the upload no longer exists.
+func (u *Uploader) resume(ctx context.Context, fd io.Reader, objectKey string, opts *Options) (*checkpoint, error) {
+	logger := log.FromContext(ctx)
+	fp, err := newFingerprint(fd)
+	if err != nil {
+		return nil, err
+	}
+	bucketName := service.StringValue(u.bucket.Properties.BucketName)
+
+	cp, err := loadCheckpoint(u.CheckpointPath)
+	if err != nil {
+		return nil, err
+	}
+	if cp != nil && (cp.Bucket != bucketName || cp.Key != objectKey) {
+		return nil, fmt.Errorf("checkpoint %s belongs to %s/%s", cp.path, cp.Bucket, cp.Key)
+	}
+
+	if cp != nil && (cp.Fingerprint != fp || cp.PartSize != u.partSize) {
+		logger.Warn("source changed, abort multipart upload",
+			zap.String("upload id", cp.UploadID), zap.String("key", objectKey))
+		if err = u.abort(ctx, objectKey, service.String(cp.UploadID)); err != nil {
+			return nil, err
+		}
+		if err = cp.remove(); err != nil {
+			return nil, err
+		}
+		return nil, ErrSourceChanged
+	}
+
+	if cp != nil {
+		uploaded, err := u.listParts(ctx, objectKey, service.String(cp.UploadID))
+		if e, ok := err.(*qserrors.QingStorError); ok && e.StatusCode == http.StatusNotFound {
+			logger.Warn("multipart upload not found, start a new one",
+				zap.String("upload id", cp.UploadID), zap.String("key", objectKey))
+			cp = nil
+		} else if err != nil {
+			return nil, err
+		} else {
+			parts := map[int]string{}
+			for _, p := range uploaded {
+				n, etag := service.IntValue(p.PartNumber), service.StringValue(p.Etag)
+				// Parts uploaded but not recorded are still reusable as the
+				// source is unchanged.
+				if recorded, ok := cp.Parts[n]; !ok || sameETag(recorded, etag) {
+					parts[n] = etag
+				}
+			}
+			cp.Parts = parts
+			return cp, cp.save()
+		}
+	}
+
+	uploadID, err := u.init(ctx, objectKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	cp = &checkpoint{
+		path:        u.CheckpointPath,
+		Bucket:      bucketName,
+		Key:         objectKey,
+		UploadID:    *uploadID,
+		PartSize:    u.partSize,
+		Fingerprint: fp,
+		Parts:       map[int]string{},
+	}
+	return cp, cp.save()
+}
+
+// listParts lists all uploaded parts of the multipart upload.
+func (u *Uploader) listParts(ctx context.Context, objectKey string, uploadID *string) ([]*service.ObjectPartType, error) {
+	var parts []*service.ObjectPartType
+	err := u.bucket.NewListMultipartPaginator(objectKey, &service.ListMultipartInput{
+		UploadID: uploadID,
+		Limit:    service.Int(1000),
+	}).EachPart(ctx, func(part *service.ObjectPartType) bool {
+		parts = append(parts, part)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// abortOnFailure aborts the failed upload even if the upload context is
+// canceled, the failure of abort is only logged.
+func (u *Uploader) abortOnFailure(ctx context.Context, objectKey string, uploadID *string) {
+	logger := log.FromContext(ctx)
+	err := multipart.Abort(ctx, func(ctx context.Context) error {
+		return u.abort(ctx, objectKey, uploadID)
+	})
+	if err != nil {
+		logger.Error("abort multipart upload",
+			zap.String("upload id", *uploadID), zap.String("key", objectKey), zap.Error(err))
+	}
+}
+
+func (u *Uploader) abort(ctx context.Context, objectKey string, uploadID *string) error {
+	_, err := u.bucket.AbortMultipartUploadWithContext(
 		ctx,
 		objectKey,
-		&service.InitiateMultipartUploadInput{},
+		&service.AbortMultipartUploadInput{
+			UploadID: uploadID,
+		},
 	)
+	return err
+}
+
+func (u *Uploader) init(ctx context.Context, objectKey string, opts *Options) (*string, error) {
+	r, output, err := u.bucket.InitiateMultipartUploadRequest(objectKey, opts.initiateMultipartUploadInput())
 	if err != nil {
 		return nil, err
 	}
+	r.Handlers.Build.PushBack(opts.initiateHeaders())
+	if err = r.SendWithContext(ctx); err != nil {
+		return nil, err
+	}
 	return output.UploadID, nil
 }
 
-func (u *Uploader) upload(ctx context.Context, fd io.Reader, uploadID *string, objectKey string) ([]*service.ObjectPartType, error) {
+// upload uploads the parts of fd, the parts recorded in cp are skipped.
+func (u *Uploader) upload(ctx context.Context, fd io.Reader, uploadID *string, objectKey string, cp *checkpoint, opts *Options, tracker *progress.Tracker) ([]*service.ObjectPartType, error) {
 	logger := log.FromContext(ctx)
-	var partCnt int
-	partNumbers := []*service.ObjectPartType{}
+	// The group bounds the parts in flight, and the buffers of plain readers.
+	group, ctx := workgroup.New(ctx, u.Concurrency)
 	fileReader := newChunk(fd, u.partSize)
-	for {
-		partBody, err := fileReader.nextPart()
+	pool := newBufferPool(group.Limit(), fileReader.partSize)
+
+	var (
+		mu    sync.Mutex
+		parts []*service.ObjectPartType
+	)
+	for partNumber := 0; group.Acquire(); partNumber++ {
+		var buf []byte
+		if !fileReader.isReaderAt() {
+			buf = pool.get()
+		}
+		partBody, err := fileReader.nextPartWithBuffer(buf)
 		if err == io.EOF {
+			pool.put(buf)
+			group.Release()
 			break
 		}
 		if err != nil {
 			logger.Error("get next part", zap.Error(err))
-			return nil, err
+			group.Release()
+			group.Fail(err)
+			break
 		}
-		_, err = u.bucket.UploadMultipartWithContext(
-			ctx,
-			objectKey,
-			&service.UploadMultipartInput{
-				UploadID:   uploadID,
-				PartNumber: &partCnt,
-				Body:       partBody,
-			},
-		)
-		if err != nil {
-			logger.Error("upload part", zap.String("key", objectKey), zap.Error(err))
-			return nil, err
+
+		if etag, ok := cp.etag(partNumber); ok {
+			size, _ := partBody.Seek(0, io.SeekEnd)
+			tracker.Add(size)
+			tracker.PartCompleted(partNumber)
+			pool.put(buf)
+			group.Release()
+			mu.Lock()
+			parts = append(parts, &service.ObjectPartType{
+				PartNumber: service.Int(partNumber),
+				Etag:       service.String(etag),
+			})
+			mu.Unlock()
+			continue
 		}
-		partNumbers = append(partNumbers, &service.ObjectPartType{
-			PartNumber: service.Int(partCnt - 0),
+
+		partNumber, partBody, buf := partNumber, partBody, buf
+		group.Go(func() error {
+			defer pool.put(buf)
+
+			tracker.PartStarted(partNumber)
+			contentMD5, err := u.contentMD5(partBody)
+			if err != nil {
+				logger.Error("hash part", zap.Int("part number", partNumber), zap.Error(err))
+				tracker.PartFailed(partNumber, err)
+				return err
+			}
+			input := opts.uploadMultipartInput(uploadID, partNumber, tracker.PartReader(partNumber, partBody))
+			input.ContentMD5 = contentMD5
+			etag, err := u.uploadPart(ctx, input, objectKey)
+			if err != nil {
+				logger.Error("upload part", zap.String("key", objectKey), zap.Int("part number", partNumber), zap.Error(err))
+				tracker.PartFailed(partNumber, err)
+				return err
+			}
+			tracker.PartCompleted(partNumber)
+			if err = cp.record(partNumber, service.StringValue(etag)); err != nil {
+				logger.Error("save checkpoint", zap.String("checkpoint", cp.path), zap.Error(err))
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			parts = append(parts, &service.ObjectPartType{
+				PartNumber: service.Int(partNumber),
+				Etag:       etag,
+			})
+			return nil
 		})
-		partCnt++
 	}
-	return partNumbers, nil
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return *parts[i].PartNumber < *parts[j].PartNumber
+	})
+	return parts, nil
+}
+
+// uploadPart uploads a part in a span which is the child of the upload span.
+func (u *Uploader) uploadPart(ctx context.Context, input *service.UploadMultipartInput, objectKey string) (*string, error) {
+	ctx, span := tracing.Start(ctx, u.bucket.Config.Tracer, "Upload Part",
+		tracing.String(tracing.AttrKey, objectKey),
+		tracing.String(tracing.AttrUploadID, *input.UploadID),
+		tracing.Int(tracing.AttrPartNumber, *input.PartNumber),
+	)
+	defer span.End()
+
+	output, err := u.bucket.UploadMultipartWithContext(ctx, objectKey, input)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+	return output.ETag, nil
 }
 
-func (u *Uploader) complete(ctx context.Context, objectKey string, uploadID *string, partNumbers []*service.ObjectPartType) error {
+func (u *Uploader) complete(ctx context.Context, objectKey string, uploadID *string, partNumbers []*service.ObjectPartType, opts *Options) error {
 	_, err := u.bucket.CompleteMultipartUploadWithContext(
 		ctx,
 		objectKey,
-		&service.CompleteMultipartUploadInput{
-			UploadID:    uploadID,
-			ObjectParts: partNumbers,
-		},
+		opts.completeMultipartUploadInput(uploadID, partNumbers),
 	)
 	if err != nil {
 		return err
@@ -138,6 +405,27 @@ func (u *Uploader) complete(ctx context.Context, objectKey string, uploadID *str
 	return nil
 }
 
+// contentMD5 returns the Content-MD5 of body from its offset if integrity
+// check is enabled. It's computed before the tracked body is sent, reading
+// and seeking back the tracked body would be reported as a retry.
+func (u *Uploader) contentMD5(body io.ReadSeeker) (*string, error) {
+	if !u.bucket.Config.EnableIntegrityCheck {
+		return nil, nil
+	}
+	offset, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	h := md5.New()
+	if _, err = io.Copy(h, body); err != nil {
+		return nil, err
+	}
+	if _, err = body.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return service.String(base64.StdEncoding.EncodeToString(h.Sum(nil))), nil
+}
+
 func getFileSize(fd io.Reader) (int64, error) {
 	var length int64 = -1
 	switch r := fd.(type) {
@@ -156,3 +444,8 @@ func getFileSize(fd io.Reader) (int64, error) {
 	}
 	return length, nil
 }
+
+// sameETag compares ETags ignoring the quotes.
+func sameETag(a, b string) bool {
+	return strings.Trim(a, `"`) == strings.Trim(b, `"`)
+}
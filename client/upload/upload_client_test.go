@@ -0,0 +1,403 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/client/progress"
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/qingstortest"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// partRequests returns the part uploads of "key" in requests.
+func partRequests(requests []*http.Request) []*http.Request {
+	var parts []*http.Request
+	for _, r := range requests {
+		if _, ok := r.URL.Query()["part_number"]; ok && r.Method == http.MethodPut && r.URL.Path == "/bucket/key" {
+			parts = append(parts, r)
+		}
+	}
+	return parts
+}
+
+// initiateRequests returns the multipart upload initiations of "key" in
+// requests.
+func initiateRequests(requests []*http.Request) []*http.Request {
+	var initiates []*http.Request
+	for _, r := range requests {
+		if _, ok := r.URL.Query()["uploads"]; ok && r.Method == http.MethodPost && r.URL.Path == "/bucket/key" {
+			initiates = append(initiates, r)
+		}
+	}
+	return initiates
+}
+
+// failAfter makes the parts uploaded after partNumber fail with 400.
+func failAfter(transport *qingstortest.FaultTransport, partNumber int) progress.Listener {
+	return progress.ListenerFunc(func(e progress.Event) {
+		if e.Type == progress.PartCompleted && e.PartNumber == partNumber {
+			transport.SetFaults(qingstortest.Fault{
+				Kind:       qingstortest.FaultStatus,
+				Operation:  "Upload Multipart",
+				StatusCode: http.StatusBadRequest,
+				ErrorCode:  "invalid_request",
+			})
+		}
+	})
+}
+
+// assertAborted checks that no object is written and no upload is left.
+func assertAborted(t *testing.T, server *qingstortest.Server, bucket *service.Bucket) {
+	_, ok := server.Object("bucket", "key")
+	assert.False(t, ok)
+	uploads, err := bucket.ListMultipartUploads(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, uploads.Uploads)
+}
+
+// concurrencyTransport records the max number of parts uploaded at the same
+// time.
+type concurrencyTransport struct {
+	http.RoundTripper
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (t *concurrencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := req.URL.Query()["part_number"]; !ok {
+		return t.RoundTripper.RoundTrip(req)
+	}
+	t.mu.Lock()
+	t.inFlight++
+	if t.inFlight > t.maxInFlight {
+		t.maxInFlight = t.inFlight
+	}
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}()
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// plainReader hides the Seek and ReadAt methods of the underlying reader.
+type plainReader struct {
+	io.Reader
+}
+
+func TestUploadConcurrently(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server)
+	transport.SetFaults(qingstortest.Fault{
+		Kind:      qingstortest.FaultLatency,
+		Operation: "Upload Multipart",
+		Latency:   100 * time.Millisecond,
+	})
+	counter := &concurrencyTransport{RoundTripper: transport}
+	client := *bucket.Config.Connection
+	client.Transport = counter
+	bucket.Config.Connection = &client
+
+	content := qingstortest.Content(smallestPartSize*5 + 100)
+	u := Init(bucket, smallestPartSize)
+	u.Concurrency = 3
+
+	err := u.Upload(bytes.NewReader(content), "key")
+	assert.Nil(t, err)
+	uploaded, _ := server.Object("bucket", "key")
+	assert.Equal(t, content, uploaded)
+	assert.Equal(t, 3, counter.maxInFlight)
+	assert.Equal(t, 6, len(partRequests(server.Requests())))
+	head, err := bucket.HeadObject("key", nil)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(service.StringValue(head.ETag), `-6"`))
+}
+
+func TestUploadPlainReader(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+	initiated, err := bucket.InitiateMultipartUpload("key", nil)
+	assert.Nil(t, err)
+
+	content := qingstortest.Content(smallestPartSize*3 + 100)
+	u := Init(bucket, smallestPartSize)
+
+	parts, err := u.upload(context.Background(), plainReader{bytes.NewReader(content)}, initiated.UploadID, "key", nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(parts))
+	for i, p := range parts {
+		assert.Equal(t, i, *p.PartNumber)
+	}
+	_, err = bucket.CompleteMultipartUpload("key", &service.CompleteMultipartUploadInput{
+		UploadID:    initiated.UploadID,
+		ObjectParts: parts,
+	})
+	assert.Nil(t, err)
+	uploaded, _ := server.Object("bucket", "key")
+	assert.Equal(t, content, uploaded)
+}
+
+func TestUploadPartFailed(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server)
+	transport.SetFaults(qingstortest.Fault{
+		Kind:       qingstortest.FaultStatus,
+		Operation:  "Upload Multipart",
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "invalid_request",
+	})
+
+	content := qingstortest.Content(smallestPartSize*8 + 100)
+	u := Init(bucket, smallestPartSize)
+	u.Concurrency = 2
+
+	err := u.Upload(bytes.NewReader(content), "key")
+	assert.NotNil(t, err)
+	// The parts after the failure are not uploaded.
+	assert.True(t, len(partRequests(server.Requests())) < 9)
+	assertAborted(t, server, bucket)
+}
+
+func TestUploadPartFailedAbort(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server)
+
+	content := qingstortest.Content(smallestPartSize*2 + 100)
+	u := Init(bucket, smallestPartSize)
+	u.Concurrency = 1
+	u.Progress = failAfter(transport, 0)
+
+	err := u.Upload(bytes.NewReader(content), "key")
+	assert.NotNil(t, err)
+	assertAborted(t, server, bucket)
+
+	transport.SetFaults()
+	u.KeepOnFailure = true
+	err = u.Upload(bytes.NewReader(content), "key")
+	assert.NotNil(t, err)
+	uploads, err := bucket.ListMultipartUploads(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(uploads.Uploads))
+}
+
+func TestUploadWithOptions(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+
+	key := bytes.Repeat([]byte("k"), 32)
+	sum := md5.Sum(key)
+	u := Init(bucket, smallestPartSize)
+	opts := &Options{
+		CacheControl:                   service.String("no-cache"),
+		ContentType:                    service.String("text/plain"),
+		XQSEncryptionCustomerAlgorithm: service.String("AES256"),
+		XQSEncryptionCustomerKey:       service.String(base64.StdEncoding.EncodeToString(key)),
+		XQSEncryptionCustomerKeyMD5:    service.String(base64.StdEncoding.EncodeToString(sum[:])),
+		XQSMetaData:                    &map[string]string{"X-QS-Meta-Test": "test"},
+		XQSStorageClass:                service.String("STANDARD_IA"),
+	}
+	check := func(header http.Header) {
+		assert.Equal(t, "no-cache", header.Get("Cache-Control"))
+		assert.Equal(t, "text/plain", header.Get("Content-Type"))
+		assert.Equal(t, "AES256", header.Get("X-QS-Encryption-Customer-Algorithm"))
+		assert.Equal(t, "test", header.Get("X-QS-Meta-Test"))
+		assert.Equal(t, "STANDARD_IA", header.Get("X-QS-Storage-Class"))
+	}
+
+	err := u.UploadWithOptions(context.Background(), bytes.NewReader([]byte("small")), "key", opts)
+	assert.Nil(t, err)
+	requests := server.Requests()
+	check(requests[len(requests)-1].Header)
+
+	content := qingstortest.Content(smallestPartSize*2 + 100)
+	err = u.UploadWithOptions(context.Background(), bytes.NewReader(content), "key", opts)
+	assert.Nil(t, err)
+	requests = server.Requests()
+	check(initiateRequests(requests)[0].Header)
+	part := partRequests(requests)[0]
+	assert.Equal(t, "AES256", part.Header.Get("X-QS-Encryption-Customer-Algorithm"))
+	assert.Equal(t, *opts.XQSEncryptionCustomerKey, part.Header.Get("X-QS-Encryption-Customer-Key"))
+
+	// The object is encrypted by the key.
+	_, err = bucket.HeadObject("key", nil)
+	assert.NotNil(t, err)
+}
+
+func TestUploadResume(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server)
+
+	dir, err := ioutil.TempDir("", "upload")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	content := qingstortest.Content(smallestPartSize*4 + 100)
+	u := Init(bucket, smallestPartSize)
+	u.Concurrency = 1
+	u.CheckpointPath = filepath.Join(dir, "checkpoint")
+	u.Progress = failAfter(transport, 1)
+
+	err = u.Upload(bytes.NewReader(content), "key")
+	assert.NotNil(t, err)
+	cp, err := loadCheckpoint(u.CheckpointPath)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, cp.UploadID)
+	assert.Equal(t, 2, len(cp.Parts))
+
+	// The part uploaded but not recorded is reused as well.
+	transport.SetFaults()
+	_, err = bucket.UploadMultipart("key", &service.UploadMultipartInput{
+		UploadID:   service.String(cp.UploadID),
+		PartNumber: service.Int(3),
+		Body:       bytes.NewReader(content[smallestPartSize*3 : smallestPartSize*4]),
+	})
+	assert.Nil(t, err)
+
+	u.Progress = nil
+	served := len(server.Requests())
+	err = u.Upload(bytes.NewReader(content), "key")
+	assert.Nil(t, err)
+	requests := server.Requests()[served:]
+	assert.Empty(t, initiateRequests(requests))
+	assert.Equal(t, 2, len(partRequests(requests)))
+	uploaded, _ := server.Object("bucket", "key")
+	assert.Equal(t, content, uploaded)
+	head, err := bucket.HeadObject("key", nil)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(service.StringValue(head.ETag), `-5"`))
+
+	_, err = os.Stat(u.CheckpointPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUploadResumeSourceChanged(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server)
+
+	dir, err := ioutil.TempDir("", "upload")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	content := qingstortest.Content(smallestPartSize*2 + 100)
+	u := Init(bucket, smallestPartSize)
+	u.Concurrency = 1
+	u.CheckpointPath = filepath.Join(dir, "checkpoint")
+	u.Progress = failAfter(transport, 0)
+
+	err = u.Upload(bytes.NewReader(content), "key")
+	assert.NotNil(t, err)
+
+	content[0]++
+	err = u.Upload(bytes.NewReader(content), "key")
+	assert.Equal(t, ErrSourceChanged, err)
+	assertAborted(t, server, bucket)
+
+	_, err = os.Stat(u.CheckpointPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUploadProgress(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server)
+
+	var (
+		mu     sync.Mutex
+		events []progress.Event
+	)
+	content := qingstortest.Content(smallestPartSize*2 + 100)
+	u := Init(bucket, smallestPartSize)
+	u.Concurrency = 1
+	u.Progress = progress.ListenerFunc(func(e progress.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+		// The part 1 fails once.
+		switch {
+		case e.Type == progress.PartStarted && e.PartNumber == 1:
+			transport.SetFaults(qingstortest.Fault{
+				Kind:       qingstortest.FaultStatus,
+				Operation:  "Upload Multipart",
+				StatusCode: http.StatusServiceUnavailable,
+			})
+		case e.Type == progress.PartRetried:
+			transport.SetFaults()
+		}
+	})
+
+	err := u.Upload(bytes.NewReader(content), "key")
+	assert.Nil(t, err)
+
+	counts := map[progress.EventType]int{}
+	for _, e := range events {
+		counts[e.Type]++
+		assert.True(t, e.TransferredBytes <= int64(len(content)))
+		assert.Equal(t, int64(len(content)), e.TotalBytes)
+	}
+	assert.Equal(t, 1, counts[progress.TransferStarted])
+	assert.Equal(t, 3, counts[progress.PartStarted])
+	assert.Equal(t, 3, counts[progress.PartCompleted])
+	assert.Equal(t, 1, counts[progress.PartRetried])
+	assert.Equal(t, 1, counts[progress.TransferCompleted])
+	last := events[len(events)-1]
+	assert.Equal(t, progress.TransferCompleted, last.Type)
+	assert.Equal(t, int64(len(content)), last.TransferredBytes)
+}
+
+func TestUploadProgressWithIntegrityCheck(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server, qingstortest.WithConfig(func(conf *config.Config) {
+		conf.EnableIntegrityCheck = true
+	}))
+
+	var (
+		mu     sync.Mutex
+		events []progress.Event
+	)
+	content := qingstortest.Content(smallestPartSize*2 + 100)
+	u := Init(bucket, smallestPartSize)
+	u.Progress = progress.ListenerFunc(func(e progress.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+
+	err := u.Upload(bytes.NewReader(content), "key")
+	assert.Nil(t, err)
+	uploaded, _ := server.Object("bucket", "key")
+	assert.Equal(t, content, uploaded)
+	assert.NotEmpty(t, partRequests(server.Requests())[0].Header.Get("Content-MD5"))
+
+	var transferred int64
+	for _, e := range events {
+		assert.NotEqual(t, progress.PartRetried, e.Type)
+		assert.True(t, e.TransferredBytes >= transferred)
+		transferred = e.TransferredBytes
+	}
+	assert.Equal(t, int64(len(content)), transferred)
+}
@@ -17,6 +17,7 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net"
@@ -30,6 +31,11 @@ import (
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
 
+	"github.com/qingstor/qingstor-sdk-go/v4/credentials"
+	"github.com/qingstor/qingstor-sdk-go/v4/internal/profile"
+	"github.com/qingstor/qingstor-sdk-go/v4/log"
+	"github.com/qingstor/qingstor-sdk-go/v4/metrics"
+	"github.com/qingstor/qingstor-sdk-go/v4/tracing"
 	"github.com/qingstor/qingstor-sdk-go/v4/utils"
 )
 
@@ -53,11 +59,42 @@ type Config struct {
 
 	EnableDualStack bool `yaml:"enable_dual_stack"`
 
-	HTTPSettings HTTPClientSettings
+	// EnableIntegrityCheck sets Content-MD5 of uploads, and verifies the MD5
+	// of uploaded and downloaded content against the ETag of objects
+	EnableIntegrityCheck bool `yaml:"enable_integrity_check"`
+
+	HTTPSettings HTTPClientSettings `yaml:"http_settings"`
+
+	RetrySettings RetrySettings `yaml:"retry_settings"`
+
+	DumpSettings DumpSettings `yaml:"dump_settings"`
+
+	// CredentialsProvider is consulted before signing every request if not nil,
+	// AccessKeyID and SecretAccessKey are ignored then
+	CredentialsProvider credentials.Provider `yaml:"-"`
+
+	// Metrics receives a sample after every API request if not nil
+	Metrics metrics.Sink `yaml:"-"`
+
+	// Tracer starts a span for every API request if not nil
+	Tracer tracing.Tracer `yaml:"-"`
+
+	// HandlerInstallers install request handlers into every service initialized
+	// with this config, see request.HandlerInstallerFunc
+	HandlerInstallers []HandlerInstaller `yaml:"-"`
 
 	Connection *http.Client
 }
 
+// HandlerInstaller installs handlers into the request lifecycle. It's called
+// with the *request.Handlers of every service initialized with the Config,
+// and of every request created without a service.
+// The handlers are passed as interface{} because config can't import request,
+// request.HandlerInstallerFunc implements it with the concrete type.
+type HandlerInstaller interface {
+	InstallHandlers(handlers interface{})
+}
+
 // HTTPClientSettings is the http client settings.
 type HTTPClientSettings struct {
 
@@ -102,6 +139,52 @@ var DefaultHTTPClientSettings = HTTPClientSettings{
 	ExpectContinueTimeout: time.Second * 2,
 }
 
+// RetrySettings is the retry settings of API requests.
+type RetrySettings struct {
+
+	// MaxRetries is the max number of retries after the first attempt, 0 disables retry
+	MaxRetries int `yaml:"max_retries"`
+
+	// MinBackoff is the base delay of the exponential backoff between attempts
+	MinBackoff time.Duration `yaml:"min_backoff"`
+
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+
+	// MaxBufferSize is the max size of a non-seekable body buffered in memory for resending,
+	// requests with larger non-seekable body will be sent only once
+	MaxBufferSize int64 `yaml:"max_buffer_size"`
+}
+
+// DefaultRetrySettings is the default retry settings.
+var DefaultRetrySettings = RetrySettings{
+	MaxRetries:    3,
+	MinBackoff:    time.Millisecond * 100,
+	MaxBackoff:    time.Second * 5,
+	MaxBufferSize: 4 * 1024 * 1024,
+}
+
+// DumpSettings is the settings of dumping requests and responses at debug level.
+// Authorization, the signature query parameter and all X-QS-*-Customer-Key headers are redacted.
+type DumpSettings struct {
+
+	// Enabled dumps method, URL, headers and string to sign of requests, status and headers of responses
+	Enabled bool `yaml:"enabled"`
+
+	// Body dumps bodies of requests and responses as well
+	Body bool `yaml:"body"`
+
+	// MaxBodySize is the max bytes of a body to dump
+	MaxBodySize int64 `yaml:"max_body_size"`
+}
+
+// DefaultDumpSettings is the default dump settings.
+var DefaultDumpSettings = DumpSettings{
+	Enabled:     false,
+	Body:        false,
+	MaxBodySize: 4 * 1024,
+}
+
 // New create a Config with given AccessKeyID and SecretAccessKey.
 func New(accessKeyID, secretAccessKey string) (c *Config, err error) {
 	c, err = NewDefault()
@@ -182,8 +265,10 @@ func (c *Config) Check() (err error) {
 // LoadDefaultConfig loads the default configuration for Config.
 // It returns error if yaml decode failed.
 func (c *Config) LoadDefaultConfig() (err error) {
-	logger, _ := zap.NewDevelopment()
+	logger := log.Default()
 	c.HTTPSettings = DefaultHTTPClientSettings
+	c.RetrySettings = DefaultRetrySettings
+	c.DumpSettings = DefaultDumpSettings
 
 	err = yaml.Unmarshal([]byte(DefaultConfigFileContent), c)
 	if err != nil {
@@ -197,9 +282,22 @@ func (c *Config) LoadDefaultConfig() (err error) {
 }
 
 // LoadUserConfig loads user configuration in ~/.qingstor/config.yaml for Config.
+// The profile named by QINGSTOR_PROFILE is loaded if the file defines it,
+// the top level configuration is loaded otherwise.
 // It returns error if file not found.
 func (c *Config) LoadUserConfig() (err error) {
-	logger, _ := zap.NewDevelopment()
+	return c.loadUserConfig(profile.ResolveEnv)
+}
+
+// LoadUserConfigWithProfile loads given profile in user configuration for Config,
+// the top level configuration is loaded if profile is empty.
+// It returns error if file or profile not found.
+func (c *Config) LoadUserConfigWithProfile(profile string) (err error) {
+	return c.loadUserConfig(withProfile(profile))
+}
+
+func (c *Config) loadUserConfig(resolve resolver) (err error) {
+	logger := log.Default()
 	_, err = os.Stat(GetUserConfigFilePath())
 	if err != nil {
 		logger.Warn("load user config",
@@ -209,13 +307,26 @@ func (c *Config) LoadUserConfig() (err error) {
 		InstallDefaultUserConfig()
 	}
 
-	return c.LoadConfigFromFilePath(GetUserConfigFilePath())
+	return c.loadConfigFromFilePath(GetUserConfigFilePath(), resolve)
 }
 
 // LoadConfigFromFilePath loads configuration from a specified local path.
+// The profile named by QINGSTOR_PROFILE is loaded if the file defines it,
+// the top level configuration is loaded otherwise.
 // It returns error if file not found or yaml decode failed.
 func (c *Config) LoadConfigFromFilePath(filePath string) (err error) {
-	logger, _ := zap.NewDevelopment()
+	return c.loadConfigFromFilePath(filePath, profile.ResolveEnv)
+}
+
+// LoadConfigFromFilePathWithProfile loads given profile in configuration from a specified local path,
+// the top level configuration is loaded if profile is empty.
+// It returns error if file or profile not found or yaml decode failed.
+func (c *Config) LoadConfigFromFilePathWithProfile(filePath string, profile string) (err error) {
+	return c.loadConfigFromFilePath(filePath, withProfile(profile))
+}
+
+func (c *Config) loadConfigFromFilePath(filePath string, resolve resolver) (err error) {
+	logger := log.Default()
 	if strings.Index(filePath, "~/") == 0 {
 		filePath = strings.Replace(filePath, "~/", getHome()+"/", 1)
 	}
@@ -226,15 +337,44 @@ func (c *Config) LoadConfigFromFilePath(filePath string) (err error) {
 		return err
 	}
 
-	return c.LoadConfigFromContent(yamlString)
+	return c.loadConfigFromContent(yamlString, resolve)
 }
 
 // LoadConfigFromContent loads configuration from a given byte slice.
+// The profile named by QINGSTOR_PROFILE is loaded if the content defines it,
+// the top level configuration is loaded otherwise.
 // It returns error if yaml decode failed.
 func (c *Config) LoadConfigFromContent(content []byte) (err error) {
-	logger, _ := zap.NewDevelopment()
+	return c.loadConfigFromContent(content, profile.ResolveEnv)
+}
+
+// LoadConfigFromContentWithProfile loads given profile in configuration from a given byte slice,
+// the top level configuration is loaded if profile is empty.
+// It returns error if profile not found or yaml decode failed.
+func (c *Config) LoadConfigFromContentWithProfile(content []byte, profile string) (err error) {
+	return c.loadConfigFromContent(content, withProfile(profile))
+}
+
+// resolver returns the yaml content of the profile to load in content.
+type resolver func(content []byte) ([]byte, error)
+
+// withProfile returns the resolver of given profile.
+func withProfile(name string) resolver {
+	return func(content []byte) ([]byte, error) {
+		return profile.Resolve(content, name)
+	}
+}
+
+func (c *Config) loadConfigFromContent(content []byte, resolve resolver) (err error) {
+	logger := log.Default()
 	c.LoadDefaultConfig()
 
+	content, err = resolve(content)
+	if err != nil {
+		logger.Error("resolve config profile", zap.Error(err))
+		return
+	}
+
 	err = yaml.Unmarshal(content, c)
 	if err != nil {
 		logger.Error("unmarshal config", zap.Error(err))
@@ -255,6 +395,13 @@ func (c *Config) LoadConfigFromContent(content []byte) (err error) {
 	return
 }
 
+// SetEndpoint sets Endpoint, and the Protocol, Host and Port parsed from it,
+// the endpoint must contain the port, eg: "https://qingstor.com:443".
+func (c *Config) SetEndpoint(endpoint string) error {
+	c.Endpoint = endpoint
+	return c.parseEndpoint()
+}
+
 // FIXME usr.Parse not parse domain without scheme, eg: "qingstor.com"
 func (c *Config) parseEndpoint() error {
 	if c.Endpoint == "" {
@@ -291,6 +438,19 @@ func (c *Config) readCredentialFromEnv() (err error) {
 	return nil
 }
 
+// Credentials returns the credentials to sign requests, which are retrieved from
+// CredentialsProvider if it's not nil, or AccessKeyID and SecretAccessKey otherwise.
+// The returned credentials have no keys if neither was set.
+func (c *Config) Credentials(ctx context.Context) (credentials.Value, error) {
+	if c.CredentialsProvider != nil {
+		return c.CredentialsProvider.Retrieve(ctx)
+	}
+	return credentials.Value{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+	}, nil
+}
+
 // InitHTTPClient : After modifying Config.HTTPSettings, you should always call this to initialize the HTTP Client.
 func (c *Config) InitHTTPClient() {
 	var emptySettings HTTPClientSettings
@@ -18,6 +18,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -83,6 +84,39 @@ log_level: 'debug'
 	// assert.Equal(t, "DEBUG", logger.GetLevel())
 }
 
+func TestLoadRetrySettingsFromContent(t *testing.T) {
+	fileContent := `
+retry_settings:
+  max_retries: 5
+  max_backoff: 10s
+`
+
+	config := Config{}
+	err := config.LoadConfigFromContent([]byte(fileContent))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 5, config.RetrySettings.MaxRetries)
+	assert.Equal(t, 10*time.Second, config.RetrySettings.MaxBackoff)
+	assert.Equal(t, DefaultRetrySettings.MinBackoff, config.RetrySettings.MinBackoff)
+	assert.Equal(t, DefaultRetrySettings.MaxBufferSize, config.RetrySettings.MaxBufferSize)
+}
+
+func TestLoadDumpSettingsFromContent(t *testing.T) {
+	fileContent := `
+dump_settings:
+  enabled: true
+  body: true
+`
+
+	config := Config{}
+	err := config.LoadConfigFromContent([]byte(fileContent))
+	assert.Nil(t, err)
+
+	assert.True(t, config.DumpSettings.Enabled)
+	assert.True(t, config.DumpSettings.Body)
+	assert.Equal(t, DefaultDumpSettings.MaxBodySize, config.DumpSettings.MaxBodySize)
+}
+
 func TestNewDefault(t *testing.T) {
 	config, err := NewDefault()
 	assert.Nil(t, err)
@@ -155,3 +189,15 @@ func TestSupportIP(t *testing.T) {
 	err = c.Check()
 	assert.NotNil(t, err)
 }
+
+func TestSetEndpoint(t *testing.T) {
+	c, err := NewDefault()
+	assert.Nil(t, err)
+
+	err = c.SetEndpoint("http://127.0.0.1:9000")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://127.0.0.1:9000", c.Endpoint)
+	assert.Equal(t, "http", c.Protocol)
+	assert.Equal(t, "127.0.0.1", c.Host)
+	assert.Equal(t, 9000, c.Port)
+}
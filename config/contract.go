@@ -22,6 +22,8 @@ import (
 	"path"
 	"runtime"
 	"strings"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/internal/profile"
 )
 
 // DefaultConfigFileContent is the content of default config file.
@@ -49,6 +51,9 @@ const (
 	// EnvConfigPath is config environment variable.
 	EnvConfigPath = "QINGSTOR_CONFIG_PATH"
 
+	// EnvProfile is config environment variable which selects the profile to load.
+	EnvProfile = profile.Env
+
 	// EnvAccessKeyID is config envrionment variable.
 	EnvAccessKeyID = "QINGSTOR_ACCESS_KEY_ID"
 
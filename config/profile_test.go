@@ -0,0 +1,117 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var profileFileContent = `
+access_key_id: 'ACCESS_KEY_ID'
+secret_access_key: 'SECRET_ACCESS_KEY'
+host: 'qingstor.com'
+port: 443
+protocol: 'https'
+httpsettings:
+  read_timeout: 1m
+
+profiles:
+  private:
+    host: 'private.dev'
+    port: 80
+    protocol: 'http'
+    http_settings:
+      connect_timeout: 5s
+  private-admin:
+    base: private
+    access_key_id: 'ADMIN_ACCESS_KEY_ID'
+    secret_access_key: 'ADMIN_SECRET_ACCESS_KEY'
+    retry_settings:
+      max_retries: 0
+  loop-a:
+    base: loop-b
+  loop-b:
+    base: loop-a
+`
+
+func TestLoadConfigWithoutProfile(t *testing.T) {
+	c := Config{}
+	err := c.LoadConfigFromContentWithProfile([]byte(profileFileContent), "")
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESS_KEY_ID", c.AccessKeyID)
+	assert.Equal(t, "qingstor.com", c.Host)
+	assert.Equal(t, time.Minute, c.HTTPSettings.ReadTimeout)
+}
+
+func TestLoadConfigWithProfile(t *testing.T) {
+	c := Config{}
+	err := c.LoadConfigFromContentWithProfile([]byte(profileFileContent), "private-admin")
+	assert.Nil(t, err)
+
+	assert.Equal(t, "ADMIN_ACCESS_KEY_ID", c.AccessKeyID)
+	assert.Equal(t, "ADMIN_SECRET_ACCESS_KEY", c.SecretAccessKey)
+	assert.Equal(t, "private.dev", c.Host)
+	assert.Equal(t, 80, c.Port)
+	assert.Equal(t, "http", c.Protocol)
+	assert.Equal(t, 5*time.Second, c.HTTPSettings.ConnectTimeout)
+	assert.Equal(t, time.Minute, c.HTTPSettings.ReadTimeout)
+	assert.Equal(t, DefaultHTTPClientSettings.WriteTimeout, c.HTTPSettings.WriteTimeout)
+	assert.Equal(t, 0, c.RetrySettings.MaxRetries)
+	assert.Equal(t, DefaultRetrySettings.MaxBackoff, c.RetrySettings.MaxBackoff)
+}
+
+func TestLoadConfigWithProfileFromEnv(t *testing.T) {
+	os.Setenv(EnvProfile, "private")
+	defer os.Unsetenv(EnvProfile)
+
+	c := Config{}
+	err := c.LoadConfigFromContent([]byte(profileFileContent))
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESS_KEY_ID", c.AccessKeyID)
+	assert.Equal(t, "private.dev", c.Host)
+}
+
+func TestLoadConfigWithUndefinedProfileFromEnv(t *testing.T) {
+	os.Setenv(EnvProfile, "not-exist")
+	defer os.Unsetenv(EnvProfile)
+
+	// The profile selected by environment falls back to the top level.
+	c := Config{}
+	err := c.LoadConfigFromContent([]byte(profileFileContent))
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESS_KEY_ID", c.AccessKeyID)
+	assert.Equal(t, "qingstor.com", c.Host)
+
+	err = c.LoadConfigFromContentWithProfile([]byte(profileFileContent), "not-exist")
+	assert.NotNil(t, err)
+}
+
+func TestLoadConfigWithInvalidProfile(t *testing.T) {
+	c := Config{}
+	err := c.LoadConfigFromContentWithProfile([]byte(profileFileContent), "not-exist")
+	assert.NotNil(t, err)
+
+	err = c.LoadConfigFromContentWithProfile([]byte(profileFileContent), "loop-a")
+	assert.NotNil(t, err)
+
+	err = c.LoadConfigFromContentWithProfile([]byte("profiles: 'invalid'"), "private")
+	assert.NotNil(t, err)
+}
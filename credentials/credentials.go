@@ -0,0 +1,143 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+// Package credentials provides the credentials used to sign requests,
+// and the providers which retrieve them from different sources.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Names of environment variables read by EnvProvider.
+const (
+	EnvAccessKeyID     = "QINGSTOR_ACCESS_KEY_ID"
+	EnvSecretAccessKey = "QINGSTOR_SECRET_ACCESS_KEY"
+	EnvSessionToken    = "QINGSTOR_SESSION_TOKEN"
+)
+
+// SessionTokenHeader is the header carrying the session token of temporary credentials.
+const SessionTokenHeader = "X-QS-Security-Token"
+
+// ErrNoCredentials is returned by providers if no credentials were found in their sources.
+var ErrNoCredentials = errors.New("no credentials found")
+
+// Value is a set of credentials.
+type Value struct {
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken is the token of temporary credentials, it's empty for permanent credentials.
+	SessionToken string
+
+	// Expires is the time the credentials expire at, it's zero if they never expire.
+	Expires time.Time
+}
+
+// HasKeys checks whether both the access key ID and secret access key are set.
+func (v Value) HasKeys() bool {
+	return v.AccessKeyID != "" && v.SecretAccessKey != ""
+}
+
+// Expired checks whether the credentials expire before now+window.
+func (v Value) Expired(window time.Duration) bool {
+	if v.Expires.IsZero() {
+		return false
+	}
+	return !time.Now().Add(window).Before(v.Expires)
+}
+
+// Provider retrieves credentials, it's called before signing every request
+// and must be safe for concurrent use.
+type Provider interface {
+	Retrieve(ctx context.Context) (Value, error)
+}
+
+// StaticProvider provides fixed credentials.
+type StaticProvider struct {
+	Value Value
+}
+
+// NewStaticProvider creates a StaticProvider, sessionToken is empty for permanent credentials.
+func NewStaticProvider(accessKeyID, secretAccessKey, sessionToken string) *StaticProvider {
+	return &StaticProvider{Value: Value{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		SessionToken:    sessionToken,
+	}}
+}
+
+// Retrieve implements Provider.
+func (p *StaticProvider) Retrieve(ctx context.Context) (Value, error) {
+	if !p.Value.HasKeys() {
+		return Value{}, ErrNoCredentials
+	}
+	return p.Value, nil
+}
+
+// EnvProvider provides credentials from environment variables
+// QINGSTOR_ACCESS_KEY_ID, QINGSTOR_SECRET_ACCESS_KEY and QINGSTOR_SESSION_TOKEN.
+type EnvProvider struct{}
+
+// NewEnvProvider creates an EnvProvider.
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{}
+}
+
+// Retrieve implements Provider.
+func (p *EnvProvider) Retrieve(ctx context.Context) (Value, error) {
+	v := Value{
+		AccessKeyID:     os.Getenv(EnvAccessKeyID),
+		SecretAccessKey: os.Getenv(EnvSecretAccessKey),
+		SessionToken:    os.Getenv(EnvSessionToken),
+	}
+	if !v.HasKeys() {
+		return Value{}, ErrNoCredentials
+	}
+	return v, nil
+}
+
+// ChainProvider tries providers in order, and provides the credentials
+// of the first provider succeeded.
+type ChainProvider struct {
+	Providers []Provider
+}
+
+// NewChainProvider creates a ChainProvider with given providers.
+func NewChainProvider(providers ...Provider) *ChainProvider {
+	return &ChainProvider{Providers: providers}
+}
+
+// Retrieve implements Provider.
+func (p *ChainProvider) Retrieve(ctx context.Context) (Value, error) {
+	errs := make([]string, 0, len(p.Providers))
+	for _, provider := range p.Providers {
+		v, err := provider.Retrieve(ctx)
+		if err == nil && v.HasKeys() {
+			return v, nil
+		}
+		if err == nil {
+			err = ErrNoCredentials
+		}
+		errs = append(errs, err.Error())
+	}
+	return Value{}, fmt.Errorf("%s in chain: [%s]", ErrNoCredentials, strings.Join(errs, "; "))
+}
@@ -0,0 +1,278 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingProvider struct {
+	mu      sync.Mutex
+	count   int
+	expires time.Duration
+}
+
+func (p *countingProvider) Retrieve(ctx context.Context) (Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.count++
+	v := Value{
+		AccessKeyID:     fmt.Sprintf("ACCESS_KEY_ID_%d", p.count),
+		SecretAccessKey: "SECRET_ACCESS_KEY",
+		SessionToken:    "SESSION_TOKEN",
+	}
+	if p.expires != 0 {
+		v.Expires = time.Now().Add(p.expires)
+	}
+	return v, nil
+}
+
+func TestValue(t *testing.T) {
+	assert.False(t, Value{AccessKeyID: "ak"}.HasKeys())
+	assert.True(t, Value{AccessKeyID: "ak", SecretAccessKey: "sk"}.HasKeys())
+
+	assert.False(t, Value{}.Expired(time.Hour))
+	v := Value{Expires: time.Now().Add(time.Minute)}
+	assert.False(t, v.Expired(0))
+	assert.True(t, v.Expired(time.Hour))
+}
+
+func TestStaticProvider(t *testing.T) {
+	v, err := NewStaticProvider("ak", "sk", "token").Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, Value{AccessKeyID: "ak", SecretAccessKey: "sk", SessionToken: "token"}, v)
+
+	_, err = NewStaticProvider("", "", "").Retrieve(context.Background())
+	assert.Equal(t, ErrNoCredentials, err)
+}
+
+func TestEnvProvider(t *testing.T) {
+	defer os.Unsetenv(EnvAccessKeyID)
+	defer os.Unsetenv(EnvSecretAccessKey)
+	defer os.Unsetenv(EnvSessionToken)
+
+	os.Setenv(EnvAccessKeyID, "")
+	_, err := NewEnvProvider().Retrieve(context.Background())
+	assert.Equal(t, ErrNoCredentials, err)
+
+	os.Setenv(EnvAccessKeyID, "ak")
+	os.Setenv(EnvSecretAccessKey, "sk")
+	os.Setenv(EnvSessionToken, "token")
+	v, err := NewEnvProvider().Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, Value{AccessKeyID: "ak", SecretAccessKey: "sk", SessionToken: "token"}, v)
+}
+
+func TestChainProvider(t *testing.T) {
+	p := NewChainProvider(
+		NewStaticProvider("", "", ""),
+		NewStaticProvider("ak", "sk", ""),
+		NewStaticProvider("other", "other", ""),
+	)
+	v, err := p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ak", v.AccessKeyID)
+
+	p = NewChainProvider(NewStaticProvider("", "", ""), NewFileProvider("/not/exist"))
+	_, err = p.Retrieve(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), ErrNoCredentials.Error())
+}
+
+func TestRefreshingProvider(t *testing.T) {
+	source := &countingProvider{}
+	p := NewRefreshingProvider(source, DefaultExpiryWindow)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := p.Retrieve(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "ACCESS_KEY_ID_1", v.AccessKeyID)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, source.count)
+
+	p.Invalidate()
+	v, err := p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESS_KEY_ID_2", v.AccessKeyID)
+}
+
+// blockingProvider retrieves credentials after release is closed.
+type blockingProvider struct {
+	countingProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) Retrieve(ctx context.Context) (Value, error) {
+	close(p.started)
+	<-p.release
+	return p.countingProvider.Retrieve(ctx)
+}
+
+func TestRefreshingProviderWaitCanceled(t *testing.T) {
+	source := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	p := NewRefreshingProvider(source, DefaultExpiryWindow)
+
+	retrieved := make(chan Value)
+	go func() {
+		v, err := p.Retrieve(context.Background())
+		assert.Nil(t, err)
+		retrieved <- v
+	}()
+	<-source.started
+
+	// The callers waiting for the retrieval in flight give up with their contexts.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := p.Retrieve(ctx)
+	assert.Equal(t, context.Canceled, err)
+
+	close(source.release)
+	assert.Equal(t, "ACCESS_KEY_ID_1", (<-retrieved).AccessKeyID)
+	v, err := p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESS_KEY_ID_1", v.AccessKeyID)
+	assert.Equal(t, 1, source.count)
+}
+
+func TestRefreshingProviderExpired(t *testing.T) {
+	source := &countingProvider{expires: time.Second}
+	p := NewRefreshingProvider(source, time.Minute)
+
+	v, err := p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESS_KEY_ID_1", v.AccessKeyID)
+	v, err = p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESS_KEY_ID_2", v.AccessKeyID)
+
+	source.expires = time.Hour
+	_, err = p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	v, err = p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESS_KEY_ID_3", v.AccessKeyID)
+}
+
+func TestFileProvider(t *testing.T) {
+	file, err := ioutil.TempFile("", "qingstor-credentials")
+	assert.Nil(t, err)
+	f := file.Name()
+	file.Close()
+	defer os.Remove(f)
+
+	write := func(content string, modTime time.Time) {
+		assert.Nil(t, ioutil.WriteFile(f, []byte(content), 0600))
+		assert.Nil(t, os.Chtimes(f, modTime, modTime))
+	}
+
+	now := time.Now()
+	write("access_key_id: 'ak1'\nsecret_access_key: 'sk1'\n", now.Add(-time.Minute))
+	p := NewFileProvider(f)
+	v, err := p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, Value{AccessKeyID: "ak1", SecretAccessKey: "sk1"}, v)
+
+	write("access_key_id: 'ak2'\nsecret_access_key: 'sk2'\nsession_token: 'token'\n", now)
+	v, err = p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, Value{AccessKeyID: "ak2", SecretAccessKey: "sk2", SessionToken: "token"}, v)
+
+	write("host: 'qingstor.com'\n", now.Add(time.Minute))
+	_, err = p.Retrieve(context.Background())
+	assert.Equal(t, ErrNoCredentials, err)
+}
+
+func TestFileProviderProfile(t *testing.T) {
+	file, err := ioutil.TempFile("", "qingstor-credentials")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`
+access_key_id: 'ak'
+secret_access_key: 'sk'
+profiles:
+  private:
+    secret_access_key: 'private-sk'
+  private-admin:
+    base: private
+    access_key_id: 'admin-ak'
+`)
+	assert.Nil(t, err)
+	file.Close()
+
+	v, err := NewFileProviderWithProfile(file.Name(), "private-admin").Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, Value{AccessKeyID: "admin-ak", SecretAccessKey: "private-sk"}, v)
+	_, err = NewFileProviderWithProfile(file.Name(), "not-exist").Retrieve(context.Background())
+	assert.NotNil(t, err)
+
+	os.Setenv("QINGSTOR_PROFILE", "private")
+	defer os.Unsetenv("QINGSTOR_PROFILE")
+	p := NewFileProvider(file.Name())
+	v, err = p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, Value{AccessKeyID: "ak", SecretAccessKey: "private-sk"}, v)
+
+	// The top level credentials are read if the file doesn't define the profile.
+	os.Setenv("QINGSTOR_PROFILE", "not-exist")
+	v, err = p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, Value{AccessKeyID: "ak", SecretAccessKey: "sk"}, v)
+}
+
+func TestProcessProvider(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	p := NewProcessProvider("/bin/sh", "-c",
+		`echo '{"access_key_id":"ak","secret_access_key":"sk","session_token":"token","expiration":"2030-01-02T15:04:05Z"}'`)
+	v, err := p.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ak", v.AccessKeyID)
+	assert.Equal(t, "token", v.SessionToken)
+	assert.Equal(t, time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC), v.Expires.UTC())
+
+	p = NewProcessProvider("/bin/sh", "-c", "echo failed >&2; exit 1")
+	_, err = p.Retrieve(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed")
+
+	p = NewProcessProvider("/bin/sh", "-c", "echo not json")
+	_, err = p.Retrieve(context.Background())
+	assert.NotNil(t, err)
+
+	p = NewProcessProvider("/bin/sh", "-c", "exec sleep 10")
+	p.Timeout = time.Millisecond * 50
+	_, err = p.Retrieve(context.Background())
+	assert.NotNil(t, err)
+}
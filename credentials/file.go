@@ -0,0 +1,131 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/internal/profile"
+)
+
+// DefaultFilePath is the config file read by FileProvider if no path was given.
+const DefaultFilePath = "~/.qingstor/config.yaml"
+
+// FileProvider provides credentials from the access_key_id, secret_access_key
+// and session_token fields of a QingStor config file.
+// The file is read again once it was modified, so that rotated credentials
+// are picked up without rebuilding the service.
+type FileProvider struct {
+	Path string
+	// Profile is the profile to read credentials from. If it's empty, the
+	// profile named by QINGSTOR_PROFILE is read as config does, or the top
+	// level configuration if the file doesn't define it.
+	Profile string
+
+	mu      sync.Mutex
+	modTime time.Time
+	profile string
+	value   Value
+}
+
+// NewFileProvider creates a FileProvider reading given path, DefaultFilePath is used if path is empty.
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{Path: path}
+}
+
+// NewFileProviderWithProfile creates a FileProvider reading given profile in path.
+func NewFileProviderWithProfile(path string, profile string) *FileProvider {
+	return &FileProvider{Path: path, Profile: profile}
+}
+
+type fileCredentials struct {
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	SessionToken    string `yaml:"session_token"`
+}
+
+// Retrieve implements Provider.
+func (p *FileProvider) Retrieve(ctx context.Context) (Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	path, err := expandPath(p.Path)
+	if err != nil {
+		return Value{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Value{}, err
+	}
+	name := p.Profile
+	if name == "" {
+		name = os.Getenv(profile.Env)
+	}
+	if !info.ModTime().Equal(p.modTime) || name != p.profile {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return Value{}, err
+		}
+		if p.Profile != "" {
+			content, err = profile.Resolve(content, p.Profile)
+		} else {
+			content, err = profile.ResolveEnv(content)
+		}
+		if err != nil {
+			return Value{}, fmt.Errorf("resolve profile in %s: %s", path, err)
+		}
+		c := fileCredentials{}
+		err = yaml.Unmarshal(content, &c)
+		if err != nil {
+			return Value{}, fmt.Errorf("parse credentials in %s: %s", path, err)
+		}
+		p.value = Value{
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.SecretAccessKey,
+			SessionToken:    c.SessionToken,
+		}
+		p.modTime = info.ModTime()
+		p.profile = name
+	}
+
+	if !p.value.HasKeys() {
+		return Value{}, ErrNoCredentials
+	}
+	return p.value, nil
+}
+
+func expandPath(path string) (string, error) {
+	if path == "" {
+		path = DefaultFilePath
+	}
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return home + path[1:], nil
+}
@@ -0,0 +1,106 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultProcessTimeout is the time limit of the credentials process if no timeout was given.
+const DefaultProcessTimeout = time.Minute
+
+// ProcessProvider provides credentials printed by an external process.
+//
+// The process must print a JSON object to stdout like:
+//
+//	{
+//	  "access_key_id": "ACCESS_KEY_ID",
+//	  "secret_access_key": "SECRET_ACCESS_KEY",
+//	  "session_token": "SESSION_TOKEN",
+//	  "expiration": "2021-01-02T15:04:05Z"
+//	}
+//
+// session_token and expiration are optional, expiration is in RFC 3339 format.
+// The process is run on every Retrieve, wrap it by RefreshingProvider to cache the credentials.
+type ProcessProvider struct {
+	Command []string
+	Timeout time.Duration
+}
+
+// NewProcessProvider creates a ProcessProvider running command with args.
+func NewProcessProvider(command string, args ...string) *ProcessProvider {
+	return &ProcessProvider{Command: append([]string{command}, args...)}
+}
+
+type processCredentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token"`
+	Expiration      string `json:"expiration"`
+}
+
+// Retrieve implements Provider.
+func (p *ProcessProvider) Retrieve(ctx context.Context) (Value, error) {
+	if len(p.Command) == 0 {
+		return Value{}, fmt.Errorf("credentials process not specified")
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProcessTimeout
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err != nil {
+		return Value{}, fmt.Errorf("run credentials process: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	c := processCredentials{}
+	err = json.Unmarshal(stdout.Bytes(), &c)
+	if err != nil {
+		return Value{}, fmt.Errorf("parse output of credentials process: %s", err)
+	}
+	v := Value{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.SessionToken,
+	}
+	if c.Expiration != "" {
+		v.Expires, err = time.Parse(time.RFC3339, c.Expiration)
+		if err != nil {
+			return Value{}, fmt.Errorf("parse expiration of credentials process: %s", err)
+		}
+	}
+	if !v.HasKeys() {
+		return Value{}, ErrNoCredentials
+	}
+	return v, nil
+}
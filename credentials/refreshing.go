@@ -0,0 +1,112 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package credentials
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultExpiryWindow is the time before expiry when RefreshingProvider
+// starts to refresh credentials.
+const DefaultExpiryWindow = time.Minute
+
+// RefreshingProvider caches the credentials retrieved by another provider,
+// and retrieves new credentials when they are about to expire.
+// Credentials without expiry are cached until Invalidate is called.
+// Concurrent callers share one retrieval, and stop waiting for it when their
+// contexts are done.
+type RefreshingProvider struct {
+	provider Provider
+	window   time.Duration
+
+	mu    sync.Mutex
+	value Value
+	valid bool
+	// call is the retrieval in flight, nil if there is none.
+	call *refreshCall
+}
+
+// refreshCall is a retrieval shared by concurrent callers, done is closed
+// after value and err are set.
+type refreshCall struct {
+	done  chan struct{}
+	value Value
+	err   error
+}
+
+// NewRefreshingProvider creates a RefreshingProvider wrapping given provider,
+// credentials are refreshed window before they expire.
+func NewRefreshingProvider(provider Provider, window time.Duration) *RefreshingProvider {
+	return &RefreshingProvider{
+		provider: provider,
+		window:   window,
+	}
+}
+
+// Retrieve implements Provider.
+func (p *RefreshingProvider) Retrieve(ctx context.Context) (Value, error) {
+	p.mu.Lock()
+	if p.valid && !p.value.Expired(p.window) {
+		v := p.value
+		p.mu.Unlock()
+		return v, nil
+	}
+	call := p.call
+	if call != nil {
+		p.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.value, call.err
+		case <-ctx.Done():
+			return Value{}, ctx.Err()
+		}
+	}
+	call = &refreshCall{done: make(chan struct{})}
+	p.call = call
+	p.mu.Unlock()
+
+	call.value, call.err = p.provider.Retrieve(ctx)
+
+	p.mu.Lock()
+	// The credentials are dropped if Invalidate was called meanwhile.
+	if p.call == call {
+		p.call = nil
+		if call.err == nil {
+			p.value = call.value
+			p.valid = true
+		}
+	}
+	p.mu.Unlock()
+	close(call.done)
+
+	if call.err != nil {
+		return Value{}, call.err
+	}
+	return call.value, nil
+}
+
+// Invalidate drops the cached credentials, the next Retrieve will retrieve new credentials.
+func (p *RefreshingProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.valid = false
+	p.value = Value{}
+	p.call = nil
+}
@@ -0,0 +1,819 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package fake
+
+import (
+	"context"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// bucket holds the stubs of bucket sub service.
+type bucket struct {
+
+	// DeleteStub is called by Delete and DeleteWithContext if it's not nil.
+	DeleteStub func(ctx context.Context) (*service.DeleteBucketOutput, error)
+
+	// DeleteCNAMEStub is called by DeleteCNAME and DeleteCNAMEWithContext if it's not nil.
+	DeleteCNAMEStub func(ctx context.Context, input *service.DeleteBucketCNAMEInput) (*service.DeleteBucketCNAMEOutput, error)
+
+	// DeleteCORSStub is called by DeleteCORS and DeleteCORSWithContext if it's not nil.
+	DeleteCORSStub func(ctx context.Context) (*service.DeleteBucketCORSOutput, error)
+
+	// DeleteExternalMirrorStub is called by DeleteExternalMirror and DeleteExternalMirrorWithContext if it's not nil.
+	DeleteExternalMirrorStub func(ctx context.Context) (*service.DeleteBucketExternalMirrorOutput, error)
+
+	// DeleteLifecycleStub is called by DeleteLifecycle and DeleteLifecycleWithContext if it's not nil.
+	DeleteLifecycleStub func(ctx context.Context) (*service.DeleteBucketLifecycleOutput, error)
+
+	// DeleteLoggingStub is called by DeleteLogging and DeleteLoggingWithContext if it's not nil.
+	DeleteLoggingStub func(ctx context.Context) (*service.DeleteBucketLoggingOutput, error)
+
+	// DeleteNotificationStub is called by DeleteNotification and DeleteNotificationWithContext if it's not nil.
+	DeleteNotificationStub func(ctx context.Context) (*service.DeleteBucketNotificationOutput, error)
+
+	// DeletePolicyStub is called by DeletePolicy and DeletePolicyWithContext if it's not nil.
+	DeletePolicyStub func(ctx context.Context) (*service.DeleteBucketPolicyOutput, error)
+
+	// DeleteReplicationStub is called by DeleteReplication and DeleteReplicationWithContext if it's not nil.
+	DeleteReplicationStub func(ctx context.Context) (*service.DeleteBucketReplicationOutput, error)
+
+	// DeleteMultipleObjectsStub is called by DeleteMultipleObjects and DeleteMultipleObjectsWithContext if it's not nil.
+	DeleteMultipleObjectsStub func(ctx context.Context, input *service.DeleteMultipleObjectsInput) (*service.DeleteMultipleObjectsOutput, error)
+
+	// GetACLStub is called by GetACL and GetACLWithContext if it's not nil.
+	GetACLStub func(ctx context.Context) (*service.GetBucketACLOutput, error)
+
+	// GetCNAMEStub is called by GetCNAME and GetCNAMEWithContext if it's not nil.
+	GetCNAMEStub func(ctx context.Context, input *service.GetBucketCNAMEInput) (*service.GetBucketCNAMEOutput, error)
+
+	// GetCORSStub is called by GetCORS and GetCORSWithContext if it's not nil.
+	GetCORSStub func(ctx context.Context) (*service.GetBucketCORSOutput, error)
+
+	// GetExternalMirrorStub is called by GetExternalMirror and GetExternalMirrorWithContext if it's not nil.
+	GetExternalMirrorStub func(ctx context.Context) (*service.GetBucketExternalMirrorOutput, error)
+
+	// GetLifecycleStub is called by GetLifecycle and GetLifecycleWithContext if it's not nil.
+	GetLifecycleStub func(ctx context.Context) (*service.GetBucketLifecycleOutput, error)
+
+	// GetLoggingStub is called by GetLogging and GetLoggingWithContext if it's not nil.
+	GetLoggingStub func(ctx context.Context) (*service.GetBucketLoggingOutput, error)
+
+	// GetNotificationStub is called by GetNotification and GetNotificationWithContext if it's not nil.
+	GetNotificationStub func(ctx context.Context) (*service.GetBucketNotificationOutput, error)
+
+	// GetPolicyStub is called by GetPolicy and GetPolicyWithContext if it's not nil.
+	GetPolicyStub func(ctx context.Context) (*service.GetBucketPolicyOutput, error)
+
+	// GetReplicationStub is called by GetReplication and GetReplicationWithContext if it's not nil.
+	GetReplicationStub func(ctx context.Context) (*service.GetBucketReplicationOutput, error)
+
+	// GetStatisticsStub is called by GetStatistics and GetStatisticsWithContext if it's not nil.
+	GetStatisticsStub func(ctx context.Context) (*service.GetBucketStatisticsOutput, error)
+
+	// HeadStub is called by Head and HeadWithContext if it's not nil.
+	HeadStub func(ctx context.Context) (*service.HeadBucketOutput, error)
+
+	// ListMultipartUploadsStub is called by ListMultipartUploads and ListMultipartUploadsWithContext if it's not nil.
+	ListMultipartUploadsStub func(ctx context.Context, input *service.ListMultipartUploadsInput) (*service.ListMultipartUploadsOutput, error)
+
+	// ListObjectsStub is called by ListObjects and ListObjectsWithContext if it's not nil.
+	ListObjectsStub func(ctx context.Context, input *service.ListObjectsInput) (*service.ListObjectsOutput, error)
+
+	// PutStub is called by Put and PutWithContext if it's not nil.
+	PutStub func(ctx context.Context) (*service.PutBucketOutput, error)
+
+	// PutACLStub is called by PutACL and PutACLWithContext if it's not nil.
+	PutACLStub func(ctx context.Context, input *service.PutBucketACLInput) (*service.PutBucketACLOutput, error)
+
+	// PutCNAMEStub is called by PutCNAME and PutCNAMEWithContext if it's not nil.
+	PutCNAMEStub func(ctx context.Context, input *service.PutBucketCNAMEInput) (*service.PutBucketCNAMEOutput, error)
+
+	// PutCORSStub is called by PutCORS and PutCORSWithContext if it's not nil.
+	PutCORSStub func(ctx context.Context, input *service.PutBucketCORSInput) (*service.PutBucketCORSOutput, error)
+
+	// PutExternalMirrorStub is called by PutExternalMirror and PutExternalMirrorWithContext if it's not nil.
+	PutExternalMirrorStub func(ctx context.Context, input *service.PutBucketExternalMirrorInput) (*service.PutBucketExternalMirrorOutput, error)
+
+	// PutLifecycleStub is called by PutLifecycle and PutLifecycleWithContext if it's not nil.
+	PutLifecycleStub func(ctx context.Context, input *service.PutBucketLifecycleInput) (*service.PutBucketLifecycleOutput, error)
+
+	// PutLoggingStub is called by PutLogging and PutLoggingWithContext if it's not nil.
+	PutLoggingStub func(ctx context.Context, input *service.PutBucketLoggingInput) (*service.PutBucketLoggingOutput, error)
+
+	// PutNotificationStub is called by PutNotification and PutNotificationWithContext if it's not nil.
+	PutNotificationStub func(ctx context.Context, input *service.PutBucketNotificationInput) (*service.PutBucketNotificationOutput, error)
+
+	// PutPolicyStub is called by PutPolicy and PutPolicyWithContext if it's not nil.
+	PutPolicyStub func(ctx context.Context, input *service.PutBucketPolicyInput) (*service.PutBucketPolicyOutput, error)
+
+	// PutReplicationStub is called by PutReplication and PutReplicationWithContext if it's not nil.
+	PutReplicationStub func(ctx context.Context, input *service.PutBucketReplicationInput) (*service.PutBucketReplicationOutput, error)
+}
+
+// Delete records the call, and returns the result of DeleteStub.
+func (f *Bucket) Delete() (*service.DeleteBucketOutput, error) {
+	return f.DeleteWithContext(context.Background())
+}
+
+// DeleteWithContext records the call, and returns the result of DeleteStub.
+func (f *Bucket) DeleteWithContext(ctx context.Context) (*service.DeleteBucketOutput, error) {
+	f.record(Call{Method: "Delete", Ctx: ctx})
+	if f.DeleteStub != nil {
+		return f.DeleteStub(ctx)
+	}
+	return &service.DeleteBucketOutput{}, nil
+}
+
+// DeleteReturns makes Delete return output and err.
+func (f *Bucket) DeleteReturns(output *service.DeleteBucketOutput, err error) {
+	f.DeleteStub = func(context.Context) (*service.DeleteBucketOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteCNAME records the call, and returns the result of DeleteCNAMEStub.
+func (f *Bucket) DeleteCNAME(input *service.DeleteBucketCNAMEInput) (*service.DeleteBucketCNAMEOutput, error) {
+	return f.DeleteCNAMEWithContext(context.Background(), input)
+}
+
+// DeleteCNAMEWithContext records the call, and returns the result of DeleteCNAMEStub.
+func (f *Bucket) DeleteCNAMEWithContext(ctx context.Context, input *service.DeleteBucketCNAMEInput) (*service.DeleteBucketCNAMEOutput, error) {
+	f.record(Call{Method: "DeleteCNAME", Ctx: ctx, Input: input})
+	if f.DeleteCNAMEStub != nil {
+		return f.DeleteCNAMEStub(ctx, input)
+	}
+	return &service.DeleteBucketCNAMEOutput{}, nil
+}
+
+// DeleteCNAMEReturns makes DeleteCNAME return output and err.
+func (f *Bucket) DeleteCNAMEReturns(output *service.DeleteBucketCNAMEOutput, err error) {
+	f.DeleteCNAMEStub = func(context.Context, *service.DeleteBucketCNAMEInput) (*service.DeleteBucketCNAMEOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteCORS records the call, and returns the result of DeleteCORSStub.
+func (f *Bucket) DeleteCORS() (*service.DeleteBucketCORSOutput, error) {
+	return f.DeleteCORSWithContext(context.Background())
+}
+
+// DeleteCORSWithContext records the call, and returns the result of DeleteCORSStub.
+func (f *Bucket) DeleteCORSWithContext(ctx context.Context) (*service.DeleteBucketCORSOutput, error) {
+	f.record(Call{Method: "DeleteCORS", Ctx: ctx})
+	if f.DeleteCORSStub != nil {
+		return f.DeleteCORSStub(ctx)
+	}
+	return &service.DeleteBucketCORSOutput{}, nil
+}
+
+// DeleteCORSReturns makes DeleteCORS return output and err.
+func (f *Bucket) DeleteCORSReturns(output *service.DeleteBucketCORSOutput, err error) {
+	f.DeleteCORSStub = func(context.Context) (*service.DeleteBucketCORSOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteExternalMirror records the call, and returns the result of DeleteExternalMirrorStub.
+func (f *Bucket) DeleteExternalMirror() (*service.DeleteBucketExternalMirrorOutput, error) {
+	return f.DeleteExternalMirrorWithContext(context.Background())
+}
+
+// DeleteExternalMirrorWithContext records the call, and returns the result of DeleteExternalMirrorStub.
+func (f *Bucket) DeleteExternalMirrorWithContext(ctx context.Context) (*service.DeleteBucketExternalMirrorOutput, error) {
+	f.record(Call{Method: "DeleteExternalMirror", Ctx: ctx})
+	if f.DeleteExternalMirrorStub != nil {
+		return f.DeleteExternalMirrorStub(ctx)
+	}
+	return &service.DeleteBucketExternalMirrorOutput{}, nil
+}
+
+// DeleteExternalMirrorReturns makes DeleteExternalMirror return output and err.
+func (f *Bucket) DeleteExternalMirrorReturns(output *service.DeleteBucketExternalMirrorOutput, err error) {
+	f.DeleteExternalMirrorStub = func(context.Context) (*service.DeleteBucketExternalMirrorOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteLifecycle records the call, and returns the result of DeleteLifecycleStub.
+func (f *Bucket) DeleteLifecycle() (*service.DeleteBucketLifecycleOutput, error) {
+	return f.DeleteLifecycleWithContext(context.Background())
+}
+
+// DeleteLifecycleWithContext records the call, and returns the result of DeleteLifecycleStub.
+func (f *Bucket) DeleteLifecycleWithContext(ctx context.Context) (*service.DeleteBucketLifecycleOutput, error) {
+	f.record(Call{Method: "DeleteLifecycle", Ctx: ctx})
+	if f.DeleteLifecycleStub != nil {
+		return f.DeleteLifecycleStub(ctx)
+	}
+	return &service.DeleteBucketLifecycleOutput{}, nil
+}
+
+// DeleteLifecycleReturns makes DeleteLifecycle return output and err.
+func (f *Bucket) DeleteLifecycleReturns(output *service.DeleteBucketLifecycleOutput, err error) {
+	f.DeleteLifecycleStub = func(context.Context) (*service.DeleteBucketLifecycleOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteLogging records the call, and returns the result of DeleteLoggingStub.
+func (f *Bucket) DeleteLogging() (*service.DeleteBucketLoggingOutput, error) {
+	return f.DeleteLoggingWithContext(context.Background())
+}
+
+// DeleteLoggingWithContext records the call, and returns the result of DeleteLoggingStub.
+func (f *Bucket) DeleteLoggingWithContext(ctx context.Context) (*service.DeleteBucketLoggingOutput, error) {
+	f.record(Call{Method: "DeleteLogging", Ctx: ctx})
+	if f.DeleteLoggingStub != nil {
+		return f.DeleteLoggingStub(ctx)
+	}
+	return &service.DeleteBucketLoggingOutput{}, nil
+}
+
+// DeleteLoggingReturns makes DeleteLogging return output and err.
+func (f *Bucket) DeleteLoggingReturns(output *service.DeleteBucketLoggingOutput, err error) {
+	f.DeleteLoggingStub = func(context.Context) (*service.DeleteBucketLoggingOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteNotification records the call, and returns the result of DeleteNotificationStub.
+func (f *Bucket) DeleteNotification() (*service.DeleteBucketNotificationOutput, error) {
+	return f.DeleteNotificationWithContext(context.Background())
+}
+
+// DeleteNotificationWithContext records the call, and returns the result of DeleteNotificationStub.
+func (f *Bucket) DeleteNotificationWithContext(ctx context.Context) (*service.DeleteBucketNotificationOutput, error) {
+	f.record(Call{Method: "DeleteNotification", Ctx: ctx})
+	if f.DeleteNotificationStub != nil {
+		return f.DeleteNotificationStub(ctx)
+	}
+	return &service.DeleteBucketNotificationOutput{}, nil
+}
+
+// DeleteNotificationReturns makes DeleteNotification return output and err.
+func (f *Bucket) DeleteNotificationReturns(output *service.DeleteBucketNotificationOutput, err error) {
+	f.DeleteNotificationStub = func(context.Context) (*service.DeleteBucketNotificationOutput, error) {
+		return output, err
+	}
+}
+
+// DeletePolicy records the call, and returns the result of DeletePolicyStub.
+func (f *Bucket) DeletePolicy() (*service.DeleteBucketPolicyOutput, error) {
+	return f.DeletePolicyWithContext(context.Background())
+}
+
+// DeletePolicyWithContext records the call, and returns the result of DeletePolicyStub.
+func (f *Bucket) DeletePolicyWithContext(ctx context.Context) (*service.DeleteBucketPolicyOutput, error) {
+	f.record(Call{Method: "DeletePolicy", Ctx: ctx})
+	if f.DeletePolicyStub != nil {
+		return f.DeletePolicyStub(ctx)
+	}
+	return &service.DeleteBucketPolicyOutput{}, nil
+}
+
+// DeletePolicyReturns makes DeletePolicy return output and err.
+func (f *Bucket) DeletePolicyReturns(output *service.DeleteBucketPolicyOutput, err error) {
+	f.DeletePolicyStub = func(context.Context) (*service.DeleteBucketPolicyOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteReplication records the call, and returns the result of DeleteReplicationStub.
+func (f *Bucket) DeleteReplication() (*service.DeleteBucketReplicationOutput, error) {
+	return f.DeleteReplicationWithContext(context.Background())
+}
+
+// DeleteReplicationWithContext records the call, and returns the result of DeleteReplicationStub.
+func (f *Bucket) DeleteReplicationWithContext(ctx context.Context) (*service.DeleteBucketReplicationOutput, error) {
+	f.record(Call{Method: "DeleteReplication", Ctx: ctx})
+	if f.DeleteReplicationStub != nil {
+		return f.DeleteReplicationStub(ctx)
+	}
+	return &service.DeleteBucketReplicationOutput{}, nil
+}
+
+// DeleteReplicationReturns makes DeleteReplication return output and err.
+func (f *Bucket) DeleteReplicationReturns(output *service.DeleteBucketReplicationOutput, err error) {
+	f.DeleteReplicationStub = func(context.Context) (*service.DeleteBucketReplicationOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteMultipleObjects records the call, and returns the result of DeleteMultipleObjectsStub.
+func (f *Bucket) DeleteMultipleObjects(input *service.DeleteMultipleObjectsInput) (*service.DeleteMultipleObjectsOutput, error) {
+	return f.DeleteMultipleObjectsWithContext(context.Background(), input)
+}
+
+// DeleteMultipleObjectsWithContext records the call, and returns the result of DeleteMultipleObjectsStub.
+func (f *Bucket) DeleteMultipleObjectsWithContext(ctx context.Context, input *service.DeleteMultipleObjectsInput) (*service.DeleteMultipleObjectsOutput, error) {
+	f.record(Call{Method: "DeleteMultipleObjects", Ctx: ctx, Input: input})
+	if f.DeleteMultipleObjectsStub != nil {
+		return f.DeleteMultipleObjectsStub(ctx, input)
+	}
+	return &service.DeleteMultipleObjectsOutput{}, nil
+}
+
+// DeleteMultipleObjectsReturns makes DeleteMultipleObjects return output and err.
+func (f *Bucket) DeleteMultipleObjectsReturns(output *service.DeleteMultipleObjectsOutput, err error) {
+	f.DeleteMultipleObjectsStub = func(context.Context, *service.DeleteMultipleObjectsInput) (*service.DeleteMultipleObjectsOutput, error) {
+		return output, err
+	}
+}
+
+// GetACL records the call, and returns the result of GetACLStub.
+func (f *Bucket) GetACL() (*service.GetBucketACLOutput, error) {
+	return f.GetACLWithContext(context.Background())
+}
+
+// GetACLWithContext records the call, and returns the result of GetACLStub.
+func (f *Bucket) GetACLWithContext(ctx context.Context) (*service.GetBucketACLOutput, error) {
+	f.record(Call{Method: "GetACL", Ctx: ctx})
+	if f.GetACLStub != nil {
+		return f.GetACLStub(ctx)
+	}
+	return &service.GetBucketACLOutput{}, nil
+}
+
+// GetACLReturns makes GetACL return output and err.
+func (f *Bucket) GetACLReturns(output *service.GetBucketACLOutput, err error) {
+	f.GetACLStub = func(context.Context) (*service.GetBucketACLOutput, error) {
+		return output, err
+	}
+}
+
+// GetCNAME records the call, and returns the result of GetCNAMEStub.
+func (f *Bucket) GetCNAME(input *service.GetBucketCNAMEInput) (*service.GetBucketCNAMEOutput, error) {
+	return f.GetCNAMEWithContext(context.Background(), input)
+}
+
+// GetCNAMEWithContext records the call, and returns the result of GetCNAMEStub.
+func (f *Bucket) GetCNAMEWithContext(ctx context.Context, input *service.GetBucketCNAMEInput) (*service.GetBucketCNAMEOutput, error) {
+	f.record(Call{Method: "GetCNAME", Ctx: ctx, Input: input})
+	if f.GetCNAMEStub != nil {
+		return f.GetCNAMEStub(ctx, input)
+	}
+	return &service.GetBucketCNAMEOutput{}, nil
+}
+
+// GetCNAMEReturns makes GetCNAME return output and err.
+func (f *Bucket) GetCNAMEReturns(output *service.GetBucketCNAMEOutput, err error) {
+	f.GetCNAMEStub = func(context.Context, *service.GetBucketCNAMEInput) (*service.GetBucketCNAMEOutput, error) {
+		return output, err
+	}
+}
+
+// GetCORS records the call, and returns the result of GetCORSStub.
+func (f *Bucket) GetCORS() (*service.GetBucketCORSOutput, error) {
+	return f.GetCORSWithContext(context.Background())
+}
+
+// GetCORSWithContext records the call, and returns the result of GetCORSStub.
+func (f *Bucket) GetCORSWithContext(ctx context.Context) (*service.GetBucketCORSOutput, error) {
+	f.record(Call{Method: "GetCORS", Ctx: ctx})
+	if f.GetCORSStub != nil {
+		return f.GetCORSStub(ctx)
+	}
+	return &service.GetBucketCORSOutput{}, nil
+}
+
+// GetCORSReturns makes GetCORS return output and err.
+func (f *Bucket) GetCORSReturns(output *service.GetBucketCORSOutput, err error) {
+	f.GetCORSStub = func(context.Context) (*service.GetBucketCORSOutput, error) {
+		return output, err
+	}
+}
+
+// GetExternalMirror records the call, and returns the result of GetExternalMirrorStub.
+func (f *Bucket) GetExternalMirror() (*service.GetBucketExternalMirrorOutput, error) {
+	return f.GetExternalMirrorWithContext(context.Background())
+}
+
+// GetExternalMirrorWithContext records the call, and returns the result of GetExternalMirrorStub.
+func (f *Bucket) GetExternalMirrorWithContext(ctx context.Context) (*service.GetBucketExternalMirrorOutput, error) {
+	f.record(Call{Method: "GetExternalMirror", Ctx: ctx})
+	if f.GetExternalMirrorStub != nil {
+		return f.GetExternalMirrorStub(ctx)
+	}
+	return &service.GetBucketExternalMirrorOutput{}, nil
+}
+
+// GetExternalMirrorReturns makes GetExternalMirror return output and err.
+func (f *Bucket) GetExternalMirrorReturns(output *service.GetBucketExternalMirrorOutput, err error) {
+	f.GetExternalMirrorStub = func(context.Context) (*service.GetBucketExternalMirrorOutput, error) {
+		return output, err
+	}
+}
+
+// GetLifecycle records the call, and returns the result of GetLifecycleStub.
+func (f *Bucket) GetLifecycle() (*service.GetBucketLifecycleOutput, error) {
+	return f.GetLifecycleWithContext(context.Background())
+}
+
+// GetLifecycleWithContext records the call, and returns the result of GetLifecycleStub.
+func (f *Bucket) GetLifecycleWithContext(ctx context.Context) (*service.GetBucketLifecycleOutput, error) {
+	f.record(Call{Method: "GetLifecycle", Ctx: ctx})
+	if f.GetLifecycleStub != nil {
+		return f.GetLifecycleStub(ctx)
+	}
+	return &service.GetBucketLifecycleOutput{}, nil
+}
+
+// GetLifecycleReturns makes GetLifecycle return output and err.
+func (f *Bucket) GetLifecycleReturns(output *service.GetBucketLifecycleOutput, err error) {
+	f.GetLifecycleStub = func(context.Context) (*service.GetBucketLifecycleOutput, error) {
+		return output, err
+	}
+}
+
+// GetLogging records the call, and returns the result of GetLoggingStub.
+func (f *Bucket) GetLogging() (*service.GetBucketLoggingOutput, error) {
+	return f.GetLoggingWithContext(context.Background())
+}
+
+// GetLoggingWithContext records the call, and returns the result of GetLoggingStub.
+func (f *Bucket) GetLoggingWithContext(ctx context.Context) (*service.GetBucketLoggingOutput, error) {
+	f.record(Call{Method: "GetLogging", Ctx: ctx})
+	if f.GetLoggingStub != nil {
+		return f.GetLoggingStub(ctx)
+	}
+	return &service.GetBucketLoggingOutput{}, nil
+}
+
+// GetLoggingReturns makes GetLogging return output and err.
+func (f *Bucket) GetLoggingReturns(output *service.GetBucketLoggingOutput, err error) {
+	f.GetLoggingStub = func(context.Context) (*service.GetBucketLoggingOutput, error) {
+		return output, err
+	}
+}
+
+// GetNotification records the call, and returns the result of GetNotificationStub.
+func (f *Bucket) GetNotification() (*service.GetBucketNotificationOutput, error) {
+	return f.GetNotificationWithContext(context.Background())
+}
+
+// GetNotificationWithContext records the call, and returns the result of GetNotificationStub.
+func (f *Bucket) GetNotificationWithContext(ctx context.Context) (*service.GetBucketNotificationOutput, error) {
+	f.record(Call{Method: "GetNotification", Ctx: ctx})
+	if f.GetNotificationStub != nil {
+		return f.GetNotificationStub(ctx)
+	}
+	return &service.GetBucketNotificationOutput{}, nil
+}
+
+// GetNotificationReturns makes GetNotification return output and err.
+func (f *Bucket) GetNotificationReturns(output *service.GetBucketNotificationOutput, err error) {
+	f.GetNotificationStub = func(context.Context) (*service.GetBucketNotificationOutput, error) {
+		return output, err
+	}
+}
+
+// GetPolicy records the call, and returns the result of GetPolicyStub.
+func (f *Bucket) GetPolicy() (*service.GetBucketPolicyOutput, error) {
+	return f.GetPolicyWithContext(context.Background())
+}
+
+// GetPolicyWithContext records the call, and returns the result of GetPolicyStub.
+func (f *Bucket) GetPolicyWithContext(ctx context.Context) (*service.GetBucketPolicyOutput, error) {
+	f.record(Call{Method: "GetPolicy", Ctx: ctx})
+	if f.GetPolicyStub != nil {
+		return f.GetPolicyStub(ctx)
+	}
+	return &service.GetBucketPolicyOutput{}, nil
+}
+
+// GetPolicyReturns makes GetPolicy return output and err.
+func (f *Bucket) GetPolicyReturns(output *service.GetBucketPolicyOutput, err error) {
+	f.GetPolicyStub = func(context.Context) (*service.GetBucketPolicyOutput, error) {
+		return output, err
+	}
+}
+
+// GetReplication records the call, and returns the result of GetReplicationStub.
+func (f *Bucket) GetReplication() (*service.GetBucketReplicationOutput, error) {
+	return f.GetReplicationWithContext(context.Background())
+}
+
+// GetReplicationWithContext records the call, and returns the result of GetReplicationStub.
+func (f *Bucket) GetReplicationWithContext(ctx context.Context) (*service.GetBucketReplicationOutput, error) {
+	f.record(Call{Method: "GetReplication", Ctx: ctx})
+	if f.GetReplicationStub != nil {
+		return f.GetReplicationStub(ctx)
+	}
+	return &service.GetBucketReplicationOutput{}, nil
+}
+
+// GetReplicationReturns makes GetReplication return output and err.
+func (f *Bucket) GetReplicationReturns(output *service.GetBucketReplicationOutput, err error) {
+	f.GetReplicationStub = func(context.Context) (*service.GetBucketReplicationOutput, error) {
+		return output, err
+	}
+}
+
+// GetStatistics records the call, and returns the result of GetStatisticsStub.
+func (f *Bucket) GetStatistics() (*service.GetBucketStatisticsOutput, error) {
+	return f.GetStatisticsWithContext(context.Background())
+}
+
+// GetStatisticsWithContext records the call, and returns the result of GetStatisticsStub.
+func (f *Bucket) GetStatisticsWithContext(ctx context.Context) (*service.GetBucketStatisticsOutput, error) {
+	f.record(Call{Method: "GetStatistics", Ctx: ctx})
+	if f.GetStatisticsStub != nil {
+		return f.GetStatisticsStub(ctx)
+	}
+	return &service.GetBucketStatisticsOutput{}, nil
+}
+
+// GetStatisticsReturns makes GetStatistics return output and err.
+func (f *Bucket) GetStatisticsReturns(output *service.GetBucketStatisticsOutput, err error) {
+	f.GetStatisticsStub = func(context.Context) (*service.GetBucketStatisticsOutput, error) {
+		return output, err
+	}
+}
+
+// Head records the call, and returns the result of HeadStub.
+func (f *Bucket) Head() (*service.HeadBucketOutput, error) {
+	return f.HeadWithContext(context.Background())
+}
+
+// HeadWithContext records the call, and returns the result of HeadStub.
+func (f *Bucket) HeadWithContext(ctx context.Context) (*service.HeadBucketOutput, error) {
+	f.record(Call{Method: "Head", Ctx: ctx})
+	if f.HeadStub != nil {
+		return f.HeadStub(ctx)
+	}
+	return &service.HeadBucketOutput{}, nil
+}
+
+// HeadReturns makes Head return output and err.
+func (f *Bucket) HeadReturns(output *service.HeadBucketOutput, err error) {
+	f.HeadStub = func(context.Context) (*service.HeadBucketOutput, error) {
+		return output, err
+	}
+}
+
+// ListMultipartUploads records the call, and returns the result of ListMultipartUploadsStub.
+func (f *Bucket) ListMultipartUploads(input *service.ListMultipartUploadsInput) (*service.ListMultipartUploadsOutput, error) {
+	return f.ListMultipartUploadsWithContext(context.Background(), input)
+}
+
+// ListMultipartUploadsWithContext records the call, and returns the result of ListMultipartUploadsStub.
+func (f *Bucket) ListMultipartUploadsWithContext(ctx context.Context, input *service.ListMultipartUploadsInput) (*service.ListMultipartUploadsOutput, error) {
+	f.record(Call{Method: "ListMultipartUploads", Ctx: ctx, Input: input})
+	if f.ListMultipartUploadsStub != nil {
+		return f.ListMultipartUploadsStub(ctx, input)
+	}
+	return &service.ListMultipartUploadsOutput{}, nil
+}
+
+// ListMultipartUploadsReturns makes ListMultipartUploads return output and err.
+func (f *Bucket) ListMultipartUploadsReturns(output *service.ListMultipartUploadsOutput, err error) {
+	f.ListMultipartUploadsStub = func(context.Context, *service.ListMultipartUploadsInput) (*service.ListMultipartUploadsOutput, error) {
+		return output, err
+	}
+}
+
+// ListObjects records the call, and returns the result of ListObjectsStub.
+func (f *Bucket) ListObjects(input *service.ListObjectsInput) (*service.ListObjectsOutput, error) {
+	return f.ListObjectsWithContext(context.Background(), input)
+}
+
+// ListObjectsWithContext records the call, and returns the result of ListObjectsStub.
+func (f *Bucket) ListObjectsWithContext(ctx context.Context, input *service.ListObjectsInput) (*service.ListObjectsOutput, error) {
+	f.record(Call{Method: "ListObjects", Ctx: ctx, Input: input})
+	if f.ListObjectsStub != nil {
+		return f.ListObjectsStub(ctx, input)
+	}
+	return &service.ListObjectsOutput{}, nil
+}
+
+// ListObjectsReturns makes ListObjects return output and err.
+func (f *Bucket) ListObjectsReturns(output *service.ListObjectsOutput, err error) {
+	f.ListObjectsStub = func(context.Context, *service.ListObjectsInput) (*service.ListObjectsOutput, error) {
+		return output, err
+	}
+}
+
+// Put records the call, and returns the result of PutStub.
+func (f *Bucket) Put() (*service.PutBucketOutput, error) {
+	return f.PutWithContext(context.Background())
+}
+
+// PutWithContext records the call, and returns the result of PutStub.
+func (f *Bucket) PutWithContext(ctx context.Context) (*service.PutBucketOutput, error) {
+	f.record(Call{Method: "Put", Ctx: ctx})
+	if f.PutStub != nil {
+		return f.PutStub(ctx)
+	}
+	return &service.PutBucketOutput{}, nil
+}
+
+// PutReturns makes Put return output and err.
+func (f *Bucket) PutReturns(output *service.PutBucketOutput, err error) {
+	f.PutStub = func(context.Context) (*service.PutBucketOutput, error) {
+		return output, err
+	}
+}
+
+// PutACL records the call, and returns the result of PutACLStub.
+func (f *Bucket) PutACL(input *service.PutBucketACLInput) (*service.PutBucketACLOutput, error) {
+	return f.PutACLWithContext(context.Background(), input)
+}
+
+// PutACLWithContext records the call, and returns the result of PutACLStub.
+func (f *Bucket) PutACLWithContext(ctx context.Context, input *service.PutBucketACLInput) (*service.PutBucketACLOutput, error) {
+	f.record(Call{Method: "PutACL", Ctx: ctx, Input: input})
+	if f.PutACLStub != nil {
+		return f.PutACLStub(ctx, input)
+	}
+	return &service.PutBucketACLOutput{}, nil
+}
+
+// PutACLReturns makes PutACL return output and err.
+func (f *Bucket) PutACLReturns(output *service.PutBucketACLOutput, err error) {
+	f.PutACLStub = func(context.Context, *service.PutBucketACLInput) (*service.PutBucketACLOutput, error) {
+		return output, err
+	}
+}
+
+// PutCNAME records the call, and returns the result of PutCNAMEStub.
+func (f *Bucket) PutCNAME(input *service.PutBucketCNAMEInput) (*service.PutBucketCNAMEOutput, error) {
+	return f.PutCNAMEWithContext(context.Background(), input)
+}
+
+// PutCNAMEWithContext records the call, and returns the result of PutCNAMEStub.
+func (f *Bucket) PutCNAMEWithContext(ctx context.Context, input *service.PutBucketCNAMEInput) (*service.PutBucketCNAMEOutput, error) {
+	f.record(Call{Method: "PutCNAME", Ctx: ctx, Input: input})
+	if f.PutCNAMEStub != nil {
+		return f.PutCNAMEStub(ctx, input)
+	}
+	return &service.PutBucketCNAMEOutput{}, nil
+}
+
+// PutCNAMEReturns makes PutCNAME return output and err.
+func (f *Bucket) PutCNAMEReturns(output *service.PutBucketCNAMEOutput, err error) {
+	f.PutCNAMEStub = func(context.Context, *service.PutBucketCNAMEInput) (*service.PutBucketCNAMEOutput, error) {
+		return output, err
+	}
+}
+
+// PutCORS records the call, and returns the result of PutCORSStub.
+func (f *Bucket) PutCORS(input *service.PutBucketCORSInput) (*service.PutBucketCORSOutput, error) {
+	return f.PutCORSWithContext(context.Background(), input)
+}
+
+// PutCORSWithContext records the call, and returns the result of PutCORSStub.
+func (f *Bucket) PutCORSWithContext(ctx context.Context, input *service.PutBucketCORSInput) (*service.PutBucketCORSOutput, error) {
+	f.record(Call{Method: "PutCORS", Ctx: ctx, Input: input})
+	if f.PutCORSStub != nil {
+		return f.PutCORSStub(ctx, input)
+	}
+	return &service.PutBucketCORSOutput{}, nil
+}
+
+// PutCORSReturns makes PutCORS return output and err.
+func (f *Bucket) PutCORSReturns(output *service.PutBucketCORSOutput, err error) {
+	f.PutCORSStub = func(context.Context, *service.PutBucketCORSInput) (*service.PutBucketCORSOutput, error) {
+		return output, err
+	}
+}
+
+// PutExternalMirror records the call, and returns the result of PutExternalMirrorStub.
+func (f *Bucket) PutExternalMirror(input *service.PutBucketExternalMirrorInput) (*service.PutBucketExternalMirrorOutput, error) {
+	return f.PutExternalMirrorWithContext(context.Background(), input)
+}
+
+// PutExternalMirrorWithContext records the call, and returns the result of PutExternalMirrorStub.
+func (f *Bucket) PutExternalMirrorWithContext(ctx context.Context, input *service.PutBucketExternalMirrorInput) (*service.PutBucketExternalMirrorOutput, error) {
+	f.record(Call{Method: "PutExternalMirror", Ctx: ctx, Input: input})
+	if f.PutExternalMirrorStub != nil {
+		return f.PutExternalMirrorStub(ctx, input)
+	}
+	return &service.PutBucketExternalMirrorOutput{}, nil
+}
+
+// PutExternalMirrorReturns makes PutExternalMirror return output and err.
+func (f *Bucket) PutExternalMirrorReturns(output *service.PutBucketExternalMirrorOutput, err error) {
+	f.PutExternalMirrorStub = func(context.Context, *service.PutBucketExternalMirrorInput) (*service.PutBucketExternalMirrorOutput, error) {
+		return output, err
+	}
+}
+
+// PutLifecycle records the call, and returns the result of PutLifecycleStub.
+func (f *Bucket) PutLifecycle(input *service.PutBucketLifecycleInput) (*service.PutBucketLifecycleOutput, error) {
+	return f.PutLifecycleWithContext(context.Background(), input)
+}
+
+// PutLifecycleWithContext records the call, and returns the result of PutLifecycleStub.
+func (f *Bucket) PutLifecycleWithContext(ctx context.Context, input *service.PutBucketLifecycleInput) (*service.PutBucketLifecycleOutput, error) {
+	f.record(Call{Method: "PutLifecycle", Ctx: ctx, Input: input})
+	if f.PutLifecycleStub != nil {
+		return f.PutLifecycleStub(ctx, input)
+	}
+	return &service.PutBucketLifecycleOutput{}, nil
+}
+
+// PutLifecycleReturns makes PutLifecycle return output and err.
+func (f *Bucket) PutLifecycleReturns(output *service.PutBucketLifecycleOutput, err error) {
+	f.PutLifecycleStub = func(context.Context, *service.PutBucketLifecycleInput) (*service.PutBucketLifecycleOutput, error) {
+		return output, err
+	}
+}
+
+// PutLogging records the call, and returns the result of PutLoggingStub.
+func (f *Bucket) PutLogging(input *service.PutBucketLoggingInput) (*service.PutBucketLoggingOutput, error) {
+	return f.PutLoggingWithContext(context.Background(), input)
+}
+
+// PutLoggingWithContext records the call, and returns the result of PutLoggingStub.
+func (f *Bucket) PutLoggingWithContext(ctx context.Context, input *service.PutBucketLoggingInput) (*service.PutBucketLoggingOutput, error) {
+	f.record(Call{Method: "PutLogging", Ctx: ctx, Input: input})
+	if f.PutLoggingStub != nil {
+		return f.PutLoggingStub(ctx, input)
+	}
+	return &service.PutBucketLoggingOutput{}, nil
+}
+
+// PutLoggingReturns makes PutLogging return output and err.
+func (f *Bucket) PutLoggingReturns(output *service.PutBucketLoggingOutput, err error) {
+	f.PutLoggingStub = func(context.Context, *service.PutBucketLoggingInput) (*service.PutBucketLoggingOutput, error) {
+		return output, err
+	}
+}
+
+// PutNotification records the call, and returns the result of PutNotificationStub.
+func (f *Bucket) PutNotification(input *service.PutBucketNotificationInput) (*service.PutBucketNotificationOutput, error) {
+	return f.PutNotificationWithContext(context.Background(), input)
+}
+
+// PutNotificationWithContext records the call, and returns the result of PutNotificationStub.
+func (f *Bucket) PutNotificationWithContext(ctx context.Context, input *service.PutBucketNotificationInput) (*service.PutBucketNotificationOutput, error) {
+	f.record(Call{Method: "PutNotification", Ctx: ctx, Input: input})
+	if f.PutNotificationStub != nil {
+		return f.PutNotificationStub(ctx, input)
+	}
+	return &service.PutBucketNotificationOutput{}, nil
+}
+
+// PutNotificationReturns makes PutNotification return output and err.
+func (f *Bucket) PutNotificationReturns(output *service.PutBucketNotificationOutput, err error) {
+	f.PutNotificationStub = func(context.Context, *service.PutBucketNotificationInput) (*service.PutBucketNotificationOutput, error) {
+		return output, err
+	}
+}
+
+// PutPolicy records the call, and returns the result of PutPolicyStub.
+func (f *Bucket) PutPolicy(input *service.PutBucketPolicyInput) (*service.PutBucketPolicyOutput, error) {
+	return f.PutPolicyWithContext(context.Background(), input)
+}
+
+// PutPolicyWithContext records the call, and returns the result of PutPolicyStub.
+func (f *Bucket) PutPolicyWithContext(ctx context.Context, input *service.PutBucketPolicyInput) (*service.PutBucketPolicyOutput, error) {
+	f.record(Call{Method: "PutPolicy", Ctx: ctx, Input: input})
+	if f.PutPolicyStub != nil {
+		return f.PutPolicyStub(ctx, input)
+	}
+	return &service.PutBucketPolicyOutput{}, nil
+}
+
+// PutPolicyReturns makes PutPolicy return output and err.
+func (f *Bucket) PutPolicyReturns(output *service.PutBucketPolicyOutput, err error) {
+	f.PutPolicyStub = func(context.Context, *service.PutBucketPolicyInput) (*service.PutBucketPolicyOutput, error) {
+		return output, err
+	}
+}
+
+// PutReplication records the call, and returns the result of PutReplicationStub.
+func (f *Bucket) PutReplication(input *service.PutBucketReplicationInput) (*service.PutBucketReplicationOutput, error) {
+	return f.PutReplicationWithContext(context.Background(), input)
+}
+
+// PutReplicationWithContext records the call, and returns the result of PutReplicationStub.
+func (f *Bucket) PutReplicationWithContext(ctx context.Context, input *service.PutBucketReplicationInput) (*service.PutBucketReplicationOutput, error) {
+	f.record(Call{Method: "PutReplication", Ctx: ctx, Input: input})
+	if f.PutReplicationStub != nil {
+		return f.PutReplicationStub(ctx, input)
+	}
+	return &service.PutBucketReplicationOutput{}, nil
+}
+
+// PutReplicationReturns makes PutReplication return output and err.
+func (f *Bucket) PutReplicationReturns(output *service.PutBucketReplicationOutput, err error) {
+	f.PutReplicationStub = func(context.Context, *service.PutBucketReplicationInput) (*service.PutBucketReplicationOutput, error) {
+		return output, err
+	}
+}
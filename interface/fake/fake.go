@@ -0,0 +1,81 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package fake
+
+import (
+	"context"
+	"sync"
+)
+
+// Call is a call recorded by fakes, calls of the WithContext variants are
+// recorded with the method names without the suffix.
+type Call struct {
+	Method    string
+	Ctx       context.Context
+	ObjectKey string
+	// Input is the input of method, it's nil if the method takes no input.
+	Input interface{}
+}
+
+// recorder records the calls of a fake.
+type recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *recorder) record(c Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, c)
+}
+
+// Calls returns the calls in order.
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsOf returns the calls of method in order.
+func (r *recorder) CallsOf(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// FakeBucket returns the fake bucket returned by Bucket for bucketName and
+// zone, it's created on the first call.
+func (f *Service) FakeBucket(bucketName string, zone string) *Bucket {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.buckets == nil {
+		f.buckets = map[string]*Bucket{}
+	}
+	key := zone + "/" + bucketName
+	b := f.buckets[key]
+	if b == nil {
+		b = &Bucket{}
+		f.buckets[key] = b
+	}
+	return b
+}
@@ -0,0 +1,84 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package fake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	iface "github.com/qingstor/qingstor-sdk-go/v4/interface"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// upload is code under test depending on the interfaces only.
+func upload(qs iface.ServiceAPI, key string, content []byte) error {
+	bucket, err := qs.Bucket("bucket", "pek3b")
+	if err != nil {
+		return err
+	}
+	if _, err = bucket.HeadObject(key, nil); err == nil {
+		return nil
+	}
+	_, err = bucket.PutObjectWithContext(context.Background(), key, &service.PutObjectInput{
+		Body: bytes.NewReader(content),
+	})
+	return err
+}
+
+func TestFakeRecordsCalls(t *testing.T) {
+	qs := &Service{}
+	bucket := qs.FakeBucket("bucket", "pek3b")
+	bucket.HeadObjectReturns(nil, errors.New("not found"))
+
+	err := upload(qs, "key", []byte("content"))
+	assert.Nil(t, err)
+
+	calls := bucket.Calls()
+	assert.Len(t, calls, 2)
+	assert.Equal(t, "HeadObject", calls[0].Method)
+	assert.Equal(t, "PutObject", calls[1].Method)
+	assert.Equal(t, "key", calls[1].ObjectKey)
+	assert.NotNil(t, calls[1].Input.(*service.PutObjectInput).Body)
+	assert.Len(t, bucket.CallsOf("PutObject"), 1)
+	assert.Empty(t, qs.FakeBucket("bucket", "sh1a").Calls())
+}
+
+func TestFakeStubs(t *testing.T) {
+	qs := &Service{}
+	bucket := qs.FakeBucket("bucket", "pek3b")
+	failed := errors.New("failed")
+	bucket.HeadObjectReturns(nil, failed)
+	bucket.PutObjectStub = func(ctx context.Context, objectKey string, input *service.PutObjectInput) (*service.PutObjectOutput, error) {
+		return nil, failed
+	}
+	assert.Equal(t, failed, upload(qs, "key", nil))
+
+	qs.BucketStub = func(bucketName string, zone string) (iface.Bucket, error) {
+		return nil, failed
+	}
+	assert.Equal(t, failed, upload(qs, "key", nil))
+
+	qs.ListBucketsReturns(&service.ListBucketsOutput{Count: service.Int(1)}, nil)
+	out, err := qs.ListBuckets(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, service.IntValue(out.Count))
+	assert.Len(t, qs.CallsOf("ListBuckets"), 1)
+}
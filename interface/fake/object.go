@@ -0,0 +1,315 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package fake
+
+import (
+	"context"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// object holds the stubs of object sub service.
+type object struct {
+
+	// AbortMultipartUploadStub is called by AbortMultipartUpload and AbortMultipartUploadWithContext if it's not nil.
+	AbortMultipartUploadStub func(ctx context.Context, objectKey string, input *service.AbortMultipartUploadInput) (*service.AbortMultipartUploadOutput, error)
+
+	// AppendObjectStub is called by AppendObject and AppendObjectWithContext if it's not nil.
+	AppendObjectStub func(ctx context.Context, objectKey string, input *service.AppendObjectInput) (*service.AppendObjectOutput, error)
+
+	// CompleteMultipartUploadStub is called by CompleteMultipartUpload and CompleteMultipartUploadWithContext if it's not nil.
+	CompleteMultipartUploadStub func(ctx context.Context, objectKey string, input *service.CompleteMultipartUploadInput) (*service.CompleteMultipartUploadOutput, error)
+
+	// DeleteObjectStub is called by DeleteObject and DeleteObjectWithContext if it's not nil.
+	DeleteObjectStub func(ctx context.Context, objectKey string) (*service.DeleteObjectOutput, error)
+
+	// GetObjectStub is called by GetObject and GetObjectWithContext if it's not nil.
+	GetObjectStub func(ctx context.Context, objectKey string, input *service.GetObjectInput) (*service.GetObjectOutput, error)
+
+	// HeadObjectStub is called by HeadObject and HeadObjectWithContext if it's not nil.
+	HeadObjectStub func(ctx context.Context, objectKey string, input *service.HeadObjectInput) (*service.HeadObjectOutput, error)
+
+	// ImageProcessStub is called by ImageProcess and ImageProcessWithContext if it's not nil.
+	ImageProcessStub func(ctx context.Context, objectKey string, input *service.ImageProcessInput) (*service.ImageProcessOutput, error)
+
+	// InitiateMultipartUploadStub is called by InitiateMultipartUpload and InitiateMultipartUploadWithContext if it's not nil.
+	InitiateMultipartUploadStub func(ctx context.Context, objectKey string, input *service.InitiateMultipartUploadInput) (*service.InitiateMultipartUploadOutput, error)
+
+	// ListMultipartStub is called by ListMultipart and ListMultipartWithContext if it's not nil.
+	ListMultipartStub func(ctx context.Context, objectKey string, input *service.ListMultipartInput) (*service.ListMultipartOutput, error)
+
+	// OptionsObjectStub is called by OptionsObject and OptionsObjectWithContext if it's not nil.
+	OptionsObjectStub func(ctx context.Context, objectKey string, input *service.OptionsObjectInput) (*service.OptionsObjectOutput, error)
+
+	// PutObjectStub is called by PutObject and PutObjectWithContext if it's not nil.
+	PutObjectStub func(ctx context.Context, objectKey string, input *service.PutObjectInput) (*service.PutObjectOutput, error)
+
+	// UploadMultipartStub is called by UploadMultipart and UploadMultipartWithContext if it's not nil.
+	UploadMultipartStub func(ctx context.Context, objectKey string, input *service.UploadMultipartInput) (*service.UploadMultipartOutput, error)
+}
+
+// AbortMultipartUpload records the call, and returns the result of AbortMultipartUploadStub.
+func (f *Bucket) AbortMultipartUpload(objectKey string, input *service.AbortMultipartUploadInput) (*service.AbortMultipartUploadOutput, error) {
+	return f.AbortMultipartUploadWithContext(context.Background(), objectKey, input)
+}
+
+// AbortMultipartUploadWithContext records the call, and returns the result of AbortMultipartUploadStub.
+func (f *Bucket) AbortMultipartUploadWithContext(ctx context.Context, objectKey string, input *service.AbortMultipartUploadInput) (*service.AbortMultipartUploadOutput, error) {
+	f.record(Call{Method: "AbortMultipartUpload", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.AbortMultipartUploadStub != nil {
+		return f.AbortMultipartUploadStub(ctx, objectKey, input)
+	}
+	return &service.AbortMultipartUploadOutput{}, nil
+}
+
+// AbortMultipartUploadReturns makes AbortMultipartUpload return output and err.
+func (f *Bucket) AbortMultipartUploadReturns(output *service.AbortMultipartUploadOutput, err error) {
+	f.AbortMultipartUploadStub = func(context.Context, string, *service.AbortMultipartUploadInput) (*service.AbortMultipartUploadOutput, error) {
+		return output, err
+	}
+}
+
+// AppendObject records the call, and returns the result of AppendObjectStub.
+func (f *Bucket) AppendObject(objectKey string, input *service.AppendObjectInput) (*service.AppendObjectOutput, error) {
+	return f.AppendObjectWithContext(context.Background(), objectKey, input)
+}
+
+// AppendObjectWithContext records the call, and returns the result of AppendObjectStub.
+func (f *Bucket) AppendObjectWithContext(ctx context.Context, objectKey string, input *service.AppendObjectInput) (*service.AppendObjectOutput, error) {
+	f.record(Call{Method: "AppendObject", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.AppendObjectStub != nil {
+		return f.AppendObjectStub(ctx, objectKey, input)
+	}
+	return &service.AppendObjectOutput{}, nil
+}
+
+// AppendObjectReturns makes AppendObject return output and err.
+func (f *Bucket) AppendObjectReturns(output *service.AppendObjectOutput, err error) {
+	f.AppendObjectStub = func(context.Context, string, *service.AppendObjectInput) (*service.AppendObjectOutput, error) {
+		return output, err
+	}
+}
+
+// CompleteMultipartUpload records the call, and returns the result of CompleteMultipartUploadStub.
+func (f *Bucket) CompleteMultipartUpload(objectKey string, input *service.CompleteMultipartUploadInput) (*service.CompleteMultipartUploadOutput, error) {
+	return f.CompleteMultipartUploadWithContext(context.Background(), objectKey, input)
+}
+
+// CompleteMultipartUploadWithContext records the call, and returns the result of CompleteMultipartUploadStub.
+func (f *Bucket) CompleteMultipartUploadWithContext(ctx context.Context, objectKey string, input *service.CompleteMultipartUploadInput) (*service.CompleteMultipartUploadOutput, error) {
+	f.record(Call{Method: "CompleteMultipartUpload", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.CompleteMultipartUploadStub != nil {
+		return f.CompleteMultipartUploadStub(ctx, objectKey, input)
+	}
+	return &service.CompleteMultipartUploadOutput{}, nil
+}
+
+// CompleteMultipartUploadReturns makes CompleteMultipartUpload return output and err.
+func (f *Bucket) CompleteMultipartUploadReturns(output *service.CompleteMultipartUploadOutput, err error) {
+	f.CompleteMultipartUploadStub = func(context.Context, string, *service.CompleteMultipartUploadInput) (*service.CompleteMultipartUploadOutput, error) {
+		return output, err
+	}
+}
+
+// DeleteObject records the call, and returns the result of DeleteObjectStub.
+func (f *Bucket) DeleteObject(objectKey string) (*service.DeleteObjectOutput, error) {
+	return f.DeleteObjectWithContext(context.Background(), objectKey)
+}
+
+// DeleteObjectWithContext records the call, and returns the result of DeleteObjectStub.
+func (f *Bucket) DeleteObjectWithContext(ctx context.Context, objectKey string) (*service.DeleteObjectOutput, error) {
+	f.record(Call{Method: "DeleteObject", Ctx: ctx, ObjectKey: objectKey})
+	if f.DeleteObjectStub != nil {
+		return f.DeleteObjectStub(ctx, objectKey)
+	}
+	return &service.DeleteObjectOutput{}, nil
+}
+
+// DeleteObjectReturns makes DeleteObject return output and err.
+func (f *Bucket) DeleteObjectReturns(output *service.DeleteObjectOutput, err error) {
+	f.DeleteObjectStub = func(context.Context, string) (*service.DeleteObjectOutput, error) {
+		return output, err
+	}
+}
+
+// GetObject records the call, and returns the result of GetObjectStub.
+func (f *Bucket) GetObject(objectKey string, input *service.GetObjectInput) (*service.GetObjectOutput, error) {
+	return f.GetObjectWithContext(context.Background(), objectKey, input)
+}
+
+// GetObjectWithContext records the call, and returns the result of GetObjectStub.
+func (f *Bucket) GetObjectWithContext(ctx context.Context, objectKey string, input *service.GetObjectInput) (*service.GetObjectOutput, error) {
+	f.record(Call{Method: "GetObject", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.GetObjectStub != nil {
+		return f.GetObjectStub(ctx, objectKey, input)
+	}
+	return &service.GetObjectOutput{}, nil
+}
+
+// GetObjectReturns makes GetObject return output and err.
+func (f *Bucket) GetObjectReturns(output *service.GetObjectOutput, err error) {
+	f.GetObjectStub = func(context.Context, string, *service.GetObjectInput) (*service.GetObjectOutput, error) {
+		return output, err
+	}
+}
+
+// HeadObject records the call, and returns the result of HeadObjectStub.
+func (f *Bucket) HeadObject(objectKey string, input *service.HeadObjectInput) (*service.HeadObjectOutput, error) {
+	return f.HeadObjectWithContext(context.Background(), objectKey, input)
+}
+
+// HeadObjectWithContext records the call, and returns the result of HeadObjectStub.
+func (f *Bucket) HeadObjectWithContext(ctx context.Context, objectKey string, input *service.HeadObjectInput) (*service.HeadObjectOutput, error) {
+	f.record(Call{Method: "HeadObject", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.HeadObjectStub != nil {
+		return f.HeadObjectStub(ctx, objectKey, input)
+	}
+	return &service.HeadObjectOutput{}, nil
+}
+
+// HeadObjectReturns makes HeadObject return output and err.
+func (f *Bucket) HeadObjectReturns(output *service.HeadObjectOutput, err error) {
+	f.HeadObjectStub = func(context.Context, string, *service.HeadObjectInput) (*service.HeadObjectOutput, error) {
+		return output, err
+	}
+}
+
+// ImageProcess records the call, and returns the result of ImageProcessStub.
+func (f *Bucket) ImageProcess(objectKey string, input *service.ImageProcessInput) (*service.ImageProcessOutput, error) {
+	return f.ImageProcessWithContext(context.Background(), objectKey, input)
+}
+
+// ImageProcessWithContext records the call, and returns the result of ImageProcessStub.
+func (f *Bucket) ImageProcessWithContext(ctx context.Context, objectKey string, input *service.ImageProcessInput) (*service.ImageProcessOutput, error) {
+	f.record(Call{Method: "ImageProcess", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.ImageProcessStub != nil {
+		return f.ImageProcessStub(ctx, objectKey, input)
+	}
+	return &service.ImageProcessOutput{}, nil
+}
+
+// ImageProcessReturns makes ImageProcess return output and err.
+func (f *Bucket) ImageProcessReturns(output *service.ImageProcessOutput, err error) {
+	f.ImageProcessStub = func(context.Context, string, *service.ImageProcessInput) (*service.ImageProcessOutput, error) {
+		return output, err
+	}
+}
+
+// InitiateMultipartUpload records the call, and returns the result of InitiateMultipartUploadStub.
+func (f *Bucket) InitiateMultipartUpload(objectKey string, input *service.InitiateMultipartUploadInput) (*service.InitiateMultipartUploadOutput, error) {
+	return f.InitiateMultipartUploadWithContext(context.Background(), objectKey, input)
+}
+
+// InitiateMultipartUploadWithContext records the call, and returns the result of InitiateMultipartUploadStub.
+func (f *Bucket) InitiateMultipartUploadWithContext(ctx context.Context, objectKey string, input *service.InitiateMultipartUploadInput) (*service.InitiateMultipartUploadOutput, error) {
+	f.record(Call{Method: "InitiateMultipartUpload", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.InitiateMultipartUploadStub != nil {
+		return f.InitiateMultipartUploadStub(ctx, objectKey, input)
+	}
+	return &service.InitiateMultipartUploadOutput{}, nil
+}
+
+// InitiateMultipartUploadReturns makes InitiateMultipartUpload return output and err.
+func (f *Bucket) InitiateMultipartUploadReturns(output *service.InitiateMultipartUploadOutput, err error) {
+	f.InitiateMultipartUploadStub = func(context.Context, string, *service.InitiateMultipartUploadInput) (*service.InitiateMultipartUploadOutput, error) {
+		return output, err
+	}
+}
+
+// ListMultipart records the call, and returns the result of ListMultipartStub.
+func (f *Bucket) ListMultipart(objectKey string, input *service.ListMultipartInput) (*service.ListMultipartOutput, error) {
+	return f.ListMultipartWithContext(context.Background(), objectKey, input)
+}
+
+// ListMultipartWithContext records the call, and returns the result of ListMultipartStub.
+func (f *Bucket) ListMultipartWithContext(ctx context.Context, objectKey string, input *service.ListMultipartInput) (*service.ListMultipartOutput, error) {
+	f.record(Call{Method: "ListMultipart", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.ListMultipartStub != nil {
+		return f.ListMultipartStub(ctx, objectKey, input)
+	}
+	return &service.ListMultipartOutput{}, nil
+}
+
+// ListMultipartReturns makes ListMultipart return output and err.
+func (f *Bucket) ListMultipartReturns(output *service.ListMultipartOutput, err error) {
+	f.ListMultipartStub = func(context.Context, string, *service.ListMultipartInput) (*service.ListMultipartOutput, error) {
+		return output, err
+	}
+}
+
+// OptionsObject records the call, and returns the result of OptionsObjectStub.
+func (f *Bucket) OptionsObject(objectKey string, input *service.OptionsObjectInput) (*service.OptionsObjectOutput, error) {
+	return f.OptionsObjectWithContext(context.Background(), objectKey, input)
+}
+
+// OptionsObjectWithContext records the call, and returns the result of OptionsObjectStub.
+func (f *Bucket) OptionsObjectWithContext(ctx context.Context, objectKey string, input *service.OptionsObjectInput) (*service.OptionsObjectOutput, error) {
+	f.record(Call{Method: "OptionsObject", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.OptionsObjectStub != nil {
+		return f.OptionsObjectStub(ctx, objectKey, input)
+	}
+	return &service.OptionsObjectOutput{}, nil
+}
+
+// OptionsObjectReturns makes OptionsObject return output and err.
+func (f *Bucket) OptionsObjectReturns(output *service.OptionsObjectOutput, err error) {
+	f.OptionsObjectStub = func(context.Context, string, *service.OptionsObjectInput) (*service.OptionsObjectOutput, error) {
+		return output, err
+	}
+}
+
+// PutObject records the call, and returns the result of PutObjectStub.
+func (f *Bucket) PutObject(objectKey string, input *service.PutObjectInput) (*service.PutObjectOutput, error) {
+	return f.PutObjectWithContext(context.Background(), objectKey, input)
+}
+
+// PutObjectWithContext records the call, and returns the result of PutObjectStub.
+func (f *Bucket) PutObjectWithContext(ctx context.Context, objectKey string, input *service.PutObjectInput) (*service.PutObjectOutput, error) {
+	f.record(Call{Method: "PutObject", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.PutObjectStub != nil {
+		return f.PutObjectStub(ctx, objectKey, input)
+	}
+	return &service.PutObjectOutput{}, nil
+}
+
+// PutObjectReturns makes PutObject return output and err.
+func (f *Bucket) PutObjectReturns(output *service.PutObjectOutput, err error) {
+	f.PutObjectStub = func(context.Context, string, *service.PutObjectInput) (*service.PutObjectOutput, error) {
+		return output, err
+	}
+}
+
+// UploadMultipart records the call, and returns the result of UploadMultipartStub.
+func (f *Bucket) UploadMultipart(objectKey string, input *service.UploadMultipartInput) (*service.UploadMultipartOutput, error) {
+	return f.UploadMultipartWithContext(context.Background(), objectKey, input)
+}
+
+// UploadMultipartWithContext records the call, and returns the result of UploadMultipartStub.
+func (f *Bucket) UploadMultipartWithContext(ctx context.Context, objectKey string, input *service.UploadMultipartInput) (*service.UploadMultipartOutput, error) {
+	f.record(Call{Method: "UploadMultipart", Ctx: ctx, ObjectKey: objectKey, Input: input})
+	if f.UploadMultipartStub != nil {
+		return f.UploadMultipartStub(ctx, objectKey, input)
+	}
+	return &service.UploadMultipartOutput{}, nil
+}
+
+// UploadMultipartReturns makes UploadMultipart return output and err.
+func (f *Bucket) UploadMultipartReturns(output *service.UploadMultipartOutput, err error) {
+	f.UploadMultipartStub = func(context.Context, string, *service.UploadMultipartInput) (*service.UploadMultipartOutput, error) {
+		return output, err
+	}
+}
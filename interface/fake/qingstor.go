@@ -0,0 +1,92 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+// Package fake provides fakes of QingStor Service API interface (API Version 2016-01-06)
+//
+// The fakes record their calls, and return the results of stubs, or empty
+// outputs if the stubs are not set:
+//
+//	qs := &fake.Service{}
+//	qs.FakeBucket("bucket", "pek3b").PutObjectReturns(nil, errors.New("failed"))
+//
+//	err := codeUnderTest(qs)
+//	calls := qs.FakeBucket("bucket", "pek3b").CallsOf("PutObject")
+package fake
+
+import (
+	"context"
+
+	iface "github.com/qingstor/qingstor-sdk-go/v4/interface"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+var (
+	_ iface.ServiceAPI = (*Service)(nil)
+	_ iface.Bucket     = (*Bucket)(nil)
+)
+
+// Service is a fake of iface.ServiceAPI.
+type Service struct {
+	recorder
+
+	// BucketStub is called by Bucket if it's not nil.
+	BucketStub func(bucketName string, zone string) (iface.Bucket, error)
+
+	// ListBucketsStub is called by ListBuckets and ListBucketsWithContext if it's not nil.
+	ListBucketsStub func(ctx context.Context, input *service.ListBucketsInput) (*service.ListBucketsOutput, error)
+
+	buckets map[string]*Bucket
+}
+
+// Bucket returns the result of BucketStub, or the fake bucket got by FakeBucket.
+func (f *Service) Bucket(bucketName string, zone string) (iface.Bucket, error) {
+	if f.BucketStub != nil {
+		return f.BucketStub(bucketName, zone)
+	}
+	return f.FakeBucket(bucketName, zone), nil
+}
+
+// ListBuckets records the call, and returns the result of ListBucketsStub.
+func (f *Service) ListBuckets(input *service.ListBucketsInput) (*service.ListBucketsOutput, error) {
+	return f.ListBucketsWithContext(context.Background(), input)
+}
+
+// ListBucketsWithContext records the call, and returns the result of ListBucketsStub.
+func (f *Service) ListBucketsWithContext(ctx context.Context, input *service.ListBucketsInput) (*service.ListBucketsOutput, error) {
+	f.record(Call{Method: "ListBuckets", Ctx: ctx, Input: input})
+	if f.ListBucketsStub != nil {
+		return f.ListBucketsStub(ctx, input)
+	}
+	return &service.ListBucketsOutput{}, nil
+}
+
+// ListBucketsReturns makes ListBuckets return output and err.
+func (f *Service) ListBucketsReturns(output *service.ListBucketsOutput, err error) {
+	f.ListBucketsStub = func(context.Context, *service.ListBucketsInput) (*service.ListBucketsOutput, error) {
+		return output, err
+	}
+}
+
+// Bucket is a fake of iface.Bucket.
+type Bucket struct {
+	recorder
+
+	// bucket holds the stubs of bucket sub service.
+	bucket
+
+	// object holds the stubs of object sub service.
+	object
+}
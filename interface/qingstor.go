@@ -33,6 +33,17 @@ type Service interface {
 	ListBucketsWithContext(ctx context.Context, input *service.ListBucketsInput) (*service.ListBucketsOutput, error)
 }
 
+// ServiceAPI is Service whose Bucket returns Bucket instead of
+// *service.Bucket, so the buckets can be faked too.
+type ServiceAPI interface {
+	// Bucket initializes a new bucket.
+	Bucket(bucketName string, zone string) (Bucket, error)
+
+	// ListBuckets does Retrieve the bucket list.
+	ListBuckets(input *service.ListBucketsInput) (*service.ListBucketsOutput, error)
+	ListBucketsWithContext(ctx context.Context, input *service.ListBucketsInput) (*service.ListBucketsOutput, error)
+}
+
 // Bucket is the method set for all public bucket API.
 type Bucket interface {
 
@@ -0,0 +1,45 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package iface
+
+import (
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+var (
+	_ Service = (*service.Service)(nil)
+	_ Bucket  = (*service.Bucket)(nil)
+)
+
+// NewService returns s as ServiceAPI, whose Bucket returns the buckets as
+// Bucket, so code can depend on the interfaces only.
+func NewService(s *service.Service) ServiceAPI {
+	return serviceWrapper{s}
+}
+
+type serviceWrapper struct {
+	*service.Service
+}
+
+// Bucket initializes a new bucket.
+func (s serviceWrapper) Bucket(bucketName string, zone string) (Bucket, error) {
+	b, err := s.Service.Bucket(bucketName, zone)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,38 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+func TestNewService(t *testing.T) {
+	conf, err := config.New("ACCESS_KEY_ID", "SECRET_ACCESS_KEY")
+	assert.Nil(t, err)
+	qs, err := service.Init(conf)
+	assert.Nil(t, err)
+
+	bucket, err := NewService(qs).Bucket("bucket", "pek3b")
+	assert.Nil(t, err)
+	_, ok := bucket.(*service.Bucket)
+	assert.True(t, ok)
+}
@@ -0,0 +1,62 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+// Package multipart shares the handling of multipart uploads between the
+// ObjectWriter of service and the upload and copy clients.
+package multipart
+
+import (
+	"context"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/request"
+)
+
+// AbortTimeout limits the time spent on aborting a failed multipart upload,
+// which is not bound to the context of transfer as it may be canceled.
+const AbortTimeout = time.Minute
+
+// Abort calls abort with a context detached from ctx, so that a canceled
+// transfer still releases its uploaded parts. The context keeps the values
+// of ctx such as the logger, and times out after AbortTimeout.
+func Abort(ctx context.Context, abort func(ctx context.Context) error) error {
+	abortCtx, cancel := context.WithTimeout(detachedContext{ctx}, AbortTimeout)
+	defer cancel()
+	return abort(abortCtx)
+}
+
+// detachedContext keeps the values of Context, but is never canceled.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// InitiateHeaders returns the handler which sets the headers missing in
+// InitiateMultipartUploadInput, it runs after the request is built.
+func InitiateHeaders(cacheControl, contentEncoding *string) request.NamedHandler {
+	return request.NamedHandler{Name: "multipart.InitiateHeaders", Fn: func(ctx context.Context, r *request.Request) error {
+		if cacheControl != nil {
+			r.HTTPRequest.Header.Set("Cache-Control", *cacheControl)
+		}
+		if contentEncoding != nil {
+			r.HTTPRequest.Header.Set("Content-Encoding", *contentEncoding)
+		}
+		return nil
+	}}
+}
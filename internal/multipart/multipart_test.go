@@ -0,0 +1,54 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package multipart
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/request"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/signer/v2"
+)
+
+type valueKey struct{}
+
+func TestAbortDetached(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), valueKey{}, "value"))
+	cancel()
+
+	err := Abort(ctx, func(ctx context.Context) error {
+		assert.Nil(t, ctx.Err())
+		assert.Equal(t, "value", ctx.Value(valueKey{}))
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.True(t, deadline.After(time.Now()))
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestInitiateHeaders(t *testing.T) {
+	r := &request.Request{HTTPRequest: signer.CanonicalReq{Request: &http.Request{Header: http.Header{}}}}
+	cacheControl := "no-cache"
+	assert.Nil(t, InitiateHeaders(&cacheControl, nil).Fn(context.Background(), r))
+	assert.Equal(t, "no-cache", r.HTTPRequest.Header.Get("Cache-Control"))
+	assert.Empty(t, r.HTTPRequest.Header.Get("Content-Encoding"))
+}
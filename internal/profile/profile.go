@@ -0,0 +1,171 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+// Package profile resolves the named profiles of QingStor config files.
+package profile
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Env is the environment variable which selects the profile to load.
+const Env = "QINGSTOR_PROFILE"
+
+const (
+	// profilesKey is the key of the profiles section in config file.
+	profilesKey = "profiles"
+
+	// profileBaseKey is the key of the profile which a profile inherits from.
+	profileBaseKey = "base"
+
+	// legacyHTTPSettingsKey is the key of HTTPSettings before it was tagged.
+	legacyHTTPSettingsKey = "httpsettings"
+	httpSettingsKey       = "http_settings"
+)
+
+// Resolve returns the yaml content of given profile in content.
+//
+// Every profile inherits the top level configuration, or the profile named by
+// its base key, and overrides fields it sets. Nested settings such as
+// http_settings are merged field by field.
+// The top level configuration is returned if profile is empty.
+func Resolve(content []byte, profile string) ([]byte, error) {
+	top, profiles, err := parse(content)
+	if err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		return yaml.Marshal(top)
+	}
+
+	resolved, err := resolveProfileMap(top, profiles, profile, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(resolved)
+}
+
+// ResolveEnv returns the yaml content of the profile named by Env in content.
+// The top level configuration is returned if Env is not set, or content
+// doesn't define the profile, so that files without profiles keep loading.
+func ResolveEnv(content []byte) ([]byte, error) {
+	top, profiles, err := parse(content)
+	if err != nil {
+		return nil, err
+	}
+	profile := os.Getenv(Env)
+	if _, ok := profiles[profile]; !ok {
+		return yaml.Marshal(top)
+	}
+
+	resolved, err := resolveProfileMap(top, profiles, profile, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(resolved)
+}
+
+// parse splits content into the top level configuration and the profiles.
+func parse(content []byte) (top, profiles map[interface{}]interface{}, err error) {
+	top = map[interface{}]interface{}{}
+	err = yaml.Unmarshal(content, &top)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profiles = map[interface{}]interface{}{}
+	if v, ok := top[profilesKey]; ok && v != nil {
+		profiles, ok = v.(map[interface{}]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("%s should be a map of profiles", profilesKey)
+		}
+	}
+	delete(top, profilesKey)
+	normalizeConfigMap(top)
+	return top, profiles, nil
+}
+
+func resolveProfileMap(
+	top, profiles map[interface{}]interface{}, name string, visited map[string]bool,
+) (map[interface{}]interface{}, error) {
+	if visited[name] {
+		return nil, fmt.Errorf("profile %s inherits from itself", name)
+	}
+	visited[name] = true
+
+	v, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found", name)
+	}
+	p, ok := v.(map[interface{}]interface{})
+	if !ok && v != nil {
+		return nil, fmt.Errorf("profile %s should be a map", name)
+	}
+	p = copyConfigMap(p)
+	normalizeConfigMap(p)
+
+	base := top
+	if b, ok := p[profileBaseKey]; ok {
+		baseName, ok := b.(string)
+		if !ok {
+			return nil, fmt.Errorf("base of profile %s should be a string", name)
+		}
+		var err error
+		base, err = resolveProfileMap(top, profiles, baseName, visited)
+		if err != nil {
+			return nil, err
+		}
+	}
+	delete(p, profileBaseKey)
+
+	return mergeConfigMap(base, p), nil
+}
+
+// normalizeConfigMap renames the legacy keys in m.
+func normalizeConfigMap(m map[interface{}]interface{}) {
+	if v, ok := m[legacyHTTPSettingsKey]; ok {
+		if _, ok := m[httpSettingsKey]; !ok {
+			m[httpSettingsKey] = v
+		}
+		delete(m, legacyHTTPSettingsKey)
+	}
+}
+
+// mergeConfigMap returns a new map with values in override set over base recursively.
+func mergeConfigMap(base, override map[interface{}]interface{}) map[interface{}]interface{} {
+	merged := copyConfigMap(base)
+	for k, v := range override {
+		bm, bok := merged[k].(map[interface{}]interface{})
+		om, ook := v.(map[interface{}]interface{})
+		if bok && ook {
+			merged[k] = mergeConfigMap(bm, om)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+func copyConfigMap(m map[interface{}]interface{}) map[interface{}]interface{} {
+	c := make(map[interface{}]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
 type contextKey struct{}
@@ -25,8 +24,24 @@ func ContextWithLogger(ctx context.Context, l *zap.Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, l)
 }
 
+// ContextWithDefaultLogger set *Logger into given context and return,
+// it keeps the logger already set in ctx, so that logger given per call takes precedence.
+func ContextWithDefaultLogger(ctx context.Context, l *zap.Logger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if l == nil {
+		return ctx
+	}
+	if _, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, loggerKey, l)
+}
+
 // FromContext get *Logger from context
-// Notice: If ctx is nil or no Logger was set before, it will return a default logger
+// Notice: If ctx is nil or no Logger was set before, it will return the logger returned by Default
 func FromContext(ctx context.Context) *zap.Logger {
 	if ctx != nil {
 		l, ok := ctx.Value(loggerKey).(*zap.Logger)
@@ -35,6 +50,5 @@ func FromContext(ctx context.Context) *zap.Logger {
 		}
 	}
 
-	logger, _ := zap.NewProduction()
-	return logger.WithOptions(zap.IncreaseLevel(zapcore.WarnLevel))
+	return Default()
 }
@@ -0,0 +1,155 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package log
+
+import (
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultLevel is the level used when the given level is empty or invalid.
+// It keeps the level of the default logger before LogLevel was honored, which
+// was a zap.NewProduction logger increased to warn, so the SDK doesn't log
+// more than it did unless a lower level is configured.
+const DefaultLevel = zapcore.WarnLevel
+
+var (
+	defaultLogger     *zap.Logger
+	defaultLoggerOnce sync.Once
+)
+
+// Default returns the logger used when no logger was given, which is
+// initialized by zap.NewProduction with DefaultLevel once and shared.
+func Default() *zap.Logger {
+	defaultLoggerOnce.Do(func() {
+		defaultLogger = New("")
+	})
+	return defaultLogger
+}
+
+// New creates a production *zap.Logger with given level, such as "debug",
+// "info", "warn", "error" and "fatal".
+// DefaultLevel is used if level is empty or invalid.
+func New(level string) *zap.Logger {
+	c := zap.NewProductionConfig()
+	c.Level = zap.NewAtomicLevelAt(ParseLevel(level))
+	logger, err := c.Build()
+	if err != nil {
+		return zap.NewNop()
+	}
+	return logger
+}
+
+// ParseLevel parses level string into zapcore.Level.
+// It returns DefaultLevel if level is empty or invalid.
+func ParseLevel(level string) zapcore.Level {
+	l := DefaultLevel
+	if level == "" {
+		return l
+	}
+	err := l.UnmarshalText([]byte(level))
+	if err != nil {
+		return DefaultLevel
+	}
+	return l
+}
+
+// Logger is a minimal logger interface for users not using zap,
+// keysAndValues are the alternating keys and values of the fields.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// FromLogger creates a *zap.Logger writing entries enabled by given level into l.
+// Entries above error level are written by l.Error.
+func FromLogger(l Logger, level string) *zap.Logger {
+	return zap.New(&loggerCore{
+		LevelEnabler: ParseLevel(level),
+		logger:       l,
+	})
+}
+
+type loggerCore struct {
+	zapcore.LevelEnabler
+
+	logger Logger
+	fields []zapcore.Field
+}
+
+func (c *loggerCore) With(fields []zapcore.Field) zapcore.Core {
+	fs := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	fs = append(fs, c.fields...)
+	fs = append(fs, fields...)
+	return &loggerCore{
+		LevelEnabler: c.LevelEnabler,
+		logger:       c.logger,
+		fields:       fs,
+	}
+}
+
+func (c *loggerCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c *loggerCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	kvs := make([]interface{}, 0, 2*(len(c.fields)+len(fields)))
+	kvs = appendFields(kvs, c.fields)
+	kvs = appendFields(kvs, fields)
+
+	switch e.Level {
+	case zapcore.DebugLevel:
+		c.logger.Debug(e.Message, kvs...)
+	case zapcore.InfoLevel:
+		c.logger.Info(e.Message, kvs...)
+	case zapcore.WarnLevel:
+		c.logger.Warn(e.Message, kvs...)
+	default:
+		c.logger.Error(e.Message, kvs...)
+	}
+	return nil
+}
+
+func (c *loggerCore) Sync() error {
+	return nil
+}
+
+// appendFields encodes fields in order and appends them as keys and values.
+func appendFields(kvs []interface{}, fields []zapcore.Field) []interface{} {
+	for _, f := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+
+		keys := make([]string, 0, len(enc.Fields))
+		for k := range enc.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kvs = append(kvs, k, enc.Fields[k])
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,103 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testLogger struct {
+	entries []string
+}
+
+func (l *testLogger) log(level, msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, fmt.Sprint(level, " ", msg, " ", keysAndValues))
+}
+
+func (l *testLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log("debug", msg, keysAndValues...)
+}
+
+func (l *testLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.log("info", msg, keysAndValues...)
+}
+
+func (l *testLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log("warn", msg, keysAndValues...)
+}
+
+func (l *testLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.log("error", msg, keysAndValues...)
+}
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, zapcore.DebugLevel, ParseLevel("debug"))
+	assert.Equal(t, zapcore.ErrorLevel, ParseLevel("ERROR"))
+	assert.Equal(t, DefaultLevel, ParseLevel(""))
+	assert.Equal(t, DefaultLevel, ParseLevel("verbose"))
+}
+
+func TestNew(t *testing.T) {
+	l := New("debug")
+	assert.True(t, l.Core().Enabled(zapcore.DebugLevel))
+
+	l = New("error")
+	assert.False(t, l.Core().Enabled(zapcore.WarnLevel))
+	assert.True(t, l.Core().Enabled(zapcore.ErrorLevel))
+}
+
+func TestDefault(t *testing.T) {
+	assert.True(t, Default() == Default())
+	assert.True(t, FromContext(nil) == Default())
+	assert.True(t, FromContext(context.Background()) == Default())
+	assert.False(t, Default().Core().Enabled(zapcore.InfoLevel))
+}
+
+func TestContextWithDefaultLogger(t *testing.T) {
+	l := zap.NewNop()
+	ctx := ContextWithDefaultLogger(context.Background(), l)
+	assert.True(t, FromContext(ctx) == l)
+
+	other := zap.NewNop()
+	ctx = ContextWithDefaultLogger(ContextWithLogger(context.Background(), other), l)
+	assert.True(t, FromContext(ctx) == other)
+
+	ctx = ContextWithDefaultLogger(context.Background(), nil)
+	assert.True(t, FromContext(ctx) == Default())
+}
+
+func TestFromLogger(t *testing.T) {
+	tl := &testLogger{}
+	l := FromLogger(tl, "info")
+
+	l.Debug("ignored")
+	l.With(zap.String("api", "Put Object")).Info("sending request", zap.Int("code", 200))
+	l.Warn("retry", zap.Error(fmt.Errorf("failed")))
+	l.DPanic("panic")
+
+	assert.Equal(t, []string{
+		"info sending request [api Put Object code 200]",
+		"warn retry [error failed]",
+		"error panic []",
+	}, tl.entries)
+}
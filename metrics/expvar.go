@@ -0,0 +1,105 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Expvar is a Sink which publishes counters of every operation by expvar,
+// so they can be read from "/debug/vars" without any collector.
+//
+// Counters of an operation are kept in a map under the operation name:
+// "requests", "errors", "attempts", "latency_ns", "bytes_sent",
+// "bytes_received" and "status_<code>".
+type Expvar struct {
+	root *expvar.Map
+
+	mu         sync.Mutex
+	operations map[string]*expvar.Map
+}
+
+// publishMu serializes the publishing of maps by NewExpvar, as expvar panics
+// if a name is published twice.
+var publishMu sync.Mutex
+
+// NewExpvar creates an Expvar sink published with given name.
+// It reuses the published map if name has been published as a map before,
+// and returns error if name has been published as another type of var.
+func NewExpvar(name string) (*Expvar, error) {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	v := expvar.Get(name)
+	if v == nil {
+		return NewExpvarWithMap(expvar.NewMap(name)), nil
+	}
+	root, ok := v.(*expvar.Map)
+	if !ok {
+		return nil, fmt.Errorf("expvar %s has been published as %T", name, v)
+	}
+	return NewExpvarWithMap(root), nil
+}
+
+// NewExpvarWithMap creates an Expvar sink writes into given map,
+// which is not necessarily published.
+func NewExpvarWithMap(root *expvar.Map) *Expvar {
+	return &Expvar{
+		root:       root,
+		operations: map[string]*expvar.Map{},
+	}
+}
+
+// Map returns the map which the counters are written into.
+func (e *Expvar) Map() *expvar.Map {
+	return e.root
+}
+
+// Observe implements Sink.
+func (e *Expvar) Observe(s *Sample) {
+	m := e.operation(s.Operation)
+
+	m.Add("requests", 1)
+	if s.Failed {
+		m.Add("errors", 1)
+	}
+	m.Add("attempts", int64(s.Attempts))
+	m.Add("latency_ns", int64(s.Latency))
+	m.Add("bytes_sent", s.BytesSent)
+	m.Add("bytes_received", s.BytesReceived)
+	if s.StatusCode > 0 {
+		m.Add("status_"+strconv.Itoa(s.StatusCode), 1)
+	}
+}
+
+func (e *Expvar) operation(name string) *expvar.Map {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	m, ok := e.operations[name]
+	if !ok {
+		if m, ok = e.root.Get(name).(*expvar.Map); !ok {
+			m = new(expvar.Map).Init()
+			e.root.Set(name, m)
+		}
+		e.operations[name] = m
+	}
+	return m
+}
@@ -0,0 +1,99 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats is the aggregation of samples of an operation.
+type Stats struct {
+	Requests      int64
+	Errors        int64
+	Attempts      int64
+	Latency       time.Duration
+	BytesSent     int64
+	BytesReceived int64
+}
+
+func (s *Stats) add(sample *Sample) {
+	s.Requests++
+	if sample.Failed {
+		s.Errors++
+	}
+	s.Attempts += int64(sample.Attempts)
+	s.Latency += sample.Latency
+	s.BytesSent += sample.BytesSent
+	s.BytesReceived += sample.BytesReceived
+}
+
+// Memory is a Sink which keeps all samples in memory, it's useful in tests.
+type Memory struct {
+	mu      sync.Mutex
+	samples []Sample
+	stats   map[string]*Stats
+}
+
+// NewMemory creates an empty Memory sink.
+func NewMemory() *Memory {
+	return &Memory{stats: map[string]*Stats{}}
+}
+
+// Observe implements Sink.
+func (m *Memory) Observe(s *Sample) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples = append(m.samples, *s)
+	stats, ok := m.stats[s.Operation]
+	if !ok {
+		stats = &Stats{}
+		m.stats[s.Operation] = stats
+	}
+	stats.add(s)
+}
+
+// Samples returns a copy of all observed samples in order.
+func (m *Memory) Samples() []Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	samples := make([]Sample, len(m.samples))
+	copy(samples, m.samples)
+	return samples
+}
+
+// Stats returns the aggregation of samples of given operation.
+func (m *Memory) Stats(operation string) Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stats, ok := m.stats[operation]; ok {
+		return *stats
+	}
+	return Stats{}
+}
+
+// Reset drops all observed samples.
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples = nil
+	m.stats = map[string]*Stats{}
+}
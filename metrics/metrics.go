@@ -0,0 +1,55 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+// Package metrics provides the sink of API request metrics and its implementations.
+package metrics
+
+import (
+	"time"
+)
+
+// Sample is the measurement of a finished API request.
+type Sample struct {
+	// Operation is the API name, e.g. "PUT Object".
+	Operation string
+	Bucket    string
+	Zone      string
+
+	// StatusCode is the status code of the last response, 0 if no response received.
+	StatusCode int
+	// ErrorCode is the QingStor error code if the request failed with QingStor error.
+	ErrorCode string
+	// Failed is true if the request returned error.
+	Failed bool
+
+	// Attempts is the count of attempts including retries.
+	Attempts int
+	// Latency is the duration from the first attempt to the end of the last one.
+	Latency time.Duration
+
+	// BytesSent is the request body bytes sent summed over all attempts.
+	BytesSent int64
+	// BytesReceived is the response body bytes read summed over all
+	// attempts. Bodies streamed to the caller such as GetObject's are read
+	// after the sample is reported, so they are counted by Content-Length.
+	BytesReceived int64
+}
+
+// Sink receives samples of API requests.
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	Observe(s *Sample)
+}
@@ -0,0 +1,79 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package metrics
+
+import (
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var samples = []*Sample{
+	{Operation: "PUT Object", StatusCode: 201, Attempts: 1, Latency: time.Second, BytesSent: 100},
+	{Operation: "PUT Object", StatusCode: 503, ErrorCode: "service_unavailable", Failed: true, Attempts: 4, Latency: time.Second},
+	{Operation: "GET Object", StatusCode: 200, Attempts: 1, BytesReceived: 200},
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	for _, s := range samples {
+		m.Observe(s)
+	}
+
+	assert.Equal(t, 3, len(m.Samples()))
+	assert.Equal(t, Stats{
+		Requests:  2,
+		Errors:    1,
+		Attempts:  5,
+		Latency:   2 * time.Second,
+		BytesSent: 100,
+	}, m.Stats("PUT Object"))
+	assert.Equal(t, int64(200), m.Stats("GET Object").BytesReceived)
+	assert.Equal(t, Stats{}, m.Stats("HEAD Object"))
+
+	m.Reset()
+	assert.Equal(t, 0, len(m.Samples()))
+}
+
+func TestExpvar(t *testing.T) {
+	e, err := NewExpvar("qingstor_test")
+	assert.Nil(t, err)
+	reused, err := NewExpvar("qingstor_test")
+	assert.Nil(t, err)
+	assert.Equal(t, e.Map(), reused.Map())
+
+	expvar.NewInt("qingstor_test_int")
+	_, err = NewExpvar("qingstor_test_int")
+	assert.NotNil(t, err)
+
+	for _, s := range samples {
+		e.Observe(s)
+	}
+
+	put := e.Map().Get("PUT Object").(*expvar.Map)
+	assert.Equal(t, "2", put.Get("requests").String())
+	assert.Equal(t, "1", put.Get("errors").String())
+	assert.Equal(t, "5", put.Get("attempts").String())
+	assert.Equal(t, "1", put.Get("status_503").String())
+	assert.Equal(t, "100", put.Get("bytes_sent").String())
+
+	get := e.Map().Get("GET Object").(*expvar.Map)
+	assert.Equal(t, "200", get.Get("bytes_received").String())
+	assert.Nil(t, get.Get("errors"))
+}
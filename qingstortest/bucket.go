@@ -0,0 +1,417 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// subresources are the bucket settings stored as they are put.
+var subresources = []string{
+	"acl", "cname", "cors", "lifecycle", "logging", "mirror", "notification", "policy", "replication",
+}
+
+type bucket struct {
+	name     string
+	zone     string
+	created  time.Time
+	objects  map[string]*object
+	uploads  map[string]*upload
+	settings map[string][]byte
+}
+
+func (b *bucket) owner() *service.OwnerType {
+	return &service.OwnerType{ID: service.String("qingstortest"), Name: service.String("qingstortest")}
+}
+
+// serveService serves the APIs without bucket.
+func (s *Server) serveService(w *responseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.error(errNotImplemented)
+		return
+	}
+	s.listBuckets(w, r)
+}
+
+// serveBucket serves the APIs of bucket.
+func (s *Server) serveBucket(w *responseWriter, r *http.Request, t target) {
+	query := r.URL.Query()
+	for _, name := range subresources {
+		if _, ok := query[name]; ok {
+			s.serveSetting(w, r, t, name)
+			return
+		}
+	}
+	_, isUploads := query["uploads"]
+	_, isDelete := query["delete"]
+	_, isStats := query["stats"]
+
+	switch {
+	case r.Method == http.MethodPut:
+		s.putBucket(w, t)
+	case r.Method == http.MethodDelete:
+		s.deleteBucket(w, t)
+	case r.Method == http.MethodHead:
+		s.withBucket(w, t, func(b *bucket) {
+			w.status(http.StatusOK)
+		})
+	case r.Method == http.MethodGet && isUploads:
+		s.listMultipartUploads(w, r, t)
+	case r.Method == http.MethodGet && isStats:
+		s.withBucket(w, t, func(b *bucket) {
+			var size int64
+			for _, o := range b.objects {
+				size += int64(len(o.data))
+			}
+			w.json(http.StatusOK, &service.GetBucketStatisticsOutput{
+				Count:    service.Int64(int64(len(b.objects))),
+				Created:  service.Time(b.created),
+				Location: service.String(b.zone),
+				Name:     service.String(b.name),
+				Size:     service.Int64(size),
+				Status:   service.String("active"),
+			})
+		})
+	case r.Method == http.MethodGet:
+		s.listObjects(w, r, t)
+	case r.Method == http.MethodPost && isDelete:
+		s.deleteMultipleObjects(w, r, t)
+	default:
+		w.error(errNotImplemented)
+	}
+}
+
+// withBucket calls fn with the bucket locked.
+func (s *Server) withBucket(w *responseWriter, t target, fn func(b *bucket)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buckets[t.bucket]
+	if b == nil {
+		w.error(errBucketNotExists)
+		return
+	}
+	fn(b)
+}
+
+func (s *Server) listBuckets(w *responseWriter, r *http.Request) {
+	query := r.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 {
+		limit = 200
+	}
+	location := r.Header.Get("Location")
+
+	s.mu.Lock()
+	var names []string
+	for name, b := range s.buckets {
+		if location == "" || b.zone == location {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	buckets := []*service.BucketType{}
+	for i := offset; i < len(names) && i < offset+limit; i++ {
+		b := s.buckets[names[i]]
+		buckets = append(buckets, &service.BucketType{
+			Name:     service.String(b.name),
+			Location: service.String(b.zone),
+			Created:  service.Time(b.created),
+		})
+	}
+	s.mu.Unlock()
+
+	w.json(http.StatusOK, map[string]interface{}{"count": len(names), "buckets": buckets})
+}
+
+func (s *Server) putBucket(w *responseWriter, t target) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[t.bucket] != nil {
+		w.error(newError(http.StatusConflict, "bucket_already_exists", "the bucket already exists"))
+		return
+	}
+	s.buckets[t.bucket] = &bucket{
+		name:     t.bucket,
+		zone:     t.zone,
+		created:  time.Now().UTC().Truncate(time.Second),
+		objects:  map[string]*object{},
+		uploads:  map[string]*upload{},
+		settings: map[string][]byte{},
+	}
+	w.status(http.StatusCreated)
+}
+
+func (s *Server) deleteBucket(w *responseWriter, t target) {
+	s.withBucket(w, t, func(b *bucket) {
+		if len(b.objects) > 0 || len(b.uploads) > 0 {
+			w.error(newError(http.StatusConflict, "bucket_not_empty", "the bucket is not empty"))
+			return
+		}
+		delete(s.buckets, t.bucket)
+		w.status(http.StatusNoContent)
+	})
+}
+
+// serveSetting stores and returns the JSON of bucket settings as they are.
+func (s *Server) serveSetting(w *responseWriter, r *http.Request, t target, name string) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.error(err)
+		return
+	}
+
+	s.withBucket(w, t, func(b *bucket) {
+		switch r.Method {
+		case http.MethodPut:
+			if !json.Valid(body) {
+				w.error(errInvalidRequest("the body of %s is not valid json", name))
+				return
+			}
+			b.settings[name] = body
+			w.status(http.StatusOK)
+		case http.MethodGet:
+			content, ok := b.settings[name]
+			if !ok && name == "acl" {
+				w.json(http.StatusOK, &service.GetBucketACLOutput{
+					Owner: b.owner(),
+					ACL: []*service.ACLType{{
+						Grantee:    &service.GranteeType{Type: service.String("user"), ID: b.owner().ID},
+						Permission: service.String("FULL_CONTROL"),
+					}},
+				})
+				return
+			}
+			if !ok {
+				w.error(newError(http.StatusNotFound, name+"_not_exists", "the "+name+" of bucket is not set"))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(content)
+		case http.MethodDelete:
+			delete(b.settings, name)
+			w.status(http.StatusNoContent)
+		default:
+			w.error(errNotImplemented)
+		}
+	})
+}
+
+// listEntry is a key or common prefix in the result of list.
+type listEntry struct {
+	key    string
+	prefix bool
+}
+
+// listKeys applies prefix, delimiter, marker and limit to sorted keys. It
+// returns the entries after marker, and whether there are more entries.
+func listKeys(keys []string, prefix, delimiter, marker string, limit int) ([]listEntry, bool) {
+	var entries []listEntry
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		entry := listEntry{key: key}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entry = listEntry{key: key[:len(prefix)+i+len(delimiter)], prefix: true}
+			}
+		}
+		if entry.key <= marker {
+			continue
+		}
+		if n := len(entries); n > 0 && entries[n-1] == entry {
+			continue
+		}
+		if len(entries) == limit {
+			return entries, true
+		}
+		entries = append(entries, entry)
+	}
+	return entries, false
+}
+
+func listLimit(r *http.Request) int {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		return 200
+	}
+	if limit > 1000 {
+		return 1000
+	}
+	return limit
+}
+
+func (s *Server) listObjects(w *responseWriter, r *http.Request, t target) {
+	query := r.URL.Query()
+	prefix, delimiter, marker := query.Get("prefix"), query.Get("delimiter"), query.Get("marker")
+	limit := listLimit(r)
+
+	s.withBucket(w, t, func(b *bucket) {
+		keys := make([]string, 0, len(b.objects))
+		for key := range b.objects {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries, hasMore := listKeys(keys, prefix, delimiter, marker, limit)
+
+		output := map[string]interface{}{
+			"name":      b.name,
+			"prefix":    prefix,
+			"delimiter": delimiter,
+			"marker":    marker,
+			"limit":     limit,
+			"has_more":  hasMore,
+			"owner":     b.owner(),
+		}
+		objects := []*service.KeyType{}
+		prefixes := []string{}
+		for _, e := range entries {
+			if e.prefix {
+				prefixes = append(prefixes, e.key)
+				continue
+			}
+			o := b.objects[e.key]
+			objects = append(objects, &service.KeyType{
+				Key:          service.String(e.key),
+				Size:         service.Int64(int64(len(o.data))),
+				Etag:         service.String(o.etag),
+				MimeType:     service.String(o.contentType),
+				Created:      service.Time(o.modified),
+				Modified:     service.Int(int(o.modified.Unix())),
+				StorageClass: service.String(o.storageClass),
+				Encrypted:    service.Bool(o.encryptionKeyMD5 != ""),
+			})
+		}
+		output["keys"] = objects
+		output["common_prefixes"] = prefixes
+		if hasMore {
+			output["next_marker"] = entries[len(entries)-1].key
+		}
+		w.json(http.StatusOK, output)
+	})
+}
+
+func (s *Server) listMultipartUploads(w *responseWriter, r *http.Request, t target) {
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	keyMarker, uploadIDMarker := query.Get("key_marker"), query.Get("upload_id_marker")
+	limit := listLimit(r)
+
+	s.withBucket(w, t, func(b *bucket) {
+		uploads := make([]*upload, 0, len(b.uploads))
+		for _, u := range b.uploads {
+			uploads = append(uploads, u)
+		}
+		sort.Slice(uploads, func(i, j int) bool {
+			if uploads[i].key != uploads[j].key {
+				return uploads[i].key < uploads[j].key
+			}
+			return uploads[i].id < uploads[j].id
+		})
+
+		var result []*service.UploadsType
+		prefixes := []string{}
+		hasMore := false
+		var nextKey, nextID string
+		for _, u := range uploads {
+			if !strings.HasPrefix(u.key, prefix) {
+				continue
+			}
+			if u.key < keyMarker || (u.key == keyMarker && (uploadIDMarker == "" || u.id <= uploadIDMarker)) {
+				continue
+			}
+			commonPrefix := ""
+			if delimiter != "" {
+				if i := strings.Index(u.key[len(prefix):], delimiter); i >= 0 {
+					commonPrefix = u.key[:len(prefix)+i+len(delimiter)]
+				}
+			}
+			if commonPrefix != "" && (commonPrefix <= keyMarker ||
+				(len(prefixes) > 0 && prefixes[len(prefixes)-1] == commonPrefix)) {
+				continue
+			}
+			if len(result)+len(prefixes) == limit {
+				hasMore = true
+				break
+			}
+			if commonPrefix != "" {
+				prefixes = append(prefixes, commonPrefix)
+				nextKey, nextID = commonPrefix, ""
+				continue
+			}
+			result = append(result, &service.UploadsType{
+				Key:      service.String(u.key),
+				UploadID: service.String(u.id),
+				Created:  service.Time(u.created),
+			})
+			nextKey, nextID = u.key, u.id
+		}
+
+		output := map[string]interface{}{
+			"name":            b.name,
+			"prefix":          prefix,
+			"delimiter":       delimiter,
+			"marker":          keyMarker,
+			"limit":           limit,
+			"has_more":        hasMore,
+			"uploads":         []*service.UploadsType{},
+			"common_prefixes": prefixes,
+		}
+		if result != nil {
+			output["uploads"] = result
+		}
+		if hasMore {
+			output["next_key_marker"] = nextKey
+			output["next_upload_id_marker"] = nextID
+		}
+		w.json(http.StatusOK, output)
+	})
+}
+
+func (s *Server) deleteMultipleObjects(w *responseWriter, r *http.Request, t target) {
+	input := &service.DeleteMultipleObjectsInput{}
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		w.error(errInvalidRequest("invalid body: %s", err))
+		return
+	}
+
+	s.withBucket(w, t, func(b *bucket) {
+		deleted := []*service.KeyType{}
+		for _, o := range input.Objects {
+			key := service.StringValue(o.Key)
+			delete(b.objects, key)
+			deleted = append(deleted, &service.KeyType{Key: service.String(key)})
+		}
+		if service.BoolValue(input.Quiet) {
+			deleted = []*service.KeyType{}
+		}
+		w.json(http.StatusOK, map[string]interface{}{
+			"deleted": deleted,
+			"errors":  []*service.KeyDeleteErrorType{},
+		})
+	})
+}
@@ -0,0 +1,290 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/request"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// FaultKind is the kind of Fault.
+type FaultKind int
+
+// Kinds of Fault.
+const (
+	// FaultLatency delays requests by Latency before sending them.
+	FaultLatency FaultKind = iota + 1
+	// FaultStatus responds StatusCode without sending requests, their bodies
+	// are read as by a server which fails after receiving them.
+	FaultStatus
+	// FaultReset resets the connection after Offset bytes of response body.
+	FaultReset
+	// FaultTruncate ends the response body after Offset bytes silently.
+	FaultTruncate
+	// FaultSlowRead delays every read of response body by Latency.
+	FaultSlowRead
+	// FaultCorrupt flips the bits of the byte at Offset of response body.
+	FaultCorrupt
+	// FaultHeader replaces the headers of response with Header.
+	FaultHeader
+)
+
+// Fault is a fault injected by FaultTransport.
+type Fault struct {
+	Kind FaultKind
+
+	// Operation is the API name of operations to inject, eg: "GET Object",
+	// all operations are injected if it's empty.
+	Operation string
+	// Key is the pattern of object keys to inject in the syntax of path.Match,
+	// all requests are injected if it's empty.
+	Key string
+	// Probability is the chance to inject in (0, 1], the fault is always
+	// injected if it's 0.
+	Probability float64
+
+	// Latency is used by FaultLatency and FaultSlowRead.
+	Latency time.Duration
+	// StatusCode and ErrorCode are used by FaultStatus, the response has a
+	// QingStor JSON error body of ErrorCode, or no body like the responses of
+	// nginx if ErrorCode is empty.
+	StatusCode int
+	ErrorCode  string
+	// Offset is used by FaultReset, FaultTruncate and FaultCorrupt.
+	Offset int64
+	// Header is used by FaultHeader.
+	Header http.Header
+}
+
+// FaultTransport is a http.RoundTripper injecting faults into the requests
+// sent by Transport.
+type FaultTransport struct {
+	// Transport sends requests, http.DefaultTransport is used if it's nil.
+	Transport http.RoundTripper
+	// ReadTimeout is the read timeout of connections, slow reads exceeding it
+	// fail with timeout errors as utils.Conn does.
+	ReadTimeout time.Duration
+
+	mu     sync.Mutex
+	faults []Fault
+	rand   *rand.Rand
+}
+
+// InjectFaults installs a FaultTransport into the connection of conf, and
+// returns it.
+func InjectFaults(conf *config.Config, faults ...Fault) *FaultTransport {
+	if conf.Connection == nil {
+		conf.InitHTTPClient()
+	}
+	t := &FaultTransport{
+		Transport:   conf.Connection.Transport,
+		ReadTimeout: conf.HTTPSettings.ReadTimeout,
+	}
+	t.SetFaults(faults...)
+
+	client := *conf.Connection
+	client.Transport = t
+	conf.Connection = &client
+	return t
+}
+
+// SetFaults replaces the faults to inject.
+func (t *FaultTransport) SetFaults(faults ...Fault) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.faults = append([]Fault(nil), faults...)
+}
+
+// pick returns the faults to inject into req.
+func (t *FaultTransport) pick(req *http.Request) []Fault {
+	apiName, objectKey := "", ""
+	if o := request.OperationFromRequest(req); o != nil {
+		apiName = o.APIName
+		if p, ok := o.Properties.(*service.Properties); ok {
+			objectKey = service.StringValue(p.ObjectKey)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rand == nil {
+		t.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	var faults []Fault
+	for _, f := range t.faults {
+		if f.Operation != "" && f.Operation != apiName {
+			continue
+		}
+		if f.Key != "" {
+			if ok, _ := path.Match(f.Key, objectKey); !ok || objectKey == "" {
+				continue
+			}
+		}
+		if f.Probability > 0 && t.rand.Float64() >= f.Probability {
+			continue
+		}
+		faults = append(faults, f)
+	}
+	return faults
+}
+
+// RoundTrip sends req with the faults picked for it.
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	faults := t.pick(req)
+	for _, f := range faults {
+		if f.Kind == FaultLatency {
+			if err := sleep(req.Context(), f.Latency); err != nil {
+				closeBody(req)
+				return nil, err
+			}
+		}
+	}
+	for _, f := range faults {
+		if f.Kind == FaultStatus {
+			drainBody(req)
+			return faultResponse(req, f), nil
+		}
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range faults {
+		switch f.Kind {
+		case FaultReset, FaultTruncate, FaultSlowRead, FaultCorrupt:
+			resp.Body = &faultBody{ReadCloser: resp.Body, fault: f, readTimeout: t.ReadTimeout}
+		case FaultHeader:
+			for k, v := range f.Header {
+				resp.Header[http.CanonicalHeaderKey(k)] = v
+			}
+		}
+	}
+	return resp, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+func drainBody(req *http.Request) {
+	if req.Body != nil {
+		io.Copy(ioutil.Discard, req.Body)
+		req.Body.Close()
+	}
+}
+
+func faultResponse(req *http.Request, f Fault) *http.Response {
+	header := http.Header{}
+	header.Set("X-QS-Request-ID", "qingstortest-fault")
+	var body []byte
+	if f.ErrorCode != "" {
+		body, _ = json.Marshal(map[string]string{
+			"code":       f.ErrorCode,
+			"message":    "injected by qingstortest",
+			"request_id": "qingstortest-fault",
+		})
+		header.Set("Content-Type", "application/json")
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        strconv.Itoa(f.StatusCode) + " " + http.StatusText(f.StatusCode),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// faultBody injects a fault into the reads of response body.
+type faultBody struct {
+	io.ReadCloser
+	fault       Fault
+	readTimeout time.Duration
+	read        int64
+}
+
+func (b *faultBody) Read(p []byte) (int, error) {
+	switch b.fault.Kind {
+	case FaultReset, FaultTruncate:
+		if b.read >= b.fault.Offset {
+			if b.fault.Kind == FaultTruncate {
+				return 0, io.EOF
+			}
+			return 0, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+		}
+		if remaining := b.fault.Offset - b.read; int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	case FaultSlowRead:
+		if b.readTimeout > 0 && b.fault.Latency >= b.readTimeout {
+			time.Sleep(b.readTimeout)
+			return 0, &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}
+		}
+		time.Sleep(b.fault.Latency)
+	}
+	n, err := b.ReadCloser.Read(p)
+	if b.fault.Kind == FaultCorrupt && b.fault.Offset >= b.read && b.fault.Offset < b.read+int64(n) {
+		p[b.fault.Offset-b.read] ^= 0xff
+	}
+	b.read += int64(n)
+	return n, err
+}
+
+// timeoutError is the error of reads exceeding the read timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
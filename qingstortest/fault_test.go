@@ -0,0 +1,179 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	qsErrors "github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+	"github.com/qingstor/qingstor-sdk-go/v4/utils"
+)
+
+// faultBucket is the options of the buckets holding "logs/a" and "data/a",
+// whose reads time out in 10ms.
+var faultBucket = []BucketOption{
+	WithConfig(func(conf *config.Config) {
+		conf.HTTPSettings.ReadTimeout = 10 * time.Millisecond
+	}),
+	WithObject("logs/a", []byte("content")),
+	WithObject("data/a", []byte("content")),
+}
+
+func readObject(bucket *service.Bucket, key string) (string, error) {
+	out, err := bucket.GetObject(key, nil)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	content, err := ioutil.ReadAll(out.Body)
+	return string(content), err
+}
+
+func TestFaultStatus(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, transport := NewTestBucket(t, server, faultBucket...)
+
+	transport.SetFaults(Fault{Kind: FaultStatus, Operation: "GET Object", Key: "logs/*", StatusCode: 503, ErrorCode: "service_unavailable"})
+	_, err := readObject(bucket, "logs/a")
+	e := err.(*qsErrors.QingStorError)
+	assert.Equal(t, 503, e.StatusCode)
+	assert.Equal(t, "service_unavailable", e.Code)
+	content, err := readObject(bucket, "data/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "content", content)
+	_, err = bucket.HeadObject("logs/a", nil)
+	assert.Nil(t, err)
+
+	// Empty bodies as nginx.
+	transport.SetFaults(Fault{Kind: FaultStatus, StatusCode: 500})
+	_, err = bucket.HeadObject("logs/a", nil)
+	e = err.(*qsErrors.QingStorError)
+	assert.Equal(t, 500, e.StatusCode)
+	assert.Equal(t, "", e.Code)
+}
+
+func TestFaultBody(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, transport := NewTestBucket(t, server, faultBucket...)
+
+	transport.SetFaults(Fault{Kind: FaultTruncate, Offset: 3})
+	content, err := readObject(bucket, "logs/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "con", content)
+
+	transport.SetFaults(Fault{Kind: FaultReset, Offset: 3})
+	content, err = readObject(bucket, "logs/a")
+	assert.True(t, errors.Is(err, syscall.ECONNRESET))
+	assert.Equal(t, "con", content)
+
+	transport.SetFaults(Fault{Kind: FaultCorrupt, Offset: 1})
+	content, err = readObject(bucket, "logs/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "c\x90ntent", content)
+
+	transport.SetFaults(Fault{Kind: FaultSlowRead, Latency: time.Millisecond})
+	content, err = readObject(bucket, "logs/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "content", content)
+
+	transport.SetFaults(Fault{Kind: FaultSlowRead, Latency: time.Second})
+	_, err = readObject(bucket, "logs/a")
+	assert.True(t, utils.IsTimeoutError(err))
+}
+
+func TestFaultHeader(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, transport := NewTestBucket(t, server, faultBucket...)
+
+	transport.SetFaults(Fault{
+		Kind:      FaultHeader,
+		Operation: "HEAD Object",
+		Header:    http.Header{"etag": {`"changed"`}},
+	})
+	out, err := bucket.HeadObject("logs/a", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, `"changed"`, service.StringValue(out.ETag))
+	assert.Equal(t, int64(7), service.Int64Value(out.ContentLength))
+}
+
+func TestFaultLatency(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, transport := NewTestBucket(t, server, faultBucket...)
+
+	transport.SetFaults(Fault{Kind: FaultLatency, Latency: 20 * time.Millisecond})
+	start := time.Now()
+	_, err := bucket.HeadObject("logs/a", nil)
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+}
+
+func TestFaultProbability(t *testing.T) {
+	transport := &FaultTransport{}
+	transport.SetFaults(Fault{Kind: FaultStatus, Probability: 0.5})
+
+	req, _ := http.NewRequest("GET", "http://qingstor.test/bucket/key", nil)
+	injected := 0
+	for i := 0; i < 1000; i++ {
+		injected += len(transport.pick(req))
+	}
+	assert.InDelta(t, 500, injected, 150)
+
+	transport.SetFaults(Fault{Kind: FaultStatus, Operation: "GET Object"})
+	assert.Empty(t, transport.pick(req))
+}
+
+func TestFaultDetectedByIntegrityCheck(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	conf, err := server.Config()
+	assert.Nil(t, err)
+	conf.EnableIntegrityCheck = true
+	transport := InjectFaults(conf)
+	qs, _ := service.Init(conf)
+	bucket, _ := qs.Bucket("bucket", "pek3b")
+	_, err = bucket.Put()
+	assert.Nil(t, err)
+	_, err = bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader([]byte("content"))})
+	assert.Nil(t, err)
+
+	transport.SetFaults(Fault{Kind: FaultTruncate, Offset: 3})
+	_, err = readObject(bucket, "key")
+	_, ok := err.(qsErrors.IntegrityError)
+	assert.True(t, ok)
+
+	// Ranged reads are not verified.
+	out, err := bucket.GetObject("key", &service.GetObjectInput{Range: service.String("bytes=0-5")})
+	assert.Nil(t, err)
+	content, err := ioutil.ReadAll(out.Body)
+	out.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "con", string(content))
+}
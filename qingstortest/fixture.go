@@ -0,0 +1,96 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// BucketOption customizes the bucket created by NewTestBucket.
+type BucketOption func(*bucketOptions)
+
+type bucketOptions struct {
+	name      string
+	configure []func(*config.Config)
+	keys      []string
+	contents  [][]byte
+}
+
+// WithBucketName sets the name of bucket, it's "bucket" by default.
+func WithBucketName(name string) BucketOption {
+	return func(o *bucketOptions) {
+		o.name = name
+	}
+}
+
+// WithConfig customizes the config returned by Server.Config before the
+// bucket is created.
+func WithConfig(fn func(conf *config.Config)) BucketOption {
+	return func(o *bucketOptions) {
+		o.configure = append(o.configure, fn)
+	}
+}
+
+// WithObject puts content at key after the bucket is created.
+func WithObject(key string, content []byte) BucketOption {
+	return func(o *bucketOptions) {
+		o.keys = append(o.keys, key)
+		o.contents = append(o.contents, content)
+	}
+}
+
+// NewTestBucket creates a bucket in server for tests, faults can be injected
+// into the requests of bucket through the returned transport. It stops the
+// test if the bucket can't be created.
+//
+//	server := qingstortest.NewServer()
+//	defer server.Close()
+//
+//	bucket, transport := qingstortest.NewTestBucket(t, server,
+//		qingstortest.WithObject("key", qingstortest.Content(1024)))
+//	transport.SetFaults(qingstortest.Fault{Kind: qingstortest.FaultReset})
+func NewTestBucket(t testing.TB, server *Server, opts ...BucketOption) (*service.Bucket, *FaultTransport) {
+	t.Helper()
+	o := &bucketOptions{name: "bucket"}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	conf, err := server.Config()
+	if err != nil {
+		t.Fatalf("qingstortest: config: %v", err)
+	}
+	for _, fn := range o.configure {
+		fn(conf)
+	}
+	transport := InjectFaults(conf)
+	bucket, err := server.NewBucket(conf, o.name)
+	if err != nil {
+		t.Fatalf("qingstortest: create bucket %s: %v", o.name, err)
+	}
+	for i, key := range o.keys {
+		_, err = bucket.PutObject(key, &service.PutObjectInput{Body: bytes.NewReader(o.contents[i])})
+		if err != nil {
+			t.Fatalf("qingstortest: put object %s: %v", key, err)
+		}
+	}
+	return bucket, transport
+}
@@ -0,0 +1,202 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// maxPartNumber is the max part number of multipart uploads.
+const maxPartNumber = 10000
+
+type upload struct {
+	id      string
+	key     string
+	created time.Time
+	// object holds the headers of the object to complete.
+	object *object
+	parts  map[int]*part
+}
+
+type part struct {
+	data    []byte
+	etag    string
+	created time.Time
+}
+
+func (s *Server) initiateMultipartUpload(w *responseWriter, r *http.Request, t target) {
+	s.withBucket(w, t, func(b *bucket) {
+		s.uploadID++
+		u := &upload{
+			id:      fmt.Sprintf("%032x", s.uploadID),
+			key:     t.key,
+			created: time.Now().UTC().Truncate(time.Second),
+			object:  newObject(r, nil),
+			parts:   map[int]*part{},
+		}
+		b.uploads[u.id] = u
+		w.json(http.StatusOK, map[string]string{
+			"bucket":    b.name,
+			"key":       t.key,
+			"upload_id": u.id,
+		})
+	})
+}
+
+// serveUpload serves the APIs of a multipart upload.
+func (s *Server) serveUpload(w *responseWriter, r *http.Request, t target, uploadID string, body []byte) {
+	s.withBucket(w, t, func(b *bucket) {
+		u := b.uploads[uploadID]
+		if u == nil || u.key != t.key {
+			w.error(errUploadNotExists)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			s.uploadMultipart(w, r, u, body)
+		case http.MethodGet:
+			listMultipart(w, r, u)
+		case http.MethodPost:
+			completeMultipartUpload(w, b, u, body)
+		case http.MethodDelete:
+			delete(b.uploads, uploadID)
+			w.status(http.StatusNoContent)
+		default:
+			w.error(errNotImplemented)
+		}
+	})
+}
+
+func (s *Server) uploadMultipart(w *responseWriter, r *http.Request, u *upload, body []byte) {
+	partNumber, err := strconv.Atoi(r.URL.Query().Get("part_number"))
+	if err != nil || partNumber < 0 || partNumber >= maxPartNumber {
+		w.error(errInvalidRequest("invalid part number"))
+		return
+	}
+
+	data := body
+	if source := r.Header.Get("X-QS-Copy-Source"); source != "" {
+		_, _, src, err := s.sourceObject(r, source)
+		if err != nil {
+			w.error(err)
+			return
+		}
+		data = src.data
+		if copyRange := r.Header.Get("X-QS-Copy-Range"); copyRange != "" {
+			start, end, ok := parseRange(copyRange, int64(len(src.data)))
+			if !ok {
+				w.error(newError(http.StatusRequestedRangeNotSatisfiable, "invalid_range", "the copy range is not satisfiable"))
+				return
+			}
+			data = src.data[start : end+1]
+		}
+		data = append([]byte(nil), data...)
+	} else if err := checkContentMD5(r, body); err != nil {
+		w.error(err)
+		return
+	}
+
+	p := &part{data: data, etag: md5ETag(data), created: time.Now().UTC().Truncate(time.Second)}
+	u.parts[partNumber] = p
+	w.Header().Set("ETag", p.etag)
+	w.status(http.StatusCreated)
+}
+
+func listMultipart(w *responseWriter, r *http.Request, u *upload) {
+	query := r.URL.Query()
+	marker := -1
+	if v := query.Get("part_number_marker"); v != "" {
+		marker, _ = strconv.Atoi(v)
+	}
+	limit := listLimit(r)
+
+	numbers := make([]int, 0, len(u.parts))
+	for n := range u.parts {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	parts := []*service.ObjectPartType{}
+	for _, n := range numbers {
+		if n <= marker || len(parts) == limit {
+			continue
+		}
+		p := u.parts[n]
+		parts = append(parts, &service.ObjectPartType{
+			PartNumber: service.Int(n),
+			Size:       service.Int64(int64(len(p.data))),
+			Etag:       service.String(p.etag),
+			Created:    service.Time(p.created),
+		})
+	}
+	w.json(http.StatusOK, map[string]interface{}{"count": len(numbers), "object_parts": parts})
+}
+
+func completeMultipartUpload(w *responseWriter, b *bucket, u *upload, body []byte) {
+	input := &service.CompleteMultipartUploadInput{}
+	if err := json.Unmarshal(body, input); err != nil {
+		w.error(errInvalidRequest("invalid body: %s", err))
+		return
+	}
+	if len(input.ObjectParts) == 0 {
+		w.error(errInvalidRequest("no parts to complete"))
+		return
+	}
+
+	var data []byte
+	sums := md5.New()
+	last := -1
+	for _, op := range input.ObjectParts {
+		n := service.IntValue(op.PartNumber)
+		p := u.parts[n]
+		if p == nil {
+			w.error(errInvalidRequest("part %d doesn't exist", n))
+			return
+		}
+		if op.Etag != nil && !sameETag(*op.Etag, p.etag) {
+			w.error(errInvalidRequest("the etag of part %d mismatches", n))
+			return
+		}
+		if n <= last {
+			w.error(errInvalidRequest("the parts are not in ascending order"))
+			return
+		}
+		last = n
+		data = append(data, p.data...)
+		sum, _ := hex.DecodeString(strings.Trim(p.etag, `"`))
+		sums.Write(sum)
+	}
+
+	o := *u.object
+	o.data = data
+	o.etag = fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sums.Sum(nil)), len(input.ObjectParts))
+	o.modified = time.Now().UTC().Truncate(time.Second)
+	b.objects[u.key] = &o
+	delete(b.uploads, u.id)
+	w.Header().Set("ETag", o.etag)
+	w.status(http.StatusCreated)
+}
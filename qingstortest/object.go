@@ -0,0 +1,396 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// storedHeaders are the request headers kept with objects and returned with them.
+var storedHeaders = []string{"Cache-Control", "Content-Disposition", "Content-Encoding", "Content-Language", "Expires"}
+
+type object struct {
+	data         []byte
+	etag         string
+	contentType  string
+	storageClass string
+	modified     time.Time
+	appendable   bool
+	// headers are the storedHeaders and X-QS-Meta-* headers.
+	headers http.Header
+
+	encryptionAlgorithm string
+	encryptionKeyMD5    string
+}
+
+// newObject creates the object with the headers of request.
+func newObject(r *http.Request, data []byte) *object {
+	o := &object{
+		data:                data,
+		etag:                md5ETag(data),
+		contentType:         r.Header.Get("Content-Type"),
+		storageClass:        r.Header.Get("X-QS-Storage-Class"),
+		modified:            time.Now().UTC().Truncate(time.Second),
+		headers:             http.Header{},
+		encryptionAlgorithm: r.Header.Get("X-QS-Encryption-Customer-Algorithm"),
+		encryptionKeyMD5:    r.Header.Get("X-QS-Encryption-Customer-Key-MD5"),
+	}
+	if o.contentType == "" {
+		o.contentType = "application/octet-stream"
+	}
+	if o.storageClass == "" {
+		o.storageClass = "STANDARD"
+	}
+	for _, name := range storedHeaders {
+		if v := r.Header.Get(name); v != "" {
+			o.headers.Set(name, v)
+		}
+	}
+	copyMetadata(o.headers, r.Header)
+	return o
+}
+
+// clone copies the object with data, the metadata is copied unless the
+// request replaces it.
+func (o *object) clone(r *http.Request, data []byte) *object {
+	if strings.EqualFold(r.Header.Get("X-QS-Metadata-Directive"), "REPLACE") {
+		return newObject(r, data)
+	}
+	c := *o
+	c.data = data
+	c.etag = md5ETag(data)
+	c.modified = time.Now().UTC().Truncate(time.Second)
+	c.appendable = false
+	c.headers = http.Header{}
+	for k, v := range o.headers {
+		c.headers[k] = v
+	}
+	if v := r.Header.Get("X-QS-Storage-Class"); v != "" {
+		c.storageClass = v
+	}
+	c.encryptionAlgorithm = r.Header.Get("X-QS-Encryption-Customer-Algorithm")
+	c.encryptionKeyMD5 = r.Header.Get("X-QS-Encryption-Customer-Key-MD5")
+	return &c
+}
+
+func copyMetadata(dst, src http.Header) {
+	for k, v := range src {
+		if strings.HasPrefix(strings.ToLower(k), "x-qs-meta-") {
+			dst[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+}
+
+func md5ETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// sameETag compares ETags without quotes.
+func sameETag(a, b string) bool {
+	return strings.Trim(a, `"`) == strings.Trim(b, `"`)
+}
+
+// checkEncryptionKey checks whether the key of request unlocks the object.
+func (o *object) checkEncryptionKey(keyMD5 string) error {
+	if o.encryptionKeyMD5 != "" && o.encryptionKeyMD5 != keyMD5 {
+		return newError(http.StatusBadRequest, "invalid_encryption_key", "the encryption key doesn't match the object")
+	}
+	return nil
+}
+
+// checkContentMD5 verifies the Content-MD5 of request.
+func checkContentMD5(r *http.Request, data []byte) error {
+	expected := r.Header.Get("Content-MD5")
+	if expected == "" {
+		return nil
+	}
+	sum := md5.Sum(data)
+	if expected != base64.StdEncoding.EncodeToString(sum[:]) && !strings.EqualFold(expected, hex.EncodeToString(sum[:])) {
+		return newError(http.StatusBadRequest, "bad_digest", "the content md5 mismatches the body")
+	}
+	return nil
+}
+
+// serveObject serves the APIs of object.
+func (s *Server) serveObject(w *responseWriter, r *http.Request, t target) {
+	query := r.URL.Query()
+	_, isUploads := query["uploads"]
+	_, isAppend := query["append"]
+	_, isImage := query["image"]
+	uploadID := query.Get("upload_id")
+
+	var body []byte
+	if r.Method == http.MethodPut || r.Method == http.MethodPost {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			w.error(err)
+			return
+		}
+	}
+
+	switch {
+	case isImage || r.Method == http.MethodOptions:
+		w.error(errNotImplemented)
+	case r.Method == http.MethodPost && isUploads:
+		s.initiateMultipartUpload(w, r, t)
+	case uploadID != "":
+		s.serveUpload(w, r, t, uploadID, body)
+	case r.Method == http.MethodPost && isAppend:
+		s.appendObject(w, r, t, body)
+	case r.Method == http.MethodPut:
+		s.putObject(w, r, t, body)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.getObject(w, r, t)
+	case r.Method == http.MethodDelete:
+		s.withBucket(w, t, func(b *bucket) {
+			delete(b.objects, t.key)
+			w.status(http.StatusNoContent)
+		})
+	default:
+		w.error(errNotImplemented)
+	}
+}
+
+// sourceObject returns the source object of copy or move, the server must be locked.
+func (s *Server) sourceObject(r *http.Request, source string) (*bucket, string, *object, error) {
+	if unescaped, err := url.PathUnescape(source); err == nil {
+		source = unescaped
+	}
+	parts := strings.SplitN(strings.TrimPrefix(source, "/"), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, "", nil, errInvalidRequest("invalid source %q", source)
+	}
+	b := s.buckets[parts[0]]
+	if b == nil {
+		return nil, "", nil, errBucketNotExists
+	}
+	o := b.objects[parts[1]]
+	if o == nil {
+		return nil, "", nil, errObjectNotExists
+	}
+	if err := o.checkEncryptionKey(r.Header.Get("X-QS-Copy-Source-Encryption-Customer-Key-MD5")); err != nil {
+		return nil, "", nil, err
+	}
+	if m := r.Header.Get("X-QS-Copy-Source-If-Match"); m != "" && !sameETag(m, o.etag) {
+		return nil, "", nil, errPreconditionFailed
+	}
+	if m := r.Header.Get("X-QS-Copy-Source-If-None-Match"); m != "" && sameETag(m, o.etag) {
+		return nil, "", nil, errPreconditionFailed
+	}
+	return b, parts[1], o, nil
+}
+
+var errPreconditionFailed = newError(http.StatusPreconditionFailed, "precondition_failed", "the precondition of request failed")
+
+func (s *Server) putObject(w *responseWriter, r *http.Request, t target, body []byte) {
+	if r.Header.Get("X-QS-Fetch-Source") != "" {
+		w.error(errNotImplemented)
+		return
+	}
+	copySource := r.Header.Get("X-QS-Copy-Source")
+	moveSource := r.Header.Get("X-QS-Move-Source")
+	if copySource == "" && moveSource == "" {
+		if err := checkContentMD5(r, body); err != nil {
+			w.error(err)
+			return
+		}
+	}
+
+	s.withBucket(w, t, func(b *bucket) {
+		var o *object
+		switch {
+		case copySource != "":
+			_, _, src, err := s.sourceObject(r, copySource)
+			if err != nil {
+				w.error(err)
+				return
+			}
+			o = src.clone(r, src.data)
+		case moveSource != "":
+			srcBucket, srcKey, src, err := s.sourceObject(r, moveSource)
+			if err != nil {
+				w.error(err)
+				return
+			}
+			delete(srcBucket.objects, srcKey)
+			o = src
+		default:
+			o = newObject(r, body)
+		}
+		b.objects[t.key] = o
+		w.Header().Set("ETag", o.etag)
+		w.status(http.StatusCreated)
+	})
+}
+
+func (s *Server) appendObject(w *responseWriter, r *http.Request, t target, body []byte) {
+	position, err := strconv.ParseInt(r.URL.Query().Get("position"), 10, 64)
+	if err != nil {
+		w.error(errInvalidRequest("invalid position"))
+		return
+	}
+	if err := checkContentMD5(r, body); err != nil {
+		w.error(err)
+		return
+	}
+
+	s.withBucket(w, t, func(b *bucket) {
+		o := b.objects[t.key]
+		if o == nil {
+			o = newObject(r, nil)
+			o.appendable = true
+		}
+		if !o.appendable {
+			w.error(newError(http.StatusConflict, "object_not_appendable", "the object is not appendable"))
+			return
+		}
+		if position != int64(len(o.data)) {
+			w.error(newError(http.StatusConflict, "invalid_append_position", "the position doesn't match the object length"))
+			return
+		}
+		o.data = append(o.data, body...)
+		o.etag = md5ETag(o.data)
+		o.modified = time.Now().UTC().Truncate(time.Second)
+		b.objects[t.key] = o
+		w.Header().Set("X-QS-Next-Append-Position", strconv.Itoa(len(o.data)))
+		w.status(http.StatusOK)
+	})
+}
+
+func (s *Server) getObject(w *responseWriter, r *http.Request, t target) {
+	var o object
+	found := false
+	s.withBucket(w, t, func(b *bucket) {
+		if p := b.objects[t.key]; p != nil {
+			o = *p
+			found = true
+		} else {
+			w.error(errObjectNotExists)
+		}
+	})
+	if !found {
+		return
+	}
+	if err := o.checkEncryptionKey(r.Header.Get("X-QS-Encryption-Customer-Key-MD5")); err != nil {
+		w.error(err)
+		return
+	}
+
+	h := w.Header()
+	h.Set("ETag", o.etag)
+	h.Set("Last-Modified", o.modified.Format(http.TimeFormat))
+	if m := r.Header.Get("If-Match"); m != "" && !sameETag(m, o.etag) {
+		w.error(errPreconditionFailed)
+		return
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil && o.modified.After(since) {
+		w.error(errPreconditionFailed)
+		return
+	}
+	if m := r.Header.Get("If-None-Match"); m != "" && sameETag(m, o.etag) {
+		w.status(http.StatusNotModified)
+		return
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !o.modified.After(since) {
+		w.status(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", o.contentType)
+	h.Set("X-QS-Storage-Class", o.storageClass)
+	if o.encryptionAlgorithm != "" {
+		h.Set("X-QS-Encryption-Customer-Algorithm", o.encryptionAlgorithm)
+	}
+	for k, v := range o.headers {
+		h[k] = v
+	}
+	if r.Method == http.MethodGet {
+		for key, values := range r.URL.Query() {
+			if strings.HasPrefix(key, "response-") && len(values) > 0 {
+				h.Set(strings.TrimPrefix(key, "response-"), values[0])
+			}
+		}
+	}
+
+	size := int64(len(o.data))
+	start, end := int64(0), size-1
+	status := http.StatusOK
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && r.Method == http.MethodGet {
+		var ok bool
+		start, end, ok = parseRange(rangeHeader, size)
+		if !ok {
+			h.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			w.error(newError(http.StatusRequestedRangeNotSatisfiable, "invalid_range", "the range is not satisfiable"))
+			return
+		}
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		status = http.StatusPartialContent
+	}
+	h.Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		w.Write(o.data[start : end+1])
+	}
+}
+
+// parseRange parses a single range of "bytes=start-end", "bytes=start-" or
+// "bytes=-suffix".
+func parseRange(header string, size int64) (start, end int64, ok bool) {
+	spec := strings.TrimPrefix(header, "bytes=")
+	if spec == header || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	i := strings.Index(spec, "-")
+	if i < 0 {
+		return 0, 0, false
+	}
+	first, last := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+	var err error
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, size > 0
+	}
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil || start >= size {
+		return 0, 0, false
+	}
+	end = size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true
+}
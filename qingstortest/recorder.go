@@ -0,0 +1,234 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/utils"
+)
+
+// RecorderMode is the mode of Recorder.
+type RecorderMode int
+
+// Modes of Recorder.
+const (
+	// ModeRecord sends requests and saves the responses to the cassette.
+	ModeRecord RecorderMode = iota
+	// ModeReplay serves the responses in the cassette without sending requests.
+	ModeReplay
+)
+
+// Query parameters which vary between signed requests, they are ignored when
+// matching requests.
+var volatileQuery = []string{"access_key_id", "expires", "signature"}
+
+// Recorder is a http.RoundTripper recording requests and responses into a
+// cassette file, and replaying them later, install it as the transport of
+// config.Config.Connection:
+//
+//	recorder, _ := qingstortest.NewRecorder("testdata/cassette.yaml", qingstortest.ModeReplay)
+//	recorder.Transport = conf.Connection.Transport
+//	conf.Connection.Transport = recorder
+//	defer recorder.Close()
+//
+// The Authorization header, customer keys and query signatures are scrubbed
+// before saving. Requests are matched by method, path, query and MatchHeaders,
+// each recorded response is served once in order.
+type Recorder struct {
+	// Transport sends requests in record mode, http.DefaultTransport is used if it's nil.
+	Transport http.RoundTripper
+	// MatchHeaders are the request headers must be equal besides method,
+	// path and query in replay mode.
+	MatchHeaders []string
+
+	mode RecorderMode
+	path string
+
+	mu           sync.Mutex
+	interactions []*interaction
+	replayed     []bool
+}
+
+type cassette struct {
+	Interactions []*interaction `yaml:"interactions"`
+}
+
+type interaction struct {
+	Request  recordedRequest  `yaml:"request"`
+	Response recordedResponse `yaml:"response"`
+}
+
+type recordedRequest struct {
+	Method string      `yaml:"method"`
+	URL    string      `yaml:"url"`
+	Header http.Header `yaml:"header"`
+}
+
+type recordedResponse struct {
+	StatusCode int         `yaml:"status_code"`
+	Header     http.Header `yaml:"header"`
+	Body       string      `yaml:"body"`
+}
+
+// NewRecorder creates a recorder of the cassette at path, which must exist in
+// replay mode.
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path}
+	if mode != ModeReplay {
+		return r, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &cassette{}
+	if err = yaml.Unmarshal(content, c); err != nil {
+		return nil, fmt.Errorf("qingstortest: invalid cassette %s: %s", path, err)
+	}
+	r.interactions = c.Interactions
+	r.replayed = make([]bool, len(c.Interactions))
+	return r, nil
+}
+
+// RoundTrip records or replays the request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, &interaction{
+		Request: recordedRequest{
+			Method: req.Method,
+			URL:    utils.RedactURL(req.URL),
+			Header: utils.RedactHeader(req.Header),
+		},
+		Response: recordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       string(body),
+		},
+	})
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(ioutil.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.replayed[i] || !r.match(req, &in.Request) {
+			continue
+		}
+		r.replayed[i] = true
+		header := http.Header{}
+		for k, v := range in.Response.Header {
+			header[k] = append([]string(nil), v...)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(in.Response.Body))),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("qingstortest: no recorded response for %s %s", req.Method, utils.RedactURL(req.URL))
+}
+
+// match checks whether req matches the recorded request.
+func (r *Recorder) match(req *http.Request, recorded *recordedRequest) bool {
+	if req.Method != recorded.Method {
+		return false
+	}
+	u, err := url.Parse(recorded.URL)
+	if err != nil || u.Path != req.URL.Path {
+		return false
+	}
+	query, recordedQuery := req.URL.Query(), u.Query()
+	for _, key := range volatileQuery {
+		query.Del(key)
+		recordedQuery.Del(key)
+	}
+	if query.Encode() != recordedQuery.Encode() {
+		return false
+	}
+	for _, key := range r.MatchHeaders {
+		if req.Header.Get(key) != recorded.Header.Get(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// Close saves the cassette in record mode.
+func (r *Recorder) Close() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	content, err := yaml.Marshal(&cassette{Interactions: r.interactions})
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, content, 0644)
+}
@@ -0,0 +1,130 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/credentials"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qingstortest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "testdata", "cassette.yaml")
+
+	server := NewServer()
+	conf, err := server.Config()
+	assert.Nil(t, err)
+	recorder, err := NewRecorder(path, ModeRecord)
+	assert.Nil(t, err)
+	recorder.Transport = conf.Connection.Transport
+	conf.Connection.Transport = recorder
+
+	qs, _ := service.Init(conf)
+	bucket, _ := qs.Bucket("bucket", "pek3b")
+	_, err = bucket.Put()
+	assert.Nil(t, err)
+	_, err = bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader([]byte("content"))})
+	assert.Nil(t, err)
+	_, err = bucket.GetObject("missing", nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, recorder.Close())
+	server.Close()
+
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(content), "QS "+DefaultAccessKeyID))
+	assert.True(t, strings.Contains(string(content), "REDACTED"))
+
+	// Replay without the server.
+	recorder, err = NewRecorder(path, ModeReplay)
+	assert.Nil(t, err)
+	conf.Connection.Transport = recorder
+	qs, _ = service.Init(conf)
+	bucket, _ = qs.Bucket("bucket", "pek3b")
+	_, err = bucket.Put()
+	assert.Nil(t, err)
+	_, err = bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader([]byte("content"))})
+	assert.Nil(t, err)
+	_, err = bucket.GetObject("missing", nil)
+	assert.Equal(t, "object_not_exists", err.(*errors.QingStorError).Code)
+
+	// Every response is replayed once.
+	_, err = bucket.Put()
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "no recorded response"))
+}
+
+func TestRecorderSessionToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qingstortest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.yaml")
+
+	server := NewServer()
+	defer server.Close()
+	conf, err := server.Config()
+	assert.Nil(t, err)
+	conf.CredentialsProvider = credentials.NewStaticProvider(
+		DefaultAccessKeyID, DefaultSecretAccessKey, "SESSION_TOKEN")
+	recorder, err := NewRecorder(path, ModeRecord)
+	assert.Nil(t, err)
+	recorder.Transport = conf.Connection.Transport
+	conf.Connection.Transport = recorder
+
+	qs, _ := service.Init(conf)
+	_, err = qs.ListBuckets(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, recorder.Close())
+
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(content), "X-Qs-Security-Token"))
+	assert.False(t, strings.Contains(string(content), "SESSION_TOKEN"))
+}
+
+func TestRecorderMatchHeaders(t *testing.T) {
+	recorder := &Recorder{MatchHeaders: []string{"Range"}, mode: ModeReplay}
+	recorder.interactions = []*interaction{{
+		Request:  recordedRequest{Method: "GET", URL: "http://qingstor.test/bucket/key?signature=REDACTED", Header: map[string][]string{"Range": {"bytes=0-1"}}},
+		Response: recordedResponse{StatusCode: 206, Body: "co"},
+	}}
+	recorder.replayed = make([]bool, 1)
+
+	req, _ := http.NewRequest("GET", "http://qingstor.test/bucket/key?signature=abc&expires=1", nil)
+	_, err := recorder.RoundTrip(req)
+	assert.NotNil(t, err)
+
+	req.Header.Set("Range", "bytes=0-1")
+	resp, err := recorder.RoundTrip(req)
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, 206, resp.StatusCode)
+	assert.Equal(t, "co", string(body))
+}
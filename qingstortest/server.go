@@ -0,0 +1,408 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+// Package qingstortest provides an in-process QingStor server for tests.
+//
+// The server keeps buckets and objects in memory, and implements the bucket
+// and object APIs used by this SDK, so tests of code using the SDK don't need
+// a QingStor account:
+//
+//	server := qingstortest.NewServer()
+//	defer server.Close()
+//
+//	conf, _ := server.Config()
+//	qs, _ := service.Init(conf)
+//	bucket, _ := qs.Bucket("bucket", "pek3b")
+//	bucket.Put()
+//
+// Tests usually create their buckets with NewTestBucket instead.
+package qingstortest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/signer/v2"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// Host is the host of the configs returned by Server.Config, connections to
+// it and all its sub domains are routed to the server.
+const Host = "qingstor.test"
+
+// Credentials accepted by the server if no credentials were given.
+const (
+	DefaultAccessKeyID     = "ACCESS_KEY_ID"
+	DefaultSecretAccessKey = "SECRET_ACCESS_KEY"
+)
+
+// Option configures the server.
+type Option func(*Server)
+
+// WithCredentials sets the credentials of the server, they are returned by
+// Server.Config, and used to verify signatures.
+func WithCredentials(accessKeyID, secretAccessKey string) Option {
+	return func(s *Server) {
+		s.accessKeyID = accessKeyID
+		s.secretAccessKey = secretAccessKey
+	}
+}
+
+// WithSignatureVerification makes the server reject requests which are not
+// signed, or signed by other credentials. Both header and query signatures
+// are accepted.
+func WithSignatureVerification() Option {
+	return func(s *Server) {
+		s.verifySignature = true
+	}
+}
+
+// WithVirtualHostStyle makes the server take the first label of host as the
+// bucket name, the config returned by Server.Config enables virtual host
+// style too. Requests to IP addresses are always in path style.
+func WithVirtualHostStyle() Option {
+	return func(s *Server) {
+		s.virtualHostStyle = true
+	}
+}
+
+// Server is a QingStor server keeping the data in memory.
+type Server struct {
+	*httptest.Server
+
+	accessKeyID      string
+	secretAccessKey  string
+	verifySignature  bool
+	virtualHostStyle bool
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	requests  []*http.Request
+	requestID int64
+	uploadID  int64
+}
+
+// NewServer starts a server, it should be closed after use.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		accessKeyID:     DefaultAccessKeyID,
+		secretAccessKey: DefaultSecretAccessKey,
+		buckets:         map[string]*bucket{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Config returns a config which sends requests to the server through Host,
+// so buckets in any zone and virtual host style work as with QingStor.
+func (s *Server) Config() (*config.Config, error) {
+	conf, err := config.New(s.accessKeyID, s.secretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	port := s.Listener.Addr().(*net.TCPAddr).Port
+	if err = conf.SetEndpoint(fmt.Sprintf("http://%s:%d", Host, port)); err != nil {
+		return nil, err
+	}
+	conf.EnableVirtualHostStyle = s.virtualHostStyle
+	conf.RetrySettings.MinBackoff = time.Millisecond
+	conf.RetrySettings.MaxBackoff = 10 * time.Millisecond
+
+	addr := s.Listener.Addr().String()
+	dialer := &net.Dialer{}
+	conf.Connection = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+	return conf, nil
+}
+
+// Object returns the content of object, ok is false if it doesn't exist.
+func (s *Server) Object(bucketName, objectKey string) (content []byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buckets[bucketName]
+	if b == nil {
+		return nil, false
+	}
+	o := b.objects[objectKey]
+	if o == nil {
+		return nil, false
+	}
+	return o.data, true
+}
+
+// Zone is the zone of buckets created by Server.NewBucket.
+const Zone = "pek3b"
+
+// NewBucket creates a bucket named bucketName in Zone with conf, which is
+// usually returned by Server.Config and then customized by the test.
+func (s *Server) NewBucket(conf *config.Config, bucketName string) (*service.Bucket, error) {
+	qs, err := service.Init(conf)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := qs.Bucket(bucketName, Zone)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = bucket.Put(); err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
+
+// Requests returns the method, URL and header of the requests served in the
+// order of arrival, their bodies are not kept.
+func (s *Server) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.requests...)
+}
+
+// Content returns size bytes of content for tests, the bytes repeat every 251
+// bytes, so misplaced parts of content are detected.
+func Content(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+// target is the resource a request operates on.
+type target struct {
+	bucket string
+	zone   string
+	key    string
+	vhost  bool
+}
+
+// ServeHTTP dispatches the request to the handler of its resource.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.record(r)
+	requestID := s.newRequestID()
+	w.Header().Set("X-QS-Request-ID", requestID)
+	rw := &responseWriter{ResponseWriter: w, r: r, requestID: requestID}
+
+	t := s.parseTarget(r)
+	if s.verifySignature {
+		if err := s.verify(r, t); err != nil {
+			rw.error(err)
+			return
+		}
+	}
+
+	switch {
+	case t.bucket == "":
+		s.serveService(rw, r)
+	case t.key == "":
+		s.serveBucket(rw, r, t)
+	default:
+		s.serveObject(rw, r, t)
+	}
+}
+
+// record keeps the request for Server.Requests.
+func (s *Server) record(r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, &http.Request{
+		Method: r.Method,
+		URL:    r.URL,
+		Host:   r.Host,
+		Header: r.Header,
+	})
+}
+
+func (s *Server) newRequestID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requestID++
+	return fmt.Sprintf("%016x", s.requestID)
+}
+
+// parseTarget parses the bucket, zone and key of request from its host and path.
+func (s *Server) parseTarget(r *http.Request) target {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	var labels []string
+	if net.ParseIP(host) == nil && host != "localhost" {
+		labels = strings.Split(host, ".")
+		// Drop the labels of base host.
+		if i := strings.Index(host, "."+Host); i >= 0 {
+			labels = strings.Split(host[:i], ".")
+		} else if host == Host {
+			labels = nil
+		} else if len(labels) > 2 {
+			labels = labels[:len(labels)-2]
+		} else {
+			labels = nil
+		}
+	}
+
+	var t target
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if s.virtualHostStyle && len(labels) > 0 {
+		t.vhost = true
+		t.bucket = labels[0]
+		if len(labels) > 1 {
+			t.zone = labels[1]
+		}
+		t.key = path
+		return t
+	}
+	if len(labels) > 0 {
+		t.zone = labels[0]
+	}
+	if i := strings.Index(path, "/"); i >= 0 {
+		t.bucket, t.key = path[:i], path[i+1:]
+	} else {
+		t.bucket = path
+	}
+	return t
+}
+
+// verify checks the header or query signature of request.
+func (s *Server) verify(r *http.Request, t target) error {
+	req := signer.CanonicalReqByPath(r)
+	if t.vhost {
+		req = signer.CanonicalReqByVhost(r, t.bucket)
+	}
+	qss := &signer.QingStorSigner{AccessKeyID: s.accessKeyID, SecretAccessKey: s.secretAccessKey}
+
+	query := r.URL.Query()
+	if signature := query.Get("signature"); signature != "" {
+		if query.Get("access_key_id") != s.accessKeyID {
+			return newError(http.StatusUnauthorized, "invalid_access_key_id", "the access key id doesn't exist")
+		}
+		expires, err := strconv.Atoi(query.Get("expires"))
+		if err != nil || int64(expires) < time.Now().Unix() {
+			return newError(http.StatusUnauthorized, "request_expired", "the request has expired")
+		}
+		expected, err := qss.BuildQuerySignature(req, expires)
+		if err != nil {
+			return err
+		}
+		values, err := url.ParseQuery(expected)
+		if err != nil || values.Get("signature") != signature {
+			return newError(http.StatusUnauthorized, "signature_not_match", "the signature doesn't match")
+		}
+		return nil
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return newError(http.StatusUnauthorized, "permission_denied", "the request is not signed")
+	}
+	if !strings.HasPrefix(authorization, "QS "+s.accessKeyID+":") {
+		return newError(http.StatusUnauthorized, "invalid_access_key_id", "the access key id doesn't exist")
+	}
+	expected, err := qss.BuildSignature(req)
+	if err != nil {
+		return err
+	}
+	if expected != authorization {
+		return newError(http.StatusUnauthorized, "signature_not_match", "the signature doesn't match")
+	}
+	return nil
+}
+
+// Error is an error response of server, whose body can be unpacked into
+// errors.QingStorError.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func newError(statusCode int, code, message string) *Error {
+	return &Error{StatusCode: statusCode, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+var (
+	errBucketNotExists = newError(http.StatusNotFound, "bucket_not_exists", "the bucket doesn't exist")
+	errObjectNotExists = newError(http.StatusNotFound, "object_not_exists", "the object doesn't exist")
+	errUploadNotExists = newError(http.StatusNotFound, "upload_not_exists", "the multipart upload doesn't exist")
+	errNotImplemented  = newError(http.StatusNotImplemented, "not_implemented", "the operation is not implemented by qingstortest")
+)
+
+func errInvalidRequest(format string, args ...interface{}) *Error {
+	return newError(http.StatusBadRequest, "invalid_request", fmt.Sprintf(format, args...))
+}
+
+// responseWriter writes responses in the format of QingStor.
+type responseWriter struct {
+	http.ResponseWriter
+	r         *http.Request
+	requestID string
+}
+
+func (w *responseWriter) json(statusCode int, v interface{}) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		w.error(err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(statusCode)
+	w.Write(content)
+}
+
+func (w *responseWriter) status(statusCode int) {
+	w.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) error(err error) {
+	e, ok := err.(*Error)
+	if !ok {
+		e = newError(http.StatusInternalServerError, "internal_error", err.Error())
+	}
+	// The body of HEAD responses is dropped.
+	if w.r.Method == http.MethodHead {
+		w.WriteHeader(e.StatusCode)
+		return
+	}
+	w.json(e.StatusCode, map[string]string{
+		"code":       e.Code,
+		"message":    e.Message,
+		"request_id": w.requestID,
+		"url":        "https://docsv4.qingcloud.com/user_guide/storage/object_storage/api/error_code/",
+	})
+}
@@ -0,0 +1,273 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package qingstortest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+func statusCode(err error) int {
+	if e, ok := err.(*errors.QingStorError); ok {
+		return e.StatusCode
+	}
+	return 0
+}
+
+func TestObject(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, _ := NewTestBucket(t, server)
+
+	_, err := bucket.PutObject("dir/key", &service.PutObjectInput{
+		ContentType: service.String("text/plain"),
+		XQSMetaData: &map[string]string{"x-qs-meta-name": "value"},
+		Body:        bytes.NewReader([]byte("0123456789")),
+	})
+	assert.Nil(t, err)
+	content, ok := server.Object("bucket", "dir/key")
+	assert.True(t, ok)
+	assert.Equal(t, "0123456789", string(content))
+
+	out, err := bucket.GetObject("dir/key", &service.GetObjectInput{Range: service.String("bytes=2-4")})
+	assert.Nil(t, err)
+	defer out.Close()
+	body, _ := ioutil.ReadAll(out.Body)
+	assert.Equal(t, "234", string(body))
+	assert.Equal(t, 206, service.IntValue(out.StatusCode))
+	assert.Equal(t, "bytes 2-4/10", service.StringValue(out.ContentRange))
+	assert.Equal(t, "text/plain", service.StringValue(out.ContentType))
+
+	head, err := bucket.HeadObject("dir/key", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), service.Int64Value(head.ContentLength))
+	assert.Equal(t, "value", (*head.XQSMetaData)["x-qs-meta-name"])
+
+	// GET Object takes 412 as a valid status.
+	failed, err := bucket.GetObject("dir/key", &service.GetObjectInput{IfMatch: service.String(`"other"`)})
+	assert.Nil(t, err)
+	failed.Close()
+	assert.Equal(t, http.StatusPreconditionFailed, service.IntValue(failed.StatusCode))
+
+	_, err = bucket.DeleteObject("dir/key")
+	assert.Nil(t, err)
+	_, err = bucket.HeadObject("dir/key", nil)
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+	_, err = bucket.GetObject("dir/key", nil)
+	e := err.(*errors.QingStorError)
+	assert.Equal(t, "object_not_exists", e.Code)
+	assert.NotEmpty(t, e.RequestID)
+}
+
+func TestNewBucketAndRequests(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	conf, err := server.Config()
+	assert.Nil(t, err)
+	bucket, err := server.NewBucket(conf, "bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, Zone, service.StringValue(bucket.Properties.Zone))
+
+	content := Content(300)
+	assert.Equal(t, content[:49], content[251:])
+	_, err = bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader(content)})
+	assert.Nil(t, err)
+	_, err = bucket.GetObject("key", &service.GetObjectInput{Range: service.String("bytes=0-9")})
+	assert.Nil(t, err)
+
+	requests := server.Requests()
+	assert.Equal(t, 3, len(requests))
+	assert.Equal(t, http.MethodPut, requests[0].Method)
+	assert.Equal(t, "/bucket/key", requests[1].URL.Path)
+	assert.Equal(t, "bytes=0-9", requests[2].Header.Get("Range"))
+}
+
+func TestCopyMoveAndAppend(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, _ := NewTestBucket(t, server)
+
+	_, err := bucket.PutObject("source", &service.PutObjectInput{Body: bytes.NewReader([]byte("content"))})
+	assert.Nil(t, err)
+	_, err = bucket.PutObject("copy", &service.PutObjectInput{XQSCopySource: service.String("/bucket/source")})
+	assert.Nil(t, err)
+	_, err = bucket.PutObject("moved", &service.PutObjectInput{XQSMoveSource: service.String("/bucket/source")})
+	assert.Nil(t, err)
+
+	content, _ := server.Object("bucket", "copy")
+	assert.Equal(t, "content", string(content))
+	content, _ = server.Object("bucket", "moved")
+	assert.Equal(t, "content", string(content))
+	_, ok := server.Object("bucket", "source")
+	assert.False(t, ok)
+
+	out, err := bucket.AppendObject("log", &service.AppendObjectInput{
+		Position: service.Int64(0),
+		Body:     bytes.NewReader([]byte("abc")),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), service.Int64Value(out.XQSNextAppendPosition))
+	_, err = bucket.AppendObject("log", &service.AppendObjectInput{
+		Position: service.Int64(1),
+		Body:     bytes.NewReader([]byte("def")),
+	})
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+}
+
+func TestMultipart(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, _ := NewTestBucket(t, server)
+
+	initiated, err := bucket.InitiateMultipartUpload("key", nil)
+	assert.Nil(t, err)
+	uploadID := initiated.UploadID
+	for i, part := range []string{"hello ", "world"} {
+		_, err = bucket.UploadMultipart("key", &service.UploadMultipartInput{
+			UploadID:   uploadID,
+			PartNumber: service.Int(i),
+			Body:       bytes.NewReader([]byte(part)),
+		})
+		assert.Nil(t, err)
+	}
+
+	parts, err := bucket.ListMultipart("key", &service.ListMultipartInput{UploadID: uploadID})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, service.IntValue(parts.Count))
+	assert.Equal(t, int64(5), service.Int64Value(parts.ObjectParts[1].Size))
+
+	_, err = bucket.CompleteMultipartUpload("key", &service.CompleteMultipartUploadInput{
+		UploadID:    uploadID,
+		ObjectParts: parts.ObjectParts,
+	})
+	assert.Nil(t, err)
+	content, _ := server.Object("bucket", "key")
+	assert.Equal(t, "hello world", string(content))
+
+	_, err = bucket.AbortMultipartUpload("key", &service.AbortMultipartUploadInput{UploadID: uploadID})
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+}
+
+func TestListObjects(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, _ := NewTestBucket(t, server)
+
+	for _, key := range []string{"a/1", "a/2", "b", "c/1", "d"} {
+		_, err := bucket.PutObject(key, &service.PutObjectInput{Body: bytes.NewReader([]byte(key))})
+		assert.Nil(t, err)
+	}
+
+	out, err := bucket.ListObjects(&service.ListObjectsInput{
+		Delimiter: service.String("/"),
+		Limit:     service.Int(2),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a/"}, service.StringValueSlice(out.CommonPrefixes))
+	assert.Equal(t, "b", service.StringValue(out.Keys[0].Key))
+	assert.True(t, service.BoolValue(out.HasMore))
+
+	out, err = bucket.ListObjects(&service.ListObjectsInput{
+		Delimiter: service.String("/"),
+		Marker:    out.NextMarker,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"c/"}, service.StringValueSlice(out.CommonPrefixes))
+	assert.Equal(t, "d", service.StringValue(out.Keys[0].Key))
+	assert.False(t, service.BoolValue(out.HasMore))
+
+	out, err = bucket.ListObjects(&service.ListObjectsInput{Prefix: service.String("a/")})
+	assert.Nil(t, err)
+	assert.Len(t, out.Keys, 2)
+}
+
+func TestBucketSettings(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	bucket, _ := NewTestBucket(t, server)
+
+	_, err := bucket.GetCORS()
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+
+	_, err = bucket.PutCORS(&service.PutBucketCORSInput{
+		CORSRules: []*service.CORSRuleType{{
+			AllowedMethods: service.StringSlice([]string{"GET"}),
+			AllowedOrigin:  service.String("*"),
+		}},
+	})
+	assert.Nil(t, err)
+	cors, err := bucket.GetCORS()
+	assert.Nil(t, err)
+	assert.Equal(t, "*", service.StringValue(cors.CORSRules[0].AllowedOrigin))
+
+	_, err = bucket.DeleteCORS()
+	assert.Nil(t, err)
+	_, err = bucket.GetCORS()
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+
+	_, err = bucket.Put()
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+}
+
+func TestSignatureVerification(t *testing.T) {
+	server := NewServer(WithSignatureVerification(), WithVirtualHostStyle())
+	defer server.Close()
+	bucket, _ := NewTestBucket(t, server)
+
+	_, err := bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader([]byte("content"))})
+	assert.Nil(t, err)
+
+	presigned, err := bucket.PresignGetObject("key", nil)
+	assert.Nil(t, err)
+	conf, _ := server.Config()
+	resp, err := conf.Connection.Get(presigned.URL)
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "content", string(body))
+
+	conf.SecretAccessKey = "WRONG"
+	qs, _ := service.Init(conf)
+	wrong, _ := qs.Bucket("bucket", "pek3b")
+	_, err = wrong.GetObject("key", nil)
+	assert.Equal(t, "signature_not_match", err.(*errors.QingStorError).Code)
+}
+
+func TestEndpoint(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	conf, _ := config.New(DefaultAccessKeyID, DefaultSecretAccessKey)
+	assert.Nil(t, conf.SetEndpoint(server.URL))
+	qs, _ := service.Init(conf)
+	_, err := qs.ListBuckets(nil)
+	assert.Nil(t, err)
+	bucket, _ := qs.Bucket("bucket", "")
+	_, err = bucket.Put()
+	assert.Nil(t, err)
+	out, err := qs.ListBuckets(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "bucket", service.StringValue(out.Buckets[0].Name))
+}
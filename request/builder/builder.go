@@ -91,13 +91,13 @@ func (qb *Builder) BuildHTTPRequest(ctx context.Context, o *data.Operation, i *r
 		zap.String("url", req.URL.String()),
 	)
 
-	logger.Info("QingStor request headers",
+	logger.Debug("QingStor request headers",
 		zap.Int64("date", timestamp),
-		zap.String("header", fmt.Sprint(req.Header)),
+		zap.String("header", fmt.Sprint(utils.RedactHeader(req.Header))),
 	)
 
 	if qb.parsedBodyString != "" {
-		logger.Info("QingStor request body string",
+		logger.Debug("QingStor request body string",
 			zap.Int64("date", timestamp),
 			zap.String("parsed_body_string", qb.parsedBodyString),
 		)
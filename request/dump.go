@@ -0,0 +1,112 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/log"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/signer/v2"
+	"github.com/qingstor/qingstor-sdk-go/v4/utils"
+)
+
+// dumpRequest logs the request to send at debug level if dump is enabled.
+func (r *Request) dumpRequest(ctx context.Context) {
+	logger := log.FromContext(ctx)
+	settings := r.Operation.Config.DumpSettings
+	if !settings.Enabled || !logger.Core().Enabled(zap.DebugLevel) {
+		return
+	}
+
+	req := r.HTTPRequest.Request
+	fields := []zap.Field{
+		zap.String("method", req.Method),
+		zap.String("url", utils.RedactURL(req.URL)),
+		zap.String("header", fmt.Sprint(utils.RedactHeader(req.Header))),
+	}
+	if stringToSign, ok := r.stringToSign(); ok {
+		fields = append(fields, zap.String("string_to_sign", stringToSign))
+	}
+	if settings.Body && req.Body != nil {
+		var body []byte
+		body, req.Body = peekBody(req.Body, settings.MaxBodySize)
+		fields = append(fields, zap.ByteString("body", body))
+	}
+
+	logger.Debug("dump QingStor request", fields...)
+}
+
+// dumpResponse logs the received response at debug level if dump is enabled.
+func (r *Request) dumpResponse(ctx context.Context) {
+	logger := log.FromContext(ctx)
+	settings := r.Operation.Config.DumpSettings
+	if !settings.Enabled || !logger.Core().Enabled(zap.DebugLevel) {
+		return
+	}
+
+	resp := r.HTTPResponse
+	fields := []zap.Field{
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("header", fmt.Sprint(utils.RedactHeader(resp.Header))),
+	}
+	if settings.Body && resp.Body != nil {
+		var body []byte
+		body, resp.Body = peekBody(resp.Body, settings.MaxBodySize)
+		fields = append(fields, zap.ByteString("body", body))
+	}
+
+	logger.Debug("dump QingStor response", fields...)
+}
+
+// stringToSign builds the string to sign of the signed request.
+// It returns false if the request is not signed.
+func (r *Request) stringToSign() (string, bool) {
+	s := &signer.QingStorSigner{}
+	if r.HTTPRequest.Header.Get("Authorization") != "" {
+		stringToSign, err := s.BuildStringToSign(r.HTTPRequest)
+		return stringToSign, err == nil
+	}
+
+	expires, err := strconv.Atoi(r.HTTPRequest.URL.Query().Get("expires"))
+	if err != nil || r.HTTPRequest.URL.Query().Get("signature") == "" {
+		return "", false
+	}
+	stringToSign, err := s.BuildQueryStringToSign(r.HTTPRequest, expires)
+	return stringToSign, err == nil
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// peekBody reads at most limit bytes from body, and returns them with a body
+// which reads the whole content of the origin body.
+func peekBody(body io.ReadCloser, limit int64) ([]byte, io.ReadCloser) {
+	content, _ := ioutil.ReadAll(io.LimitReader(body, limit))
+	return content, peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(content), body),
+		Closer: body,
+	}
+}
@@ -0,0 +1,141 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/credentials"
+	"github.com/qingstor/qingstor-sdk-go/v4/log"
+	"github.com/qingstor/qingstor-sdk-go/v4/utils"
+)
+
+func TestRequestDump(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := ioutil.ReadAll(r.Body)
+		body = string(content)
+		w.Header().Set("X-QS-Request-ID", "test")
+		w.WriteHeader(201)
+		fmt.Fprint(w, "response content")
+	}))
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := log.ContextWithLogger(context.Background(), zap.New(core))
+
+	operation := newTestOperation(t, server)
+	operation.Config.DumpSettings.Enabled = true
+	operation.Config.DumpSettings.Body = true
+	operation.Config.DumpSettings.MaxBodySize = 4
+
+	output := &SomeActionOutput{}
+	r, err := New(operation, &SomeBodyInput{Body: strings.NewReader("content")}, output)
+	assert.Nil(t, err)
+	r.Handlers.Build.PushBack(NamedHandler{Name: "key", Fn: func(ctx context.Context, r *Request) error {
+		r.HTTPRequest.Header.Set("X-QS-Encryption-Customer-Key", "secret-key")
+		return nil
+	}})
+	err = r.SendWithContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "content", body)
+
+	requests := logs.FilterMessage("dump QingStor request").All()
+	assert.Equal(t, 1, len(requests))
+	fields := requests[0].ContextMap()
+	assert.Equal(t, "PUT", fields["method"])
+	assert.Contains(t, fields["header"], utils.Redacted)
+	assert.NotContains(t, fields["header"], "secret-key")
+	assert.NotContains(t, fields["header"], r.HTTPRequest.Header.Get("Authorization"))
+	assert.Contains(t, fields["string_to_sign"], "PUT\n")
+	assert.Equal(t, "cont", fields["body"])
+
+	responses := logs.FilterMessage("dump QingStor response").All()
+	assert.Equal(t, 1, len(responses))
+	fields = responses[0].ContextMap()
+	assert.Equal(t, int64(201), fields["status_code"])
+	assert.Equal(t, "resp", fields["body"])
+}
+
+func TestRequestDumpSessionToken(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(0, &bodies)
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := log.ContextWithLogger(context.Background(), zap.New(core))
+
+	operation := newTestOperation(t, server)
+	operation.Config.DumpSettings.Enabled = true
+	operation.Config.CredentialsProvider = credentials.NewStaticProvider(
+		"TEMP_ACCESS_KEY_ID", "TEMP_SECRET_ACCESS_KEY", "SESSION_TOKEN")
+	r, err := New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(ctx)
+	assert.Nil(t, err)
+
+	fields := logs.FilterMessage("dump QingStor request").All()[0].ContextMap()
+	assert.Contains(t, fields["header"], "X-Qs-Security-Token:["+utils.Redacted+"]")
+	assert.NotContains(t, fields["header"], "SESSION_TOKEN")
+}
+
+func TestRequestDumpDisabled(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(0, &bodies)
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := log.ContextWithLogger(context.Background(), zap.New(core))
+
+	r, err := New(newTestOperation(t, server), nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, logs.FilterMessage("dump QingStor request").Len())
+}
+
+func TestRequestDumpQuerySignature(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(0, &bodies)
+	defer server.Close()
+
+	operation := newTestOperation(t, server)
+	operation.Config.DumpSettings.Enabled = true
+	r, err := New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.Build()
+	assert.Nil(t, err)
+	err = r.SignQuery(10)
+	assert.Nil(t, err)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	r.dumpRequest(log.ContextWithLogger(context.Background(), zap.New(core)))
+	fields := logs.FilterMessage("dump QingStor request").All()[0].ContextMap()
+	assert.Contains(t, fields["url"], "signature="+utils.Redacted)
+	assert.NotEqual(t, "", fields["string_to_sign"])
+}
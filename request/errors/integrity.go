@@ -0,0 +1,36 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package errors
+
+import "fmt"
+
+// IntegrityError indicates that the MD5 of object content sent or received
+// doesn't match the ETag returned by QingStor.
+type IntegrityError struct {
+	// Operation is the API name of the request, eg: "PUT Object".
+	Operation string
+	// ContentMD5 is the hex MD5 of the content.
+	ContentMD5 string
+	ETag       string
+	RequestID  string
+}
+
+// Error returns the description of IntegrityError.
+func (e IntegrityError) Error() string {
+	return fmt.Sprintf(`integrity check of "%s" failed: content MD5 "%s" doesn't match ETag "%s", Request ID "%s"`,
+		e.Operation, e.ContentMD5, e.ETag, e.RequestID)
+}
@@ -0,0 +1,42 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package errors
+
+import "fmt"
+
+// WaiterTimeoutError indicates that a waiter used up its attempts before the
+// condition was met.
+type WaiterTimeoutError struct {
+	Waiter   string
+	Attempts int
+	// LastErr is the error of the last attempt, nil if it succeeded without
+	// meeting the condition.
+	LastErr error
+}
+
+// Unwrap implement interface of errors
+func (e WaiterTimeoutError) Unwrap() error {
+	return e.LastErr
+}
+
+// Error returns the description of WaiterTimeoutError.
+func (e WaiterTimeoutError) Error() string {
+	if e.LastErr != nil {
+		return fmt.Sprintf(`waiter "%s" timed out after %d attempts, last error: %s`, e.Waiter, e.Attempts, e.LastErr)
+	}
+	return fmt.Sprintf(`waiter "%s" timed out after %d attempts`, e.Waiter, e.Attempts)
+}
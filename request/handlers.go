@@ -0,0 +1,238 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"context"
+	"errors"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+)
+
+// Names of the handlers which implement the builtin request lifecycle.
+const (
+	CheckHandlerName  = "qingstor.Check"
+	BuildHandlerName  = "qingstor.Build"
+	SignHandlerName   = "qingstor.Sign"
+	SendHandlerName   = "qingstor.Send"
+	UnpackHandlerName = "qingstor.Unpack"
+)
+
+// ErrStopPhase can be returned by a handler to skip the remaining handlers
+// of the current phase without failing the request, it may be wrapped.
+// A handler stopping the Send phase before SendHandlerName must set
+// Request.HTTPResponse, which is unpacked in the Unpack phase.
+var ErrStopPhase = errors.New("stop phase")
+
+// Handler is a function run in a phase of the request lifecycle.
+// Returning a non-nil error other than ErrStopPhase aborts the request.
+type Handler func(ctx context.Context, r *Request) error
+
+// NamedHandler is a Handler with a name to locate it in a HandlerList.
+type NamedHandler struct {
+	Name string
+	Fn   Handler
+}
+
+// HandlerList is an ordered list of handlers run in a phase.
+type HandlerList struct {
+	list []NamedHandler
+}
+
+// Len returns the count of handlers in the list.
+func (l *HandlerList) Len() int {
+	return len(l.list)
+}
+
+// Names returns the names of handlers in the list in order.
+func (l *HandlerList) Names() []string {
+	names := make([]string, 0, len(l.list))
+	for _, h := range l.list {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+// PushBack appends handlers to the end of the list.
+func (l *HandlerList) PushBack(hs ...NamedHandler) {
+	l.list = append(l.list, hs...)
+}
+
+// PushFront inserts handlers to the front of the list.
+func (l *HandlerList) PushFront(hs ...NamedHandler) {
+	list := make([]NamedHandler, 0, len(hs)+len(l.list))
+	list = append(list, hs...)
+	l.list = append(list, l.list...)
+}
+
+// InsertBefore inserts handler before the first handler with given name.
+// It returns false if no such handler.
+func (l *HandlerList) InsertBefore(name string, h NamedHandler) bool {
+	for i := range l.list {
+		if l.list[i].Name == name {
+			l.insert(i, h)
+			return true
+		}
+	}
+	return false
+}
+
+// InsertAfter inserts handler after the first handler with given name.
+// It returns false if no such handler.
+func (l *HandlerList) InsertAfter(name string, h NamedHandler) bool {
+	for i := range l.list {
+		if l.list[i].Name == name {
+			l.insert(i+1, h)
+			return true
+		}
+	}
+	return false
+}
+
+// Swap replaces the handlers with given name by handler h.
+// It returns false if no such handler.
+func (l *HandlerList) Swap(name string, h NamedHandler) bool {
+	swapped := false
+	for i := range l.list {
+		if l.list[i].Name == name {
+			l.list[i] = h
+			swapped = true
+		}
+	}
+	return swapped
+}
+
+// Remove removes all handlers with given name.
+func (l *HandlerList) Remove(name string) {
+	list := l.list[:0:0]
+	for _, h := range l.list {
+		if h.Name != name {
+			list = append(list, h)
+		}
+	}
+	l.list = list
+}
+
+// Clear removes all handlers in the list.
+func (l *HandlerList) Clear() {
+	l.list = nil
+}
+
+// Run runs handlers in order with given ctx and request.
+// It stops at the first handler returns error.
+func (l *HandlerList) Run(ctx context.Context, r *Request) error {
+	for _, h := range l.list {
+		err := h.Fn(ctx, r)
+		if errors.Is(err, ErrStopPhase) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *HandlerList) insert(i int, h NamedHandler) {
+	list := make([]NamedHandler, 0, len(l.list)+1)
+	list = append(list, l.list[:i]...)
+	list = append(list, h)
+	l.list = append(list, l.list[i:]...)
+}
+
+func (l HandlerList) copy() HandlerList {
+	list := make([]NamedHandler, len(l.list))
+	copy(list, l.list)
+	return HandlerList{list: list}
+}
+
+// Handlers stores the handler lists of every phase of the request lifecycle.
+// Check and Build run in BuildWithContext, Sign runs in SignWithContext,
+// Send and Unpack run in DoWithContext.
+type Handlers struct {
+	Check  HandlerList
+	Build  HandlerList
+	Sign   HandlerList
+	Send   HandlerList
+	Unpack HandlerList
+}
+
+// NewHandlers creates Handlers with the builtin handlers.
+func NewHandlers() *Handlers {
+	h := &Handlers{}
+	h.Check.PushBack(NamedHandler{Name: CheckHandlerName, Fn: func(ctx context.Context, r *Request) error {
+		return r.check(ctx)
+	}})
+	h.Build.PushBack(NamedHandler{Name: BuildHandlerName, Fn: func(ctx context.Context, r *Request) error {
+		return r.build(ctx)
+	}})
+	h.Sign.PushBack(NamedHandler{Name: SignHandlerName, Fn: func(ctx context.Context, r *Request) error {
+		return r.sign(ctx)
+	}})
+	h.Send.PushBack(NamedHandler{Name: SendHandlerName, Fn: func(ctx context.Context, r *Request) error {
+		return r.send(ctx)
+	}})
+	h.Unpack.PushBack(NamedHandler{Name: UnpackHandlerName, Fn: func(ctx context.Context, r *Request) error {
+		return r.unpack(ctx)
+	}})
+	return h
+}
+
+// NewHandlersWithConfig creates Handlers with the builtin handlers, and the
+// handlers installed by the HandlerInstallers of c.
+func NewHandlersWithConfig(c *config.Config) *Handlers {
+	h := NewHandlers()
+	if c != nil {
+		for _, installer := range c.HandlerInstallers {
+			installer.InstallHandlers(h)
+		}
+	}
+	return h
+}
+
+// HandlerInstallerFunc is a config.HandlerInstaller calling the function with
+// the handlers to install into, it registers handlers for every service
+// initialized with a config:
+//
+//	conf.HandlerInstallers = append(conf.HandlerInstallers,
+//		request.HandlerInstallerFunc(func(h *request.Handlers) {
+//			h.Build.PushBack(handler)
+//		}))
+type HandlerInstallerFunc func(h *Handlers)
+
+// InstallHandlers implements config.HandlerInstaller, handlers of other types
+// are ignored.
+func (f HandlerInstallerFunc) InstallHandlers(handlers interface{}) {
+	if h, ok := handlers.(*Handlers); ok {
+		f(h)
+	}
+}
+
+// Copy returns a deep copy of the handlers, modifying the copy will not affect the origin.
+// It returns nil if h is nil.
+func (h *Handlers) Copy() *Handlers {
+	if h == nil {
+		return nil
+	}
+	return &Handlers{
+		Check:  h.Check.copy(),
+		Build:  h.Build.copy(),
+		Sign:   h.Sign.copy(),
+		Send:   h.Send.copy(),
+		Unpack: h.Unpack.copy(),
+	}
+}
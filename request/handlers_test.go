@@ -0,0 +1,199 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/credentials"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+)
+
+func noopHandler(name string) NamedHandler {
+	return NamedHandler{Name: name, Fn: func(ctx context.Context, r *Request) error {
+		return nil
+	}}
+}
+
+func TestHandlerList(t *testing.T) {
+	l := HandlerList{}
+	l.PushBack(noopHandler("b"))
+	l.PushFront(noopHandler("a"))
+	l.PushBack(noopHandler("d"))
+	assert.True(t, l.InsertAfter("b", noopHandler("c")))
+	assert.True(t, l.InsertBefore("a", noopHandler("0")))
+	assert.False(t, l.InsertBefore("x", noopHandler("y")))
+	assert.Equal(t, []string{"0", "a", "b", "c", "d"}, l.Names())
+
+	l.Remove("0")
+	assert.True(t, l.Swap("d", noopHandler("e")))
+	assert.Equal(t, []string{"a", "b", "c", "e"}, l.Names())
+	assert.Equal(t, 4, l.Len())
+
+	l.Clear()
+	assert.Equal(t, 0, l.Len())
+}
+
+func TestHandlersCopy(t *testing.T) {
+	h := NewHandlers()
+	c := h.Copy()
+	c.Build.PushBack(noopHandler("custom"))
+
+	assert.Equal(t, []string{BuildHandlerName}, h.Build.Names())
+	assert.Equal(t, []string{BuildHandlerName, "custom"}, c.Build.Names())
+
+	var nilHandlers *Handlers
+	assert.Nil(t, nilHandlers.Copy())
+}
+
+func TestNewHandlersWithConfig(t *testing.T) {
+	c := &config.Config{}
+	c.HandlerInstallers = append(c.HandlerInstallers, HandlerInstallerFunc(func(h *Handlers) {
+		h.Build.PushBack(noopHandler("global"))
+	}))
+	h := NewHandlersWithConfig(c)
+	assert.Equal(t, []string{BuildHandlerName, "global"}, h.Build.Names())
+	assert.Equal(t, []string{SendHandlerName}, h.Send.Names())
+
+	assert.Equal(t, []string{BuildHandlerName}, NewHandlersWithConfig(nil).Build.Names())
+}
+
+func TestHandlersRun(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-QS-Test")
+		w.WriteHeader(201)
+	}))
+	defer server.Close()
+
+	var phases []string
+	record := func(name string) NamedHandler {
+		return NamedHandler{Name: name, Fn: func(ctx context.Context, r *Request) error {
+			phases = append(phases, name)
+			return nil
+		}}
+	}
+
+	output := &SomeActionOutput{}
+	r, err := New(newTestOperation(t, server), nil, output)
+	assert.Nil(t, err)
+	r.Handlers.Build.PushBack(NamedHandler{Name: "header", Fn: func(ctx context.Context, r *Request) error {
+		r.HTTPRequest.Header.Set("X-QS-Test", "test")
+		return nil
+	}})
+	r.Handlers.Sign.PushBack(record("sign"))
+	r.Handlers.Unpack.PushFront(record("unpack"))
+
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "test", header)
+	assert.Equal(t, []string{"sign", "unpack"}, phases)
+	assert.Equal(t, 201, *output.StatusCode)
+}
+
+func TestHandlersShortCircuit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("request should not be sent")
+	}))
+	defer server.Close()
+
+	output := &SomeActionOutput{}
+	r, err := New(newTestOperation(t, server), nil, output)
+	assert.Nil(t, err)
+	r.Handlers.Send.PushFront(NamedHandler{Name: "cache", Fn: func(ctx context.Context, r *Request) error {
+		r.HTTPResponse = &http.Response{
+			StatusCode: 201,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}
+		return ErrStopPhase
+	}})
+
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 201, *output.StatusCode)
+
+	r.Handlers.Sign.PushFront(NamedHandler{Name: "deny", Fn: func(ctx context.Context, r *Request) error {
+		return fmt.Errorf("denied")
+	}})
+	err = r.SendWithContext(context.Background())
+	assert.EqualError(t, err, "denied")
+}
+
+func TestHandlersShortCircuitWithoutResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("request should not be sent")
+	}))
+	defer server.Close()
+
+	r, err := New(newTestOperation(t, server), nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	r.Handlers.Send.PushFront(NamedHandler{Name: "skip", Fn: func(ctx context.Context, r *Request) error {
+		return fmt.Errorf("skip sending: %w", ErrStopPhase)
+	}})
+
+	err = r.SendWithContext(context.Background())
+	_, ok := err.(errors.SDKError)
+	assert.True(t, ok)
+	assert.Contains(t, err.Error(), "HTTPResponse must be set")
+}
+
+func TestSignWithCredentialsProvider(t *testing.T) {
+	var authorization, token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		token = r.Header.Get(credentials.SessionTokenHeader)
+		w.WriteHeader(201)
+	}))
+	defer server.Close()
+
+	provider := credentials.NewStaticProvider("TEMP_ACCESS_KEY_ID", "TEMP_SECRET_ACCESS_KEY", "SESSION_TOKEN")
+	operation := newTestOperation(t, server)
+	operation.Config.CredentialsProvider = provider
+
+	r, err := New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(authorization, "QS TEMP_ACCESS_KEY_ID:"))
+	assert.Equal(t, "SESSION_TOKEN", token)
+
+	// Rotated credentials are used by the next request.
+	provider.Value = credentials.Value{AccessKeyID: "NEW_ACCESS_KEY_ID", SecretAccessKey: "NEW_SECRET_ACCESS_KEY"}
+	r, err = New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(authorization, "QS NEW_ACCESS_KEY_ID:"))
+	assert.Equal(t, "", token)
+
+	operation.Config.CredentialsProvider = credentials.NewChainProvider()
+	r, err = New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.NotNil(t, err)
+}
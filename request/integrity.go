@@ -0,0 +1,209 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	qsErrors "github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+)
+
+// uploadOperations are the operations whose body is the content of object,
+// and whose ETag is the MD5 of body.
+var uploadOperations = map[string]bool{
+	"PUT Object":       true,
+	"Upload Multipart": true,
+}
+
+// uploadDigest is the MD5 of body sent by an attempt, sum is known before
+// sending, or hash is written while sending.
+type uploadDigest struct {
+	sum  []byte
+	hash hash.Hash
+}
+
+// prepareIntegrityCheck sets Content-MD5 of uploads with seekable body, and
+// hashes the other bodies while they are sent. Seekable body is read and
+// seeked back before sending, callers tracking the reads and seeks of body
+// should set Content-MD5 themselves.
+func (r *Request) prepareIntegrityCheck() error {
+	r.uploadDigest = nil
+	if !uploadOperations[r.Operation.APIName] || r.HTTPRequest.Body == nil || r.HTTPRequest.Body == http.NoBody {
+		return nil
+	}
+
+	if v := r.HTTPRequest.Header.Get("Content-MD5"); v != "" {
+		if sum, err := base64.StdEncoding.DecodeString(v); err == nil {
+			r.uploadDigest = &uploadDigest{sum: sum}
+		}
+		return nil
+	}
+
+	if body, ok := r.inputBody().(io.ReadSeeker); ok {
+		offset, err := body.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return qsErrors.NewSDKError(
+				qsErrors.WithAction("seek body in prepareIntegrityCheck"),
+				qsErrors.WithError(err),
+			)
+		}
+		h := md5.New()
+		_, err = io.Copy(h, io.LimitReader(body, r.HTTPRequest.ContentLength))
+		if err != nil {
+			return qsErrors.NewSDKError(
+				qsErrors.WithAction("hash body in prepareIntegrityCheck"),
+				qsErrors.WithError(err),
+			)
+		}
+		_, err = body.Seek(offset, io.SeekStart)
+		if err != nil {
+			return qsErrors.NewSDKError(
+				qsErrors.WithAction("rewind body in prepareIntegrityCheck"),
+				qsErrors.WithError(err),
+			)
+		}
+
+		sum := h.Sum(nil)
+		r.HTTPRequest.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum))
+		r.uploadDigest = &uploadDigest{sum: sum}
+		return nil
+	}
+
+	h := md5.New()
+	r.HTTPRequest.Body = &hashingBody{ReadCloser: r.HTTPRequest.Body, hash: h}
+	r.uploadDigest = &uploadDigest{hash: h}
+	return nil
+}
+
+// inputBody returns the body of input, it's nil if input has no body.
+func (r *Request) inputBody() io.Reader {
+	if r.Input == nil || !r.Input.IsValid() ||
+		r.Input.Kind() != reflect.Ptr || r.Input.IsNil() {
+		return nil
+	}
+	field := r.Input.Elem().FieldByName("Body")
+	if !field.IsValid() || field.Type() != readerType || field.IsNil() {
+		return nil
+	}
+	return field.Interface().(io.Reader)
+}
+
+// checkIntegrity compares the MD5 of uploaded body with the returned ETag,
+// and makes the body of GET Object verify itself at EOF.
+//
+// Multipart ETags, ranged reads and objects encrypted by customer keys are
+// skipped, their ETags are not the MD5 of content.
+func (r *Request) checkIntegrity() error {
+	resp := r.HTTPResponse
+	if r.HTTPRequest.Header.Get("X-QS-Encryption-Customer-Algorithm") != "" ||
+		resp.Header.Get("X-QS-Encryption-Customer-Algorithm") != "" {
+		return nil
+	}
+	etag, ok := contentETag(resp.Header.Get("ETag"))
+	if !ok {
+		return nil
+	}
+
+	if r.uploadDigest != nil {
+		sum := r.uploadDigest.sum
+		if sum == nil {
+			sum = r.uploadDigest.hash.Sum(nil)
+		}
+		if hex.EncodeToString(sum) != etag {
+			return r.integrityError(hex.EncodeToString(sum), etag)
+		}
+		return nil
+	}
+
+	if r.Operation.APIName != "GET Object" || resp.StatusCode != http.StatusOK || resp.Uncompressed {
+		return nil
+	}
+	if r.Output == nil || !r.Output.IsValid() || r.Output.Kind() != reflect.Ptr || r.Output.IsNil() {
+		return nil
+	}
+	field := r.Output.Elem().FieldByName("Body")
+	if !field.IsValid() || field.IsNil() {
+		return nil
+	}
+	body, ok := field.Interface().(io.ReadCloser)
+	if !ok {
+		return nil
+	}
+	field.Set(reflect.ValueOf(&verifyingBody{ReadCloser: body, hash: md5.New(), etag: etag, r: r}))
+	return nil
+}
+
+// contentETag returns the ETag without quotes, ok is false if it's not the
+// MD5 of content.
+func contentETag(etag string) (string, bool) {
+	etag = strings.ToLower(strings.Trim(etag, `"`))
+	if len(etag) != hex.EncodedLen(md5.Size) {
+		return "", false
+	}
+	if _, err := hex.DecodeString(etag); err != nil {
+		return "", false
+	}
+	return etag, true
+}
+
+func (r *Request) integrityError(contentMD5, etag string) error {
+	return qsErrors.IntegrityError{
+		Operation:  r.Operation.APIName,
+		ContentMD5: contentMD5,
+		ETag:       etag,
+		RequestID:  r.HTTPResponse.Header.Get("X-QS-Request-ID"),
+	}
+}
+
+// hashingBody hashes the body while it's sent.
+type hashingBody struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (b *hashingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	return n, err
+}
+
+// verifyingBody hashes the body while it's read, and returns
+// qsErrors.IntegrityError at EOF if the MD5 doesn't match the ETag.
+type verifyingBody struct {
+	io.ReadCloser
+	hash hash.Hash
+	etag string
+	r    *Request
+}
+
+func (b *verifyingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	if err == io.EOF {
+		if sum := hex.EncodeToString(b.hash.Sum(nil)); sum != b.etag {
+			return n, b.r.integrityError(sum, b.etag)
+		}
+	}
+	return n, err
+}
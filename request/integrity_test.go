@@ -0,0 +1,145 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+)
+
+type SomeBodyOutput struct {
+	StatusCode *int          `location:"statusCode"`
+	RequestID  *string       `location:"requestID"`
+	Body       io.ReadCloser `location:"body"`
+}
+
+func md5Hex(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// newETagServer answers with the ETag returned by etag, and content for GET.
+func newETagServer(content string, etag func(body string) string, contentMD5 *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*contentMD5 = r.Header.Get("Content-MD5")
+		w.Header().Set("X-QS-Request-ID", "test")
+		if r.Method == "GET" {
+			w.Header().Set("ETag", `"`+etag(content)+`"`)
+			w.WriteHeader(200)
+			io.WriteString(w, content)
+			return
+		}
+		w.Header().Set("ETag", `"`+etag(string(body))+`"`)
+		w.WriteHeader(201)
+	}))
+}
+
+func sendIntegrityRequest(t *testing.T, server *httptest.Server, apiName string, body io.Reader, maxRetries int) (*SomeBodyOutput, error) {
+	operation := newTestOperation(t, server)
+	operation.APIName = apiName
+	operation.Config.EnableIntegrityCheck = true
+	operation.Config.RetrySettings.MaxRetries = maxRetries
+	if apiName == "GET Object" {
+		operation.RequestMethod = "GET"
+		operation.StatusCodes = []int{200}
+	}
+
+	output := &SomeBodyOutput{}
+	input := &SomeBodyInput{Body: body}
+	if body != nil {
+		input.ContentLength = Int64(int64(len("content")))
+	}
+	r, err := New(operation, input, output)
+	assert.Nil(t, err)
+	return output, r.SendWithContext(context.Background())
+}
+
+func TestIntegrityUpload(t *testing.T) {
+	var contentMD5 string
+	server := newETagServer("", md5Hex, &contentMD5)
+	defer server.Close()
+
+	_, err := sendIntegrityRequest(t, server, "PUT Object", strings.NewReader("content"), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "mgNkuembtIDdJeHwKEyFVQ==", contentMD5)
+
+	// Non-seekable body is hashed while it's sent.
+	_, err = sendIntegrityRequest(t, server, "Upload Multipart", ioutil.NopCloser(strings.NewReader("content")), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "", contentMD5)
+
+	// Other operations are not checked.
+	_, err = sendIntegrityRequest(t, server, "Some Action", strings.NewReader("content"), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "", contentMD5)
+}
+
+func TestIntegrityUploadMismatch(t *testing.T) {
+	var contentMD5 string
+	server := newETagServer("", func(string) string { return md5Hex("other") }, &contentMD5)
+	defer server.Close()
+
+	for _, body := range []io.Reader{strings.NewReader("content"), ioutil.NopCloser(strings.NewReader("content"))} {
+		_, err := sendIntegrityRequest(t, server, "PUT Object", body, 0)
+		e, ok := err.(errors.IntegrityError)
+		assert.True(t, ok)
+		assert.Equal(t, "PUT Object", e.Operation)
+		assert.Equal(t, md5Hex("content"), e.ContentMD5)
+		assert.Equal(t, md5Hex("other"), e.ETag)
+		assert.Equal(t, "test", e.RequestID)
+	}
+
+	// Multipart ETags are skipped.
+	multipart := newETagServer("", func(string) string { return md5Hex("other") + "-2" }, &contentMD5)
+	defer multipart.Close()
+	_, err := sendIntegrityRequest(t, multipart, "PUT Object", strings.NewReader("content"), 0)
+	assert.Nil(t, err)
+}
+
+func TestIntegrityDownload(t *testing.T) {
+	var contentMD5 string
+	server := newETagServer("content", md5Hex, &contentMD5)
+	defer server.Close()
+
+	output, err := sendIntegrityRequest(t, server, "GET Object", nil, 0)
+	assert.Nil(t, err)
+	content, err := ioutil.ReadAll(output.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "content", string(content))
+
+	corrupted := newETagServer("content", func(string) string { return md5Hex("other") }, &contentMD5)
+	defer corrupted.Close()
+	output, err = sendIntegrityRequest(t, corrupted, "GET Object", nil, 0)
+	assert.Nil(t, err)
+	content, err = ioutil.ReadAll(output.Body)
+	assert.Equal(t, "content", string(content))
+	e, ok := err.(errors.IntegrityError)
+	assert.True(t, ok)
+	assert.Equal(t, "GET Object", e.Operation)
+}
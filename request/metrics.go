@@ -0,0 +1,144 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"io"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/metrics"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+)
+
+// measurement collects the metrics sample of a request across attempts.
+type measurement struct {
+	start  time.Time
+	sample metrics.Sample
+}
+
+func newMeasurement() *measurement {
+	return &measurement{start: time.Now()}
+}
+
+// addAttempt counts the bytes transferred by the last attempt of r, which
+// ended with err. The response body left open for the caller of a succeeded
+// attempt is counted by its Content-Length, as it's read after the sample is
+// reported.
+func (m *measurement) addAttempt(r *Request, err error) {
+	m.sample.BytesSent += r.sent.count()
+
+	received := r.received.count()
+	if err == nil && r.received != nil && !r.received.finished() &&
+		r.HTTPResponse != nil && r.HTTPResponse.ContentLength > received {
+		received = r.HTTPResponse.ContentLength
+	}
+	m.sample.BytesReceived += received
+}
+
+// byteCounter counts the bytes read from a request or response body, the
+// transport may read request bodies in another goroutine.
+type byteCounter struct {
+	io.ReadCloser
+	n    int64
+	done int32
+}
+
+func (c *byteCounter) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	atomic.AddInt64(&c.n, int64(n))
+	if err == io.EOF {
+		atomic.StoreInt32(&c.done, 1)
+	}
+	return n, err
+}
+
+func (c *byteCounter) Close() error {
+	atomic.StoreInt32(&c.done, 1)
+	return c.ReadCloser.Close()
+}
+
+// count returns the bytes read, it's 0 if c is nil.
+func (c *byteCounter) count() int64 {
+	if c == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&c.n)
+}
+
+// finished checks whether the body is read to the end or closed.
+func (c *byteCounter) finished() bool {
+	return atomic.LoadInt32(&c.done) == 1
+}
+
+// observe reports the sample to the metrics sink in config.
+func (m *measurement) observe(r *Request, err error) {
+	sink := r.Operation.Config.Metrics
+	if sink == nil {
+		return
+	}
+
+	s := m.sample
+	s.Operation = r.Operation.APIName
+	s.Bucket = r.property("bucket-name")
+	s.Zone = r.property("zone")
+	s.Attempts = r.Attempts
+	s.Latency = time.Since(m.start)
+	if r.HTTPResponse != nil {
+		s.StatusCode = r.HTTPResponse.StatusCode
+	}
+	if err != nil {
+		s.Failed = true
+		switch e := err.(type) {
+		case *errors.QingStorError:
+			s.ErrorCode = e.Code
+			s.StatusCode = e.StatusCode
+		case errors.QingStorError:
+			s.ErrorCode = e.Code
+			s.StatusCode = e.StatusCode
+		case errors.UnhandledResponseError:
+			s.StatusCode = e.StatusCode
+		}
+	}
+
+	sink.Observe(&s)
+}
+
+// property returns the value of operation property with given name tag.
+func (r *Request) property(name string) string {
+	if r.Operation.Properties == nil {
+		return ""
+	}
+	fields := reflect.ValueOf(r.Operation.Properties)
+	if fields.Kind() == reflect.Ptr {
+		fields = fields.Elem()
+	}
+	if fields.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < fields.NumField(); i++ {
+		if fields.Type().Field(i).Tag.Get("name") != name {
+			continue
+		}
+		if value, ok := fields.Field(i).Interface().(*string); ok && value != nil {
+			return *value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/metrics"
+)
+
+type SomeBucketProperties struct {
+	BucketName *string `json:"bucket-name" name:"bucket-name"`
+	Zone       *string `json:"zone" name:"zone"`
+}
+
+func TestRequestMetrics(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	sink := metrics.NewMemory()
+	operation := newTestOperation(t, server)
+	operation.Config.Metrics = sink
+	operation.Properties = &SomeBucketProperties{
+		BucketName: String("bucket"),
+		Zone:       String(""),
+	}
+	operation.RequestURI = "/<bucket-name>"
+
+	r, err := New(operation, &SomeBodyInput{Body: strings.NewReader("content")}, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+
+	operation.Config.RetrySettings.MaxRetries = 0
+	r, err = New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+
+	samples := sink.Samples()
+	assert.Equal(t, 2, len(samples))
+
+	s := samples[0]
+	assert.Equal(t, "Some Action", s.Operation)
+	assert.Equal(t, "bucket", s.Bucket)
+	assert.Equal(t, 201, s.StatusCode)
+	assert.Equal(t, 2, s.Attempts)
+	assert.Equal(t, int64(14), s.BytesSent)
+	assert.False(t, s.Failed)
+	assert.True(t, s.Latency > 0)
+}
+
+func TestRequestMetricsError(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	sink := metrics.NewMemory()
+	operation := newTestOperation(t, server)
+	operation.Config.Metrics = sink
+	operation.Config.RetrySettings.MaxRetries = 0
+
+	r, err := New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.NotNil(t, err)
+
+	s := sink.Samples()[0]
+	assert.True(t, s.Failed)
+	assert.Equal(t, 503, s.StatusCode)
+	assert.Equal(t, "service_unavailable", s.ErrorCode)
+	assert.Equal(t, 1, s.Attempts)
+}
+
+type SomeStreamOutput struct {
+	StatusCode *int          `location:"statusCode"`
+	Body       io.ReadCloser `location:"body"`
+}
+
+func TestRequestMetricsBytes(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	sink := metrics.NewMemory()
+	operation := newTestOperation(t, server)
+	operation.Config.Metrics = sink
+
+	r, err := New(operation, &SomeBodyInput{Body: strings.NewReader("content")}, &SomeActionOutput{})
+	assert.Nil(t, err)
+	// The second attempt fails before sending, the request of the first
+	// attempt is not counted again.
+	r.Handlers.Build.PushFront(NamedHandler{Name: "reset", Fn: func(ctx context.Context, r *Request) error {
+		if r.Attempts == 2 {
+			return syscall.ECONNRESET
+		}
+		return nil
+	}})
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+
+	s := sink.Samples()[0]
+	assert.Equal(t, 3, s.Attempts)
+	assert.Equal(t, int64(14), s.BytesSent)
+	assert.Equal(t, int64(len(`{"code":"service_unavailable","request_id":"test"}`)), s.BytesReceived)
+}
+
+func TestRequestMetricsStreamedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-QS-Request-ID", "test")
+		w.WriteHeader(201)
+		fmt.Fprint(w, "streamed content")
+	}))
+	defer server.Close()
+
+	sink := metrics.NewMemory()
+	operation := newTestOperation(t, server)
+	operation.Config.Metrics = sink
+	operation.APIName = "GET Object"
+
+	output := &SomeStreamOutput{}
+	r, err := New(operation, nil, output)
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	// The body left for the caller is counted by its Content-Length.
+	assert.Equal(t, int64(16), sink.Samples()[0].BytesReceived)
+	content, err := ioutil.ReadAll(output.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "streamed content", string(content))
+	output.Body.Close()
+}
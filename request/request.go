@@ -27,6 +27,7 @@ import (
 	"github.com/pengsrc/go-shared/convert"
 	"go.uber.org/zap"
 
+	"github.com/qingstor/qingstor-sdk-go/v4/credentials"
 	"github.com/qingstor/qingstor-sdk-go/v4/log"
 	"github.com/qingstor/qingstor-sdk-go/v4/request/builder"
 	"github.com/qingstor/qingstor-sdk-go/v4/request/data"
@@ -44,6 +45,21 @@ type Request struct {
 	HTTPRequest signer.CanonicalReq
 
 	HTTPResponse *http.Response
+
+	// Handlers run the phases of this request, modify them to inject behavior.
+	Handlers *Handlers
+
+	// Attempts is the count of attempts made by SendWithContext.
+	Attempts int
+
+	// Logger is used if no logger was set in the context given per call,
+	// the logger returned by log.Default is used if it's nil.
+	Logger *zap.Logger
+
+	uploadDigest *uploadDigest
+
+	// sent and received count the body bytes of the current attempt.
+	sent, received *byteCounter
 }
 
 // New create a Request from given Operation, Input and Output.
@@ -62,16 +78,82 @@ func New(o *data.Operation, i data.Input, x interface{}) (*Request, error) {
 		Operation: o,
 		Input:     &input,
 		Output:    &output,
+		Handlers:  NewHandlersWithConfig(o.Config),
 	}, nil
 }
 
 // SendWithContext sends API request with given ctx.
+// Failed attempts are retried according to the RetrySettings.
 // It returns error if error occurred.
 func (r *Request) SendWithContext(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	ctx = log.ContextWithDefaultLogger(ctx, r.Logger)
+
+	ctx, span := r.startSpan(ctx)
+	m := newMeasurement()
+
+	err := r.sendWithRetry(ctx, m)
+
+	m.observe(r, err)
+	r.endSpan(span, err)
+	return err
+}
+
+// sendWithRetry sends API request and retries failed attempts.
+func (r *Request) sendWithRetry(ctx context.Context, m *measurement) error {
+	logger := log.FromContext(ctx)
+
+	settings := r.retrySettings(ctx)
+	body := &retryBody{}
+	if settings.MaxRetries > 0 {
+		var err error
+		body, err = newRetryBody(r, settings.MaxBufferSize)
+		if err != nil {
+			return err
+		}
+		defer body.restore()
+	}
+
+	r.Attempts = 0
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			err := WaitForRetry(ctx, settings, attempt)
+			if err != nil {
+				return errors.NewSDKError(
+					errors.WithAction("wait for retry in SendWithContext"),
+					errors.WithError(err),
+				)
+			}
+		}
+
+		r.Attempts++
+		r.sent, r.received = nil, nil
+		err := r.sendOnce(ctx)
+		m.addAttempt(r, err)
+		if err == nil {
+			return nil
+		}
+		if attempt >= settings.MaxRetries || !body.resendable || !r.isRetryable(err) {
+			return err
+		}
+		// The error of this attempt is more useful than why it can't be retried.
+		if body.rewind() != nil {
+			return err
+		}
+
+		logger.Warn("retry request",
+			zap.String("api", r.Operation.APIName),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+		r.discardResponse()
+	}
+}
 
+// sendOnce builds, signs, sends and unpacks the API request once.
+func (r *Request) sendOnce(ctx context.Context) error {
 	err := r.BuildWithContext(ctx)
 	if err != nil {
 		return err
@@ -90,6 +172,14 @@ func (r *Request) SendWithContext(ctx context.Context) error {
 	return nil
 }
 
+// discardResponse closes the response of a failed attempt.
+func (r *Request) discardResponse() {
+	if r.HTTPResponse != nil && r.HTTPResponse.Body != nil {
+		r.HTTPResponse.Body.Close()
+	}
+	r.HTTPResponse = nil
+}
+
 // Send sends API request.
 // It returns error if error occurred.
 // Deprecated: Use SendWithContext instead
@@ -103,13 +193,14 @@ func (r *Request) BuildWithContext(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	ctx = log.ContextWithDefaultLogger(ctx, r.Logger)
 
-	err := r.check(ctx)
+	err := r.handlers().Check.Run(ctx, r)
 	if err != nil {
 		return err
 	}
 
-	err = r.build(ctx)
+	err = r.handlers().Build.Run(ctx, r)
 	if err != nil {
 		return err
 	}
@@ -130,13 +221,14 @@ func (r *Request) DoWithContext(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	ctx = log.ContextWithDefaultLogger(ctx, r.Logger)
 
-	err := r.send(ctx)
+	err := r.handlers().Send.Run(ctx, r)
 	if err != nil {
 		return err
 	}
 
-	err = r.unpack(ctx)
+	err = r.handlers().Unpack.Run(ctx, r)
 	if err != nil {
 		return err
 	}
@@ -156,12 +248,11 @@ func (r *Request) SignWithContext(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	ctx = log.ContextWithDefaultLogger(ctx, r.Logger)
 
-	if r.Operation.Config.AccessKeyID != "" && r.Operation.Config.SecretAccessKey != "" {
-		err := r.sign(ctx)
-		if err != nil {
-			return err
-		}
+	err := r.handlers().Sign.Run(ctx, r)
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -185,6 +276,17 @@ func (r *Request) SignQuery(timeoutSeconds int) error {
 	return nil
 }
 
+// SignQueryUntil sign the API request by appending query string, the signature expires at given time.
+// It returns error if error occurred.
+func (r *Request) SignQueryUntil(expires time.Time) error {
+	err := r.signQuery(int(expires.Unix()))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ApplySignature applies the Authorization header.
 // It returns error if error occurred.
 func (r *Request) ApplySignature(authorization string) error {
@@ -204,7 +306,20 @@ func (r *Request) ApplyQuerySignature(accessKeyID string, expires int, signature
 	return nil
 }
 
+// handlers returns the handlers of the request, a request not created by New
+// runs the builtin handlers and the handlers installed by its config.
+func (r *Request) handlers() *Handlers {
+	if r.Handlers == nil {
+		r.Handlers = NewHandlersWithConfig(r.Operation.Config)
+	}
+	return r.Handlers
+}
+
 func (r *Request) check(ctx context.Context) error {
+	if r.Operation.Config.CredentialsProvider != nil {
+		return nil
+	}
+
 	if r.Operation.Config.AccessKeyID == "" && r.Operation.Config.SecretAccessKey != "" {
 		return errors.NewSDKError(
 			errors.WithAction("check request"),
@@ -236,15 +351,27 @@ func (r *Request) build(ctx context.Context) error {
 		r.HTTPRequest = signer.CanonicalReqByVhost(req, retBucket)
 	}
 
+	if r.Operation.Config.EnableIntegrityCheck {
+		return r.prepareIntegrityCheck()
+	}
 	return nil
 }
 
 func (r *Request) sign(ctx context.Context) error {
+	v, err := r.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	// Anonymous request is sent without signature.
+	if !v.HasKeys() {
+		return nil
+	}
+
 	s := &signer.QingStorSigner{
-		AccessKeyID:     r.Operation.Config.AccessKeyID,
-		SecretAccessKey: r.Operation.Config.SecretAccessKey,
+		AccessKeyID:     v.AccessKeyID,
+		SecretAccessKey: v.SecretAccessKey,
 	}
-	err := s.WriteSignature(r.HTTPRequest)
+	err = s.WriteSignature(r.HTTPRequest)
 	if err != nil {
 		return err
 	}
@@ -253,11 +380,22 @@ func (r *Request) sign(ctx context.Context) error {
 }
 
 func (r *Request) signQuery(expires int) error {
+	v, err := r.credentials(r.HTTPRequest.Context())
+	if err != nil {
+		return err
+	}
+	if !v.HasKeys() {
+		return errors.NewSDKError(
+			errors.WithAction("sign query"),
+			errors.WithError(fmt.Errorf("credentials not provided")),
+		)
+	}
+
 	s := &signer.QingStorSigner{
-		AccessKeyID:     r.Operation.Config.AccessKeyID,
-		SecretAccessKey: r.Operation.Config.SecretAccessKey,
+		AccessKeyID:     v.AccessKeyID,
+		SecretAccessKey: v.SecretAccessKey,
 	}
-	err := s.WriteQuerySignature(r.HTTPRequest, expires)
+	err = s.WriteQuerySignature(r.HTTPRequest, expires)
 	if err != nil {
 		return err
 	}
@@ -265,6 +403,32 @@ func (r *Request) signQuery(expires int) error {
 	return nil
 }
 
+// credentials retrieves the credentials to sign the request, the session token
+// of temporary credentials is set into header to be signed along.
+func (r *Request) credentials(ctx context.Context) (credentials.Value, error) {
+	v, err := r.Operation.Config.Credentials(ctx)
+	if err != nil {
+		return credentials.Value{}, errors.NewSDKError(
+			errors.WithAction("retrieve credentials"),
+			errors.WithError(err),
+		)
+	}
+	if v.HasKeys() && v.SessionToken != "" {
+		r.HTTPRequest.Header.Set(credentials.SessionTokenHeader, v.SessionToken)
+	}
+	return v, nil
+}
+
+type operationKey struct{}
+
+// OperationFromRequest returns the operation sending req, it's nil if req is
+// not sent by this SDK. The transports of Config.Connection can use it to
+// tell operations apart.
+func OperationFromRequest(req *http.Request) *data.Operation {
+	o, _ := req.Context().Value(operationKey{}).(*data.Operation)
+	return o
+}
+
 func (r *Request) send(ctx context.Context) error {
 	logger := log.FromContext(ctx)
 	var resp *http.Response
@@ -280,7 +444,15 @@ func (r *Request) send(ctx context.Context) error {
 		zap.String("host", r.HTTPRequest.Host),
 	)
 
-	resp, err = r.Operation.Config.Connection.Do(r.HTTPRequest.Request)
+	r.dumpRequest(ctx)
+
+	req := r.HTTPRequest.Request
+	req = req.WithContext(context.WithValue(req.Context(), operationKey{}, r.Operation))
+	if req.Body != nil && req.Body != http.NoBody {
+		r.sent = &byteCounter{ReadCloser: req.Body}
+		req.Body = r.sent
+	}
+	resp, err = r.Operation.Config.Connection.Do(req)
 	if err != nil {
 		return errors.NewSDKError(
 			errors.WithAction("do request in send"),
@@ -289,15 +461,30 @@ func (r *Request) send(ctx context.Context) error {
 	}
 
 	r.HTTPResponse = resp
+	r.dumpResponse(ctx)
+	if resp.Body != nil && resp.Body != http.NoBody {
+		r.received = &byteCounter{ReadCloser: resp.Body}
+		resp.Body = r.received
+	}
 
 	return nil
 }
 
 func (r *Request) unpack(ctx context.Context) error {
+	if r.HTTPResponse == nil {
+		return errors.NewSDKError(
+			errors.WithAction("unpack response"),
+			errors.WithError(fmt.Errorf("HTTPResponse must be set by the Send phase")),
+		)
+	}
+
 	err := response.UnpackToOutputWithContext(ctx, r.Operation, r.HTTPResponse, r.Output)
 	if err != nil {
 		return err
 	}
 
+	if r.Operation.Config.EnableIntegrityCheck {
+		return r.checkIntegrity()
+	}
 	return nil
 }
@@ -21,6 +21,7 @@ import (
 	"context"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -170,3 +171,37 @@ func TestAnonymousAPICall(t *testing.T) {
 	r.SendWithContext(nil)
 	assert.Equal(t, r.HTTPRequest.Header.Get("Authorization"), "")
 }
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestOperationFromRequest(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	var sent *data.Operation
+	operation := newTestOperation(t, server)
+	operation.Config.Connection = &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sent = OperationFromRequest(req)
+			return &http.Response{
+				StatusCode: 201,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	r, err := New(operation, &SomeActionInput{}, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, operation, sent)
+
+	req, _ := http.NewRequest("GET", "http://qingstor.com", nil)
+	assert.Nil(t, OperationFromRequest(req))
+}
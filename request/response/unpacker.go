@@ -34,6 +34,7 @@ import (
 	"github.com/qingstor/qingstor-sdk-go/v4/log"
 	"github.com/qingstor/qingstor-sdk-go/v4/request/data"
 	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+	"github.com/qingstor/qingstor-sdk-go/v4/utils"
 )
 
 // unpacker is the response unpacker for QingStor service.
@@ -130,9 +131,9 @@ func (b *unpacker) parseResponseHeaders(ctx context.Context) error {
 
 	requestID := b.resp.Header.Get(http.CanonicalHeaderKey("X-QS-Request-ID"))
 	logger := log.FromContext(ctx)
-	logger.Info("QingStor response header",
+	logger.Debug("QingStor response header",
 		zap.Int64("date", convert.StringToTimestamp(b.resp.Header.Get("Date"), convert.RFC822)),
-		zap.String("header", fmt.Sprint(b.resp.Header)),
+		zap.String("header", fmt.Sprint(utils.RedactHeader(b.resp.Header))),
 	)
 
 	fields := b.output.Elem()
@@ -240,7 +241,7 @@ func (b *unpacker) parseResponseBody(ctx context.Context) error {
 				)
 			}
 
-			logger.Info("QingStor response body",
+			logger.Debug("QingStor response body",
 				zap.Int64("date", convert.StringToTimestamp(b.resp.Header.Get("Date"), convert.RFC822)),
 				zap.ByteString("body", buffer.Bytes()),
 			)
@@ -292,7 +293,7 @@ func (b *unpacker) parseResponseElements(ctx context.Context) error {
 		return nil
 	}
 
-	logger.Info("QingStor response body",
+	logger.Debug("QingStor response body",
 		zap.Int64("date", convert.StringToTimestamp(b.resp.Header.Get("Date"), convert.RFC822)),
 		zap.ByteString("body", buffer.Bytes()),
 	)
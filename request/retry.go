@@ -0,0 +1,257 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	qsErrors "github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+)
+
+type retrySettingsKey struct{}
+
+// ContextWithRetrySettings set RetrySettings into given context and return,
+// requests sent with the returned context use it instead of Config.RetrySettings.
+func ContextWithRetrySettings(ctx context.Context, s config.RetrySettings) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, retrySettingsKey{}, s)
+}
+
+// retrySettings returns the retry settings for this request with given ctx.
+func (r *Request) retrySettings(ctx context.Context) config.RetrySettings {
+	if s, ok := ctx.Value(retrySettingsKey{}).(config.RetrySettings); ok {
+		return s
+	}
+	return r.Operation.Config.RetrySettings
+}
+
+// retryableStatusCodes are the response status codes worth retrying.
+var retryableStatusCodes = map[int]bool{
+	429: true, // Too many requests
+	500: true, // Internal server error
+	502: true, // Bad gateway
+	503: true, // Service unavailable
+	504: true, // Gateway timeout
+}
+
+// retryableErrorCodes are the QingStor error codes worth retrying.
+var retryableErrorCodes = map[string]bool{
+	"internal_error":      true,
+	"service_unavailable": true,
+}
+
+// nonIdempotentOperations are the operations which may take effect twice if
+// they are sent again after the server failed, so their 5xx responses are not
+// retried.
+var nonIdempotentOperations = map[string]bool{
+	"Append Object":             true,
+	"Complete multipart upload": true,
+}
+
+// IsRetryable checks whether the error returned by a request is worth retrying.
+// Network errors are retried only if the connection was reset, timed out or
+// closed in the middle of a response.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	switch e := err.(type) {
+	case *qsErrors.QingStorError:
+		return retryableStatusCodes[e.StatusCode] || retryableErrorCodes[e.Code]
+	case qsErrors.QingStorError:
+		return retryableStatusCodes[e.StatusCode] || retryableErrorCodes[e.Code]
+	case qsErrors.UnhandledResponseError:
+		return retryableStatusCodes[e.StatusCode]
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isRetryable checks whether the error returned by an attempt of r is worth
+// retrying, server errors of non-idempotent operations are not.
+func (r *Request) isRetryable(err error) bool {
+	if !IsRetryable(err) {
+		return false
+	}
+	return !nonIdempotentOperations[r.Operation.APIName] || statusCode(err) < 500
+}
+
+// statusCode returns the status code of the response failing with err, or 0
+// if there is no response.
+func statusCode(err error) int {
+	switch e := err.(type) {
+	case *qsErrors.QingStorError:
+		return e.StatusCode
+	case qsErrors.QingStorError:
+		return e.StatusCode
+	case qsErrors.UnhandledResponseError:
+		return e.StatusCode
+	}
+	return 0
+}
+
+// retryDelay returns the delay before the given attempt, which grows
+// exponentially and is jittered into [delay/2, delay).
+func retryDelay(s config.RetrySettings, attempt int) time.Duration {
+	delay := s.MinBackoff
+	for i := 1; i < attempt && (s.MaxBackoff <= 0 || delay < s.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if s.MaxBackoff > 0 && delay > s.MaxBackoff {
+		delay = s.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// WaitForRetry waits for the backoff before the given attempt, which starts
+// from 1 for the first retry. It returns early with the error of ctx if ctx
+// is done, so retry loops out of SendWithContext back off the same way.
+func WaitForRetry(ctx context.Context, s config.RetrySettings, attempt int) error {
+	return sleepWithContext(ctx, retryDelay(s, attempt))
+}
+
+// sleepWithContext waits for d, it returns early with the error of ctx if ctx is done.
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+
+// retryBody keeps the input body resendable across attempts.
+type retryBody struct {
+	field    reflect.Value
+	original io.Reader
+
+	seeker io.Seeker
+	offset int64
+
+	// resendable is false if the body could only be sent once.
+	resendable bool
+}
+
+// newRetryBody prepares the input body of r for resending.
+// Seekable body will be rewound to its current offset before every retry and
+// is not closed by sending, non-seekable body no larger than limit will be
+// buffered in memory.
+func newRetryBody(r *Request, limit int64) (*retryBody, error) {
+	b := &retryBody{resendable: true}
+
+	if r.Input == nil || !r.Input.IsValid() ||
+		r.Input.Kind() != reflect.Ptr || r.Input.IsNil() {
+		return b, nil
+	}
+	field := r.Input.Elem().FieldByName("Body")
+	if !field.IsValid() || field.Type() != readerType || field.IsNil() {
+		return b, nil
+	}
+
+	body := field.Interface().(io.Reader)
+	if s, ok := body.(io.ReadSeeker); ok {
+		offset, err := s.Seek(0, io.SeekCurrent)
+		if err == nil {
+			b.seeker = s
+			b.offset = offset
+			// net/http closes the body after sending, which makes closable
+			// body such as *os.File unable to be rewound.
+			if _, ok := body.(io.Closer); ok {
+				b.field = field
+				b.original = body
+				field.Set(reflect.ValueOf(nopCloseReadSeeker{s}))
+			}
+			return b, nil
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	_, err := io.Copy(buffer, io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, qsErrors.NewSDKError(
+			qsErrors.WithAction("buffer body in newRetryBody"),
+			qsErrors.WithError(err),
+		)
+	}
+
+	b.field = field
+	b.original = body
+	if int64(buffer.Len()) <= limit {
+		b.seeker = bytes.NewReader(buffer.Bytes())
+		field.Set(reflect.ValueOf(b.seeker))
+	} else {
+		b.resendable = false
+		field.Set(reflect.ValueOf(io.MultiReader(buffer, body)))
+	}
+	return b, nil
+}
+
+// nopCloseReadSeeker hides the Close method of body from net/http.
+type nopCloseReadSeeker struct {
+	io.ReadSeeker
+}
+
+// rewind moves the body back to where the first attempt started.
+func (b *retryBody) rewind() error {
+	if b.seeker == nil {
+		return nil
+	}
+	_, err := b.seeker.Seek(b.offset, io.SeekStart)
+	if err != nil {
+		return qsErrors.NewSDKError(
+			qsErrors.WithAction("rewind body in retryBody"),
+			qsErrors.WithError(err),
+		)
+	}
+	return nil
+}
+
+// restore puts the original body back into the input.
+func (b *retryBody) restore() {
+	if b.field.IsValid() {
+		b.field.Set(reflect.ValueOf(&b.original).Elem())
+	}
+}
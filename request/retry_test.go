@@ -0,0 +1,307 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/data"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+)
+
+type SomeBodyInput struct {
+	ContentLength *int64    `json:"Content-Length" name:"Content-Length" location:"headers"`
+	Body          io.Reader `location:"body"`
+}
+
+func (s *SomeBodyInput) Validate() error {
+	return nil
+}
+
+func newTestOperation(t *testing.T, server *httptest.Server) *data.Operation {
+	u, err := url.Parse(server.URL)
+	assert.Nil(t, err)
+	port, err := strconv.Atoi(u.Port())
+	assert.Nil(t, err)
+
+	conf, err := config.New("ACCESS_KEY_ID", "SECRET_ACCESS_KEY")
+	assert.Nil(t, err)
+	conf.Protocol = "http"
+	conf.Host = u.Hostname()
+	conf.Port = port
+	conf.RetrySettings.MinBackoff = time.Millisecond
+	conf.RetrySettings.MaxBackoff = time.Millisecond * 5
+
+	return &data.Operation{
+		Config: conf,
+		Properties: &SomeActionProperties{
+			A:  String("aaa"),
+			B:  String("bbb"),
+			CD: String("ccc-ddd"),
+		},
+		APIName:       "Some Action",
+		RequestMethod: "PUT",
+		RequestURI:    "/<a>/<b>/<c-d>",
+		StatusCodes: []int{
+			201, // Created
+		},
+	}
+}
+
+func newFlakyServer(failures int, bodies *[]string) *httptest.Server {
+	count := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(content))
+
+		w.Header().Set("X-QS-Request-ID", "test")
+		count++
+		if count <= failures {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(503)
+			fmt.Fprint(w, `{"code":"service_unavailable","request_id":"test"}`)
+			return
+		}
+		w.WriteHeader(201)
+	}))
+}
+
+func TestRequestRetry(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(2, &bodies)
+	defer server.Close()
+
+	output := &SomeActionOutput{}
+	r, err := New(newTestOperation(t, server), &SomeBodyInput{
+		Body: strings.NewReader("content"),
+	}, output)
+	assert.Nil(t, err)
+
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 201, *output.StatusCode)
+	assert.Equal(t, []string{"content", "content", "content"}, bodies)
+}
+
+func TestRequestRetryFileBody(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	f, err := ioutil.TempFile("", "qingstor-sdk-go-retry")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	_, err = f.WriteString("content")
+	assert.Nil(t, err)
+	_, err = f.Seek(0, io.SeekStart)
+	assert.Nil(t, err)
+
+	input := &SomeBodyInput{Body: f}
+	r, err := New(newTestOperation(t, server), input, &SomeActionOutput{})
+	assert.Nil(t, err)
+
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, r.Attempts)
+	assert.Equal(t, []string{"content", "content"}, bodies)
+	assert.Equal(t, f, input.Body)
+}
+
+func TestRequestRetryRewindFailed(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	body := &failingSeeker{Reader: strings.NewReader("content")}
+	r, err := New(newTestOperation(t, server), &SomeBodyInput{Body: body}, &SomeActionOutput{})
+	assert.Nil(t, err)
+
+	err = r.SendWithContext(context.Background())
+	e, ok := err.(*errors.QingStorError)
+	assert.True(t, ok)
+	assert.Equal(t, "service_unavailable", e.Code)
+	assert.Equal(t, 1, r.Attempts)
+}
+
+// failingSeeker fails to seek after it has been read.
+type failingSeeker struct {
+	*strings.Reader
+	read bool
+}
+
+func (s *failingSeeker) Read(p []byte) (int, error) {
+	s.read = true
+	return s.Reader.Read(p)
+}
+
+func (s *failingSeeker) Seek(offset int64, whence int) (int64, error) {
+	if s.read {
+		return 0, fmt.Errorf("seek failed")
+	}
+	return s.Reader.Seek(offset, whence)
+}
+
+func TestRequestRetryNonSeekableBody(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	input := &SomeBodyInput{
+		Body: ioutil.NopCloser(strings.NewReader("content")),
+	}
+	body := input.Body
+	r, err := New(newTestOperation(t, server), input, &SomeActionOutput{})
+	assert.Nil(t, err)
+
+	err = r.SendWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"content", "content"}, bodies)
+	assert.Equal(t, body, input.Body)
+}
+
+func TestRequestRetryBodyTooLarge(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	operation := newTestOperation(t, server)
+	operation.Config.RetrySettings.MaxBufferSize = 4
+	r, err := New(operation, &SomeBodyInput{
+		ContentLength: Int64(7),
+		Body:          ioutil.NopCloser(strings.NewReader("content")),
+	}, &SomeActionOutput{})
+	assert.Nil(t, err)
+
+	err = r.SendWithContext(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"content"}, bodies)
+}
+
+func TestRequestRetryExhausted(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(10, &bodies)
+	defer server.Close()
+
+	r, err := New(newTestOperation(t, server), nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+
+	ctx := ContextWithRetrySettings(context.Background(), config.RetrySettings{
+		MaxRetries: 1,
+	})
+	err = r.SendWithContext(ctx)
+	e, ok := err.(*errors.QingStorError)
+	assert.True(t, ok)
+	assert.Equal(t, "service_unavailable", e.Code)
+	assert.Equal(t, 2, len(bodies))
+}
+
+func TestRequestRetryNonIdempotent(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(2, &bodies)
+	defer server.Close()
+
+	o := newTestOperation(t, server)
+	o.APIName = "Append Object"
+	r, err := New(o, &SomeBodyInput{
+		Body: strings.NewReader("content"),
+	}, &SomeActionOutput{})
+	assert.Nil(t, err)
+
+	// The content may have been appended by the failed attempt.
+	err = r.SendWithContext(context.Background())
+	assert.Equal(t, 503, err.(*errors.QingStorError).StatusCode)
+	assert.Equal(t, []string{"content"}, bodies)
+}
+
+func TestRequestRetryCanceled(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(10, &bodies)
+	defer server.Close()
+
+	operation := newTestOperation(t, server)
+	operation.Config.RetrySettings.MinBackoff = time.Hour
+	operation.Config.RetrySettings.MaxBackoff = time.Hour
+	r, err := New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err = r.SendWithContext(ctx)
+	assert.NotNil(t, err)
+	assert.True(t, ctx.Err() != nil)
+	assert.Equal(t, 1, len(bodies))
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{&errors.QingStorError{StatusCode: 503}, true},
+		{&errors.QingStorError{StatusCode: 500, Code: "internal_error"}, true},
+		{&errors.QingStorError{StatusCode: 404, Code: "object_not_exists"}, false},
+		{errors.NewUnhandledResponseError(errors.WithStatusCode(502)), true},
+		{errors.NewUnhandledResponseError(errors.WithStatusCode(400)), false},
+		{errors.NewSDKError(errors.WithError(&net.OpError{Op: "read", Err: syscall.ECONNRESET})), true},
+		{errors.NewSDKError(errors.WithError(&url.Error{Op: "Put", Err: io.ErrUnexpectedEOF})), true},
+		{errors.NewSDKError(errors.WithError(&url.Error{Op: "Put", Err: io.EOF})), false},
+		{errors.NewSDKError(errors.WithError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED})), false},
+		{errors.NewSDKError(errors.WithError(&net.OpError{Op: "write", Err: syscall.EPIPE})), false},
+		{errors.NewSDKError(errors.WithError(&net.DNSError{Err: "i/o timeout", IsTimeout: true})), true},
+		{errors.NewSDKError(errors.WithError(&net.DNSError{Err: "no such host", IsNotFound: true})), false},
+		{errors.NewSDKError(errors.WithError(context.Canceled)), false},
+		{errors.NewSDKError(errors.WithError(fmt.Errorf("cannot get Content-Length"))), false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, IsRetryable(c.err), fmt.Sprint(c.err))
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	s := config.RetrySettings{
+		MinBackoff: time.Millisecond * 100,
+		MaxBackoff: time.Millisecond * 300,
+	}
+	for attempt := 1; attempt < 10; attempt++ {
+		d := retryDelay(s, attempt)
+		assert.True(t, d >= time.Millisecond*50)
+		assert.True(t, d <= time.Millisecond*300)
+	}
+	assert.True(t, retryDelay(s, 1) <= time.Millisecond*100)
+}
+
+func Int64(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,167 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+)
+
+// PostPolicy is the policy document of browser form POST upload, it limits
+// what the form is allowed to upload.
+type PostPolicy struct {
+	bucket     string
+	expiration time.Time
+	conditions []interface{}
+	fields     map[string]string
+}
+
+// NewPostPolicy creates a PostPolicy for uploading to bucket before expiration.
+func NewPostPolicy(bucket string, expiration time.Time) *PostPolicy {
+	return &PostPolicy{
+		bucket:     bucket,
+		expiration: expiration,
+		conditions: []interface{}{map[string]string{"bucket": bucket}},
+		fields:     map[string]string{},
+	}
+}
+
+// Bucket returns the bucket the policy allows to upload to.
+func (p *PostPolicy) Bucket() string {
+	return p.bucket
+}
+
+// Expiration returns the time the policy expires at.
+func (p *PostPolicy) Expiration() time.Time {
+	return p.expiration
+}
+
+// SetKey only allows to upload the object with given key.
+func (p *PostPolicy) SetKey(key string) error {
+	if key == "" {
+		return newPostPolicyError(fmt.Errorf("key is empty"))
+	}
+	p.conditions = append(p.conditions, []string{"eq", "$key", key})
+	p.fields["key"] = key
+	return nil
+}
+
+// SetKeyStartsWith only allows to upload objects whose key starts with prefix.
+// The key field is set to prefix followed by "${filename}", which is replaced
+// with the name of the uploaded file, change the key field in form if needed.
+func (p *PostPolicy) SetKeyStartsWith(prefix string) error {
+	p.conditions = append(p.conditions, []string{"starts-with", "$key", prefix})
+	p.fields["key"] = prefix + "${filename}"
+	return nil
+}
+
+// SetContentLengthRange limits the size of the uploaded object in bytes.
+func (p *PostPolicy) SetContentLengthRange(min, max int64) error {
+	if min < 0 || min > max {
+		return newPostPolicyError(fmt.Errorf("invalid content length range [%d, %d]", min, max))
+	}
+	p.conditions = append(p.conditions, []interface{}{"content-length-range", min, max})
+	return nil
+}
+
+// SetContentType only allows to upload the object with given content type.
+func (p *PostPolicy) SetContentType(contentType string) error {
+	if contentType == "" {
+		return newPostPolicyError(fmt.Errorf("content type is empty"))
+	}
+	p.conditions = append(p.conditions, []string{"eq", "$Content-Type", contentType})
+	p.fields["Content-Type"] = contentType
+	return nil
+}
+
+// SetContentTypeStartsWith only allows to upload objects whose content type starts with prefix,
+// such as "image/".
+func (p *PostPolicy) SetContentTypeStartsWith(prefix string) error {
+	p.conditions = append(p.conditions, []string{"starts-with", "$Content-Type", prefix})
+	if prefix != "" {
+		p.fields["Content-Type"] = prefix
+	}
+	return nil
+}
+
+// SetMetadata requires the uploaded object to have given metadata, key is the
+// name without the "x-qs-meta-" prefix.
+func (p *PostPolicy) SetMetadata(key, value string) error {
+	if key == "" {
+		return newPostPolicyError(fmt.Errorf("metadata key is empty"))
+	}
+	name := "x-qs-meta-" + strings.ToLower(key)
+	p.conditions = append(p.conditions, []string{"eq", "$" + name, value})
+	p.fields[name] = value
+	return nil
+}
+
+// Marshal encodes the policy into JSON.
+func (p *PostPolicy) Marshal() ([]byte, error) {
+	if p.expiration.IsZero() {
+		return nil, newPostPolicyError(fmt.Errorf("expiration not set"))
+	}
+	if _, ok := p.fields["key"]; !ok {
+		return nil, newPostPolicyError(fmt.Errorf("key condition not set"))
+	}
+
+	return json.Marshal(struct {
+		Expiration string        `json:"expiration"`
+		Conditions []interface{} `json:"conditions"`
+	}{
+		Expiration: p.expiration.UTC().Format("2006-01-02T15:04:05.000Z"),
+		Conditions: p.conditions,
+	})
+}
+
+// SignPostPolicy signs the policy, it returns the form fields of the upload,
+// which contain key, policy, signature, access_key_id and the fields required by conditions.
+// The file to upload must be the last field of the form.
+func (qss *QingStorSigner) SignPostPolicy(p *PostPolicy) (map[string]string, error) {
+	content, err := p.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	policy := base64.StdEncoding.EncodeToString(content)
+
+	h := hmac.New(sha256.New, []byte(qss.SecretAccessKey))
+	h.Write([]byte(policy))
+	signature := strings.TrimSpace(base64.StdEncoding.EncodeToString(h.Sum(nil)))
+
+	fields := make(map[string]string, len(p.fields)+3)
+	for k, v := range p.fields {
+		fields[k] = v
+	}
+	fields["policy"] = policy
+	fields["signature"] = signature
+	fields["access_key_id"] = qss.AccessKeyID
+	return fields, nil
+}
+
+func newPostPolicyError(err error) error {
+	return errors.NewSDKError(
+		errors.WithAction("build post policy"),
+		errors.WithError(err),
+	)
+}
@@ -0,0 +1,84 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostPolicy(t *testing.T) {
+	expiration := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := NewPostPolicy("bucket", expiration)
+	assert.Nil(t, p.SetKeyStartsWith("uploads/"))
+	assert.Nil(t, p.SetContentLengthRange(1, 1024))
+	assert.Nil(t, p.SetContentTypeStartsWith("image/"))
+	assert.Nil(t, p.SetMetadata("User", "test"))
+
+	content, err := p.Marshal()
+	assert.Nil(t, err)
+	assert.Equal(t,
+		`{"expiration":"2020-01-02T03:04:05.000Z","conditions":[{"bucket":"bucket"},`+
+			`["starts-with","$key","uploads/"],["content-length-range",1,1024],`+
+			`["starts-with","$Content-Type","image/"],["eq","$x-qs-meta-user","test"]]}`,
+		string(content),
+	)
+}
+
+func TestPostPolicyInvalid(t *testing.T) {
+	p := NewPostPolicy("bucket", time.Now())
+	assert.NotNil(t, p.SetKey(""))
+	assert.NotNil(t, p.SetContentLengthRange(10, 1))
+	assert.NotNil(t, p.SetContentType(""))
+	assert.NotNil(t, p.SetMetadata("", "value"))
+
+	_, err := p.Marshal()
+	assert.NotNil(t, err)
+
+	_, err = NewPostPolicy("bucket", time.Time{}).Marshal()
+	assert.NotNil(t, err)
+}
+
+func TestQingStorSignerSignPostPolicy(t *testing.T) {
+	p := NewPostPolicy("bucket", time.Now().Add(time.Hour))
+	assert.Nil(t, p.SetKey("key.jpg"))
+	assert.Nil(t, p.SetContentType("image/jpeg"))
+
+	s := QingStorSigner{
+		AccessKeyID:     "ENV_ACCESS_KEY_ID",
+		SecretAccessKey: "ENV_SECRET_ACCESS_KEY",
+	}
+	fields, err := s.SignPostPolicy(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "key.jpg", fields["key"])
+	assert.Equal(t, "image/jpeg", fields["Content-Type"])
+	assert.Equal(t, "ENV_ACCESS_KEY_ID", fields["access_key_id"])
+
+	content, err := base64.StdEncoding.DecodeString(fields["policy"])
+	assert.Nil(t, err)
+	assert.True(t, json.Valid(content))
+
+	h := hmac.New(sha256.New, []byte("ENV_SECRET_ACCESS_KEY"))
+	h.Write([]byte(fields["policy"]))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(h.Sum(nil)), fields["signature"])
+}
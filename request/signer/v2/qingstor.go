@@ -100,7 +100,7 @@ func (qss *QingStorSigner) BuildSignature(request CanonicalReq) (string, error)
 	authorization := "QS " + qss.AccessKeyID + ":" + signature
 
 	logger.Debug("build signature",
-		zap.String("qs_authorization", authorization),
+		zap.String("qs_authorization", "QS "+qss.AccessKeyID+":"+utils.Redacted),
 		zap.Int64("date", convert.StringToTimestamp(request.Header.Get("Date"), convert.RFC822)),
 	)
 
@@ -126,7 +126,10 @@ func (qss *QingStorSigner) BuildQuerySignature(request CanonicalReq, expires int
 	)
 
 	logger.Debug("build query signature",
-		zap.String("signature", query),
+		zap.String("signature", fmt.Sprintf(
+			"access_key_id=%s&expires=%d&signature=%s",
+			qss.AccessKeyID, expires, utils.Redacted,
+		)),
 		zap.Int64("date", convert.StringToTimestamp(request.Header.Get("Date"), convert.RFC822)),
 	)
 
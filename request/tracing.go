@@ -0,0 +1,62 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/tracing"
+)
+
+// startSpan starts the span of this request with the tracer in config,
+// the returned context carries the span.
+func (r *Request) startSpan(ctx context.Context) (context.Context, tracing.Span) {
+	attrs := []tracing.Attribute{
+		tracing.String(tracing.AttrOperation, r.Operation.APIName),
+	}
+	if bucket := r.property("bucket-name"); bucket != "" {
+		attrs = append(attrs, tracing.String(tracing.AttrBucket, bucket))
+	}
+	if zone := r.property("zone"); zone != "" {
+		attrs = append(attrs, tracing.String(tracing.AttrZone, zone))
+	}
+	if key := r.property("object-key"); key != "" {
+		attrs = append(attrs, tracing.String(tracing.AttrKey, key))
+	}
+
+	return tracing.Start(ctx, r.Operation.Config.Tracer, r.Operation.APIName, attrs...)
+}
+
+// endSpan sets the result of this request into span and ends it.
+func (r *Request) endSpan(span tracing.Span, err error) {
+	attrs := []tracing.Attribute{
+		tracing.Int(tracing.AttrAttempts, r.Attempts),
+	}
+	if r.HTTPResponse != nil {
+		attrs = append(attrs,
+			tracing.Int(tracing.AttrStatusCode, r.HTTPResponse.StatusCode),
+			tracing.String(tracing.AttrRequestID,
+				r.HTTPResponse.Header.Get(http.CanonicalHeaderKey("X-QS-Request-ID"))),
+		)
+	}
+	span.SetAttributes(attrs...)
+	if err != nil {
+		span.SetError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,83 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package request
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/tracing"
+)
+
+func TestRequestTracing(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	recorder := tracing.NewRecorder()
+	operation := newTestOperation(t, server)
+	operation.Config.Tracer = recorder
+	operation.Properties = &SomeBucketProperties{
+		BucketName: String("bucket"),
+		Zone:       String(""),
+	}
+	operation.RequestURI = "/<bucket-name>"
+
+	ctx, parent := recorder.Start(context.Background(), "parent")
+	r, err := New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(ctx)
+	assert.Nil(t, err)
+	parent.End()
+
+	spans := recorder.Spans()
+	assert.Equal(t, 2, len(spans))
+	s := spans[1]
+	assert.Equal(t, spans[0].ID, s.ParentID)
+	assert.Equal(t, "Some Action", s.Name)
+	assert.Equal(t, "Some Action", s.Attributes[tracing.AttrOperation])
+	assert.Equal(t, "bucket", s.Attributes[tracing.AttrBucket])
+	assert.Equal(t, "test", s.Attributes[tracing.AttrRequestID])
+	assert.Equal(t, 2, s.Attributes[tracing.AttrAttempts])
+	assert.Equal(t, 201, s.Attributes[tracing.AttrStatusCode])
+	assert.Nil(t, s.Err)
+	assert.False(t, s.End.IsZero())
+}
+
+func TestRequestTracingError(t *testing.T) {
+	var bodies []string
+	server := newFlakyServer(1, &bodies)
+	defer server.Close()
+
+	recorder := tracing.NewRecorder()
+	operation := newTestOperation(t, server)
+	operation.Config.Tracer = recorder
+	operation.Config.RetrySettings.MaxRetries = 0
+
+	r, err := New(operation, nil, &SomeActionOutput{})
+	assert.Nil(t, err)
+	err = r.SendWithContext(context.Background())
+	assert.NotNil(t, err)
+
+	spans := recorder.Spans()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, err, spans[0].Err)
+	assert.Equal(t, 503, spans[0].Attributes[tracing.AttrStatusCode])
+	assert.Equal(t, "test", spans[0].Attributes[tracing.AttrRequestID])
+}
@@ -24,6 +24,8 @@ import (
 	"strings"
 	"time"
 
+	"go.uber.org/zap"
+
 	"github.com/qingstor/qingstor-sdk-go/v4/config"
 	"github.com/qingstor/qingstor-sdk-go/v4/request"
 	"github.com/qingstor/qingstor-sdk-go/v4/request/data"
@@ -38,11 +40,18 @@ var _ strings.Reader
 var _ time.Time
 var _ config.Config
 var _ utils.Conn
+var _ zap.Logger
 
 // Bucket presents bucket.
 type Bucket struct {
 	Config     *config.Config
 	Properties *Properties
+
+	// Handlers are copied into every request created by this bucket.
+	Handlers *request.Handlers
+
+	// Logger is used by every request created by this bucket.
+	Logger *zap.Logger
 }
 
 // Bucket initializes a new bucket.
@@ -53,7 +62,7 @@ func (s *Service) Bucket(bucketName string, zone string) (*Bucket, error) {
 		Zone:       &zone,
 	}
 
-	return &Bucket{Config: s.Config, Properties: properties}, nil
+	return &Bucket{Config: s.Config, Properties: properties, Handlers: s.Handlers.Copy(), Logger: s.Logger}, nil
 }
 
 // Delete does Delete a bucket.
@@ -106,6 +115,10 @@ func (s *Bucket) DeleteRequest() (*request.Request, *DeleteBucketOutput, error)
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -171,6 +184,10 @@ func (s *Bucket) DeleteCNAMERequest(input *DeleteBucketCNAMEInput) (*request.Req
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -252,6 +269,10 @@ func (s *Bucket) DeleteCORSRequest() (*request.Request, *DeleteBucketCORSOutput,
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -313,6 +334,10 @@ func (s *Bucket) DeleteExternalMirrorRequest() (*request.Request, *DeleteBucketE
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -374,6 +399,10 @@ func (s *Bucket) DeleteLifecycleRequest() (*request.Request, *DeleteBucketLifecy
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -435,6 +464,10 @@ func (s *Bucket) DeleteLoggingRequest() (*request.Request, *DeleteBucketLoggingO
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -496,6 +529,10 @@ func (s *Bucket) DeleteNotificationRequest() (*request.Request, *DeleteBucketNot
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -557,6 +594,10 @@ func (s *Bucket) DeletePolicyRequest() (*request.Request, *DeleteBucketPolicyOut
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -618,6 +659,10 @@ func (s *Bucket) DeleteReplicationRequest() (*request.Request, *DeleteBucketRepl
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -683,6 +728,10 @@ func (s *Bucket) DeleteMultipleObjectsRequest(input *DeleteMultipleObjectsInput)
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -779,6 +828,10 @@ func (s *Bucket) GetACLRequest() (*request.Request, *GetBucketACLOutput, error)
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -849,6 +902,10 @@ func (s *Bucket) GetCNAMERequest(input *GetBucketCNAMEInput) (*request.Request,
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -948,6 +1005,10 @@ func (s *Bucket) GetCORSRequest() (*request.Request, *GetBucketCORSOutput, error
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1012,6 +1073,10 @@ func (s *Bucket) GetExternalMirrorRequest() (*request.Request, *GetBucketExterna
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1076,6 +1141,10 @@ func (s *Bucket) GetLifecycleRequest() (*request.Request, *GetBucketLifecycleOut
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1140,6 +1209,10 @@ func (s *Bucket) GetLoggingRequest() (*request.Request, *GetBucketLoggingOutput,
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1206,6 +1279,10 @@ func (s *Bucket) GetNotificationRequest() (*request.Request, *GetBucketNotificat
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1270,6 +1347,10 @@ func (s *Bucket) GetPolicyRequest() (*request.Request, *GetBucketPolicyOutput, e
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1334,6 +1415,10 @@ func (s *Bucket) GetReplicationRequest() (*request.Request, *GetBucketReplicatio
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1398,6 +1483,10 @@ func (s *Bucket) GetStatisticsRequest() (*request.Request, *GetBucketStatisticsO
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1475,6 +1564,10 @@ func (s *Bucket) HeadRequest() (*request.Request, *HeadBucketOutput, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1540,6 +1633,10 @@ func (s *Bucket) ListMultipartUploadsRequest(input *ListMultipartUploadsInput) (
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1646,6 +1743,10 @@ func (s *Bucket) ListObjectsRequest(input *ListObjectsInput) (*request.Request,
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1746,6 +1847,10 @@ func (s *Bucket) PutRequest() (*request.Request, *PutBucketOutput, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1811,6 +1916,10 @@ func (s *Bucket) PutACLRequest(input *PutBucketACLInput) (*request.Request, *Put
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1904,6 +2013,10 @@ func (s *Bucket) PutCNAMERequest(input *PutBucketCNAMEInput) (*request.Request,
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -2011,6 +2124,10 @@ func (s *Bucket) PutCORSRequest(input *PutBucketCORSInput) (*request.Request, *P
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -2104,6 +2221,10 @@ func (s *Bucket) PutExternalMirrorRequest(input *PutBucketExternalMirrorInput) (
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -2189,6 +2310,10 @@ func (s *Bucket) PutLifecycleRequest(input *PutBucketLifecycleInput) (*request.R
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -2282,6 +2407,10 @@ func (s *Bucket) PutLoggingRequest(input *PutBucketLoggingInput) (*request.Reque
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -2376,6 +2505,10 @@ func (s *Bucket) PutNotificationRequest(input *PutBucketNotificationInput) (*req
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -2469,6 +2602,10 @@ func (s *Bucket) PutPolicyRequest(input *PutBucketPolicyInput) (*request.Request
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -2562,6 +2699,10 @@ func (s *Bucket) PutReplicationRequest(input *PutBucketReplicationInput) (*reque
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
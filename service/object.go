@@ -24,6 +24,8 @@ import (
 	"strings"
 	"time"
 
+	"go.uber.org/zap"
+
 	"github.com/qingstor/qingstor-sdk-go/v4/config"
 	"github.com/qingstor/qingstor-sdk-go/v4/request"
 	"github.com/qingstor/qingstor-sdk-go/v4/request/data"
@@ -38,6 +40,7 @@ var _ strings.Reader
 var _ time.Time
 var _ config.Config
 var _ utils.Conn
+var _ zap.Logger
 
 // AbortMultipartUpload does Abort multipart upload.
 // Documentation URL: https://docsv4.qingcloud.com/user_guide/storage/object_storage/api/object/multipart/abort/
@@ -95,6 +98,10 @@ func (s *Bucket) AbortMultipartUploadRequest(objectKey string, input *AbortMulti
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -182,6 +189,10 @@ func (s *Bucket) AppendObjectRequest(objectKey string, input *AppendObjectInput)
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -297,6 +308,10 @@ func (s *Bucket) CompleteMultipartUploadRequest(objectKey string, input *Complet
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -410,6 +425,10 @@ func (s *Bucket) DeleteObjectRequest(objectKey string) (*request.Request, *Delet
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -480,6 +499,10 @@ func (s *Bucket) GetObjectRequest(objectKey string, input *GetObjectInput) (*req
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -623,6 +646,10 @@ func (s *Bucket) HeadObjectRequest(objectKey string, input *HeadObjectInput) (*r
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -733,6 +760,10 @@ func (s *Bucket) ImageProcessRequest(objectKey string, input *ImageProcessInput)
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -848,6 +879,10 @@ func (s *Bucket) InitiateMultipartUploadRequest(objectKey string, input *Initiat
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -975,6 +1010,10 @@ func (s *Bucket) ListMultipartRequest(objectKey string, input *ListMultipartInpu
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1073,6 +1112,10 @@ func (s *Bucket) OptionsObjectRequest(objectKey string, input *OptionsObjectInpu
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1182,6 +1225,10 @@ func (s *Bucket) PutObjectRequest(objectKey string, input *PutObjectInput) (*req
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -1362,6 +1409,10 @@ func (s *Bucket) UploadMultipartRequest(objectKey string, input *UploadMultipart
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -0,0 +1,241 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/internal/multipart"
+)
+
+// DefaultWriterPartSize is the part size of ObjectWriter if no part size was given.
+const DefaultWriterPartSize = 8 * 1024 * 1024
+
+// ObjectWriter uploads the data written to it as an object whose length is
+// unknown in advance. The first part is buffered in memory, if the writer is
+// closed before the part is full, the object is uploaded by PutObject,
+// otherwise a multipart upload is initiated and every full part is uploaded
+// during Write.
+//
+// ObjectWriter is not safe for concurrent use.
+type ObjectWriter struct {
+	bucket    *Bucket
+	ctx       context.Context
+	objectKey string
+	input     *PutObjectInput
+	partSize  int
+
+	buf      []byte
+	uploadID *string
+	parts    []*ObjectPartType
+	written  int64
+	err      error
+	closed   bool
+}
+
+// NewObjectWriter returns a writer which uploads the object objectKey.
+// The headers of object are taken from input, its Body and ContentLength
+// are ignored, input can be nil. The requests are sent with ctx.
+func (s *Bucket) NewObjectWriter(ctx context.Context, objectKey string, input *PutObjectInput) *ObjectWriter {
+	return s.NewObjectWriterWithPartSize(ctx, objectKey, input, DefaultWriterPartSize)
+}
+
+// NewObjectWriterWithPartSize is like NewObjectWriter, but buffers and
+// uploads partSize bytes per part. All parts except the last must be at least
+// 4 MiB, so partSize smaller than that only works for small objects.
+func (s *Bucket) NewObjectWriterWithPartSize(ctx context.Context, objectKey string, input *PutObjectInput, partSize int) *ObjectWriter {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if input == nil {
+		input = &PutObjectInput{}
+	}
+	if partSize <= 0 {
+		partSize = DefaultWriterPartSize
+	}
+	return &ObjectWriter{
+		bucket:    s,
+		ctx:       ctx,
+		objectKey: objectKey,
+		input:     input,
+		partSize:  partSize,
+	}
+}
+
+// Write buffers p, and uploads the buffered data once it reaches the part
+// size. An error returned by Write is returned by all following calls.
+func (w *ObjectWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n := 0
+	for len(p) > 0 {
+		size := w.partSize - len(w.buf)
+		if size > len(p) {
+			size = len(p)
+		}
+		if w.buf == nil {
+			w.buf = make([]byte, 0, w.partSize)
+		}
+		w.buf = append(w.buf, p[:size]...)
+		p = p[size:]
+		n += size
+		w.written += int64(size)
+
+		if len(w.buf) == w.partSize {
+			if w.err = w.flush(); w.err != nil {
+				return n, w.err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Written returns the count of bytes written.
+func (w *ObjectWriter) Written() int64 {
+	return w.written
+}
+
+// Close uploads the buffered data and completes the object. The object is
+// not visible until Close returns nil. If the upload failed, the multipart
+// upload is aborted.
+func (w *ObjectWriter) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err != nil {
+		w.abort()
+		return w.err
+	}
+
+	if w.uploadID == nil {
+		input := *w.input
+		input.ContentLength = Int64(int64(len(w.buf)))
+		input.Body = bytes.NewReader(w.buf)
+		_, w.err = w.bucket.PutObjectWithContext(w.ctx, w.objectKey, &input)
+		return w.err
+	}
+
+	if len(w.buf) > 0 {
+		if w.err = w.uploadPart(); w.err != nil {
+			w.abort()
+			return w.err
+		}
+	}
+	_, w.err = w.bucket.CompleteMultipartUploadWithContext(w.ctx, w.objectKey, &CompleteMultipartUploadInput{
+		UploadID:                       w.uploadID,
+		ObjectParts:                    w.parts,
+		XQSEncryptionCustomerAlgorithm: w.input.XQSEncryptionCustomerAlgorithm,
+		XQSEncryptionCustomerKey:       w.input.XQSEncryptionCustomerKey,
+		XQSEncryptionCustomerKeyMD5:    w.input.XQSEncryptionCustomerKeyMD5,
+	})
+	if w.err != nil {
+		w.abort()
+	}
+	return w.err
+}
+
+// CloseWithError closes the writer without completing the object, the
+// multipart upload is aborted if it was initiated. Following calls of Close
+// return err, or io.ErrClosedPipe if err is nil. The error of abort is
+// returned.
+func (w *ObjectWriter) CloseWithError(err error) error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+	w.err = err
+	w.buf = nil
+	return w.abort()
+}
+
+// flush uploads the full buffer, the multipart upload is initiated for the
+// first part.
+func (w *ObjectWriter) flush() error {
+	if w.uploadID == nil {
+		uploadID, err := w.initiate()
+		if err != nil {
+			return err
+		}
+		w.uploadID = uploadID
+	}
+	return w.uploadPart()
+}
+
+func (w *ObjectWriter) initiate() (*string, error) {
+	r, output, err := w.bucket.InitiateMultipartUploadRequest(w.objectKey, &InitiateMultipartUploadInput{
+		ContentType:                    w.input.ContentType,
+		XQSEncryptionCustomerAlgorithm: w.input.XQSEncryptionCustomerAlgorithm,
+		XQSEncryptionCustomerKey:       w.input.XQSEncryptionCustomerKey,
+		XQSEncryptionCustomerKeyMD5:    w.input.XQSEncryptionCustomerKeyMD5,
+		XQSMetaData:                    w.input.XQSMetaData,
+		XQSStorageClass:                w.input.XQSStorageClass,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.Handlers.Build.PushBack(multipart.InitiateHeaders(w.input.CacheControl, w.input.ContentEncoding))
+	if err = r.SendWithContext(w.ctx); err != nil {
+		return nil, err
+	}
+	return output.UploadID, nil
+}
+
+func (w *ObjectWriter) uploadPart() error {
+	partNumber := len(w.parts)
+	output, err := w.bucket.UploadMultipartWithContext(w.ctx, w.objectKey, &UploadMultipartInput{
+		UploadID:                       w.uploadID,
+		PartNumber:                     Int(partNumber),
+		ContentLength:                  Int64(int64(len(w.buf))),
+		XQSEncryptionCustomerAlgorithm: w.input.XQSEncryptionCustomerAlgorithm,
+		XQSEncryptionCustomerKey:       w.input.XQSEncryptionCustomerKey,
+		XQSEncryptionCustomerKeyMD5:    w.input.XQSEncryptionCustomerKeyMD5,
+		Body:                           bytes.NewReader(w.buf),
+	})
+	if err != nil {
+		return fmt.Errorf("upload part %d: %w", partNumber, err)
+	}
+	w.parts = append(w.parts, &ObjectPartType{PartNumber: Int(partNumber), Etag: output.ETag})
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// abort aborts the multipart upload even if the context of writer is canceled.
+func (w *ObjectWriter) abort() error {
+	if w.uploadID == nil {
+		return nil
+	}
+	err := multipart.Abort(w.ctx, func(ctx context.Context) error {
+		_, err := w.bucket.AbortMultipartUploadWithContext(ctx, w.objectKey, &AbortMultipartUploadInput{
+			UploadID: w.uploadID,
+		})
+		return err
+	})
+	w.uploadID = nil
+	return err
+}
@@ -0,0 +1,141 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/qingstortest"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// countRequests counts the requests to "key" of method, which have the query
+// parameter if it's not empty.
+func countRequests(server *qingstortest.Server, method, parameter string) int {
+	n := 0
+	for _, r := range server.Requests() {
+		_, ok := r.URL.Query()[parameter]
+		if r.Method == method && r.URL.Path == "/bucket/key" && (parameter == "" || ok) {
+			n++
+		}
+	}
+	return n
+}
+
+// assertAborted checks that no object is written and no upload is left.
+func assertAborted(t *testing.T, server *qingstortest.Server, bucket *service.Bucket) {
+	_, ok := server.Object("bucket", "key")
+	assert.False(t, ok)
+	uploads, err := bucket.ListMultipartUploads(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, uploads.Uploads)
+}
+
+func TestObjectWriterSmall(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+
+	content := qingstortest.Content(100)
+	w := bucket.NewObjectWriterWithPartSize(context.Background(), "key", nil, 1000)
+	_, err := io.Copy(w, bytes.NewBuffer(content))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+	assert.Equal(t, 1, countRequests(server, http.MethodPut, ""))
+	assert.Equal(t, 0, countRequests(server, http.MethodPost, "uploads"))
+	written, _ := server.Object("bucket", "key")
+	assert.Equal(t, content, written)
+	assert.Equal(t, int64(100), w.Written())
+}
+
+func TestObjectWriterMultipart(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+
+	content := qingstortest.Content(2500)
+	w := bucket.NewObjectWriterWithPartSize(context.Background(), "key", &service.PutObjectInput{
+		CacheControl: service.String("no-cache"),
+		ContentType:  service.String("text/plain"),
+		XQSMetaData:  &map[string]string{"x-qs-meta-origin": "pipe"},
+	}, 1000)
+	// Writes of different sizes across the boundary of parts.
+	for _, size := range []int{300, 900, 1, 1299} {
+		n, err := w.Write(content[w.Written() : w.Written()+int64(size)])
+		assert.Nil(t, err)
+		assert.Equal(t, size, n)
+	}
+	assert.Equal(t, 2, countRequests(server, http.MethodPut, "upload_id"))
+	assert.Nil(t, w.Close())
+
+	assert.Equal(t, 3, countRequests(server, http.MethodPut, ""))
+	assert.Equal(t, 3, countRequests(server, http.MethodPut, "upload_id"))
+	written, _ := server.Object("bucket", "key")
+	assert.Equal(t, content, written)
+	out, err := bucket.GetObject("key", nil)
+	assert.Nil(t, err)
+	out.Close()
+	assert.Equal(t, "no-cache", service.StringValue(out.CacheControl))
+	assert.Equal(t, "text/plain", service.StringValue(out.ContentType))
+	assert.Equal(t, "pipe", (*out.XQSMetaData)["x-qs-meta-origin"])
+
+	_, err = w.Write([]byte("closed"))
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
+
+func TestObjectWriterCloseWithError(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+
+	w := bucket.NewObjectWriterWithPartSize(context.Background(), "key", nil, 1000)
+	_, err := w.Write(qingstortest.Content(1500))
+	assert.Nil(t, err)
+
+	canceled := errors.New("canceled")
+	assert.Nil(t, w.CloseWithError(canceled))
+	assertAborted(t, server, bucket)
+	_, err = w.Write([]byte("closed"))
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
+
+func TestObjectWriterPartFailed(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server)
+	transport.SetFaults(qingstortest.Fault{
+		Kind:       qingstortest.FaultStatus,
+		Operation:  "Upload Multipart",
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "invalid_request",
+	})
+
+	w := bucket.NewObjectWriterWithPartSize(context.Background(), "key", nil, 1000)
+	_, err := w.Write(qingstortest.Content(2500))
+	assert.NotNil(t, err)
+	_, again := w.Write([]byte("more"))
+	assert.Equal(t, err, again)
+	assert.Equal(t, err, w.Close())
+	assertAborted(t, server, bucket)
+}
@@ -0,0 +1,318 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package service
+
+import (
+	"context"
+)
+
+// ListObjectsPaginator pages through the objects of bucket.
+//
+// The page size, prefix, delimiter and start marker are taken from the input.
+// If the server returns HasMore without NextMarker, the next page starts
+// after the last key or common prefix of the page.
+type ListObjectsPaginator struct {
+	bucket *Bucket
+	input  ListObjectsInput
+	done   bool
+
+	prefixes   []string
+	seenPrefix map[string]bool
+}
+
+// NewListObjectsPaginator creates a paginator of ListObjects, input can be nil.
+func (s *Bucket) NewListObjectsPaginator(input *ListObjectsInput) *ListObjectsPaginator {
+	p := &ListObjectsPaginator{bucket: s, seenPrefix: map[string]bool{}}
+	if input != nil {
+		p.input = *input
+	}
+	return p
+}
+
+// HasNext checks whether there are more pages.
+func (p *ListObjectsPaginator) HasNext() bool {
+	return !p.done
+}
+
+// Next returns the next page.
+func (p *ListObjectsPaginator) Next(ctx context.Context) (*ListObjectsOutput, error) {
+	input := p.input
+	output, err := p.bucket.ListObjectsWithContext(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+	for _, prefix := range output.CommonPrefixes {
+		if prefix != nil && !p.seenPrefix[*prefix] {
+			p.seenPrefix[*prefix] = true
+			p.prefixes = append(p.prefixes, *prefix)
+		}
+	}
+
+	marker := StringValue(output.NextMarker)
+	if marker == "" {
+		if n := len(output.Keys); n > 0 {
+			marker = StringValue(output.Keys[n-1].Key)
+		}
+		if n := len(output.CommonPrefixes); n > 0 && StringValue(output.CommonPrefixes[n-1]) > marker {
+			marker = StringValue(output.CommonPrefixes[n-1])
+		}
+	}
+	// Stop if the marker doesn't move forward, which would return the same
+	// page forever.
+	if !BoolValue(output.HasMore) || marker == "" || marker == StringValue(p.input.Marker) {
+		p.done = true
+	}
+	p.input.Marker = String(marker)
+	return output, nil
+}
+
+// CommonPrefixes returns the common prefixes of all pages returned so far.
+func (p *ListObjectsPaginator) CommonPrefixes() []string {
+	return p.prefixes
+}
+
+// EachKey calls fn with the keys of remaining pages, until fn returns false.
+func (p *ListObjectsPaginator) EachKey(ctx context.Context, fn func(key *KeyType) bool) error {
+	for p.HasNext() {
+		output, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range output.Keys {
+			if !fn(key) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// ListMultipartUploadsPaginator pages through the multipart uploads of bucket.
+//
+// The page size, prefix, delimiter and start markers are taken from the
+// input. If the server returns HasMore without the next markers, the next
+// page starts after the last upload of the page.
+type ListMultipartUploadsPaginator struct {
+	bucket *Bucket
+	input  ListMultipartUploadsInput
+	done   bool
+
+	prefixes   []string
+	seenPrefix map[string]bool
+}
+
+// NewListMultipartUploadsPaginator creates a paginator of ListMultipartUploads,
+// input can be nil.
+func (s *Bucket) NewListMultipartUploadsPaginator(input *ListMultipartUploadsInput) *ListMultipartUploadsPaginator {
+	p := &ListMultipartUploadsPaginator{bucket: s, seenPrefix: map[string]bool{}}
+	if input != nil {
+		p.input = *input
+	}
+	return p
+}
+
+// HasNext checks whether there are more pages.
+func (p *ListMultipartUploadsPaginator) HasNext() bool {
+	return !p.done
+}
+
+// Next returns the next page.
+func (p *ListMultipartUploadsPaginator) Next(ctx context.Context) (*ListMultipartUploadsOutput, error) {
+	input := p.input
+	output, err := p.bucket.ListMultipartUploadsWithContext(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+	for _, prefix := range output.CommonPrefixes {
+		if prefix != nil && !p.seenPrefix[*prefix] {
+			p.seenPrefix[*prefix] = true
+			p.prefixes = append(p.prefixes, *prefix)
+		}
+	}
+
+	keyMarker := StringValue(output.NextKeyMarker)
+	uploadIDMarker := StringValue(output.NextUploadIDMarker)
+	if keyMarker == "" {
+		if n := len(output.Uploads); n > 0 {
+			keyMarker = StringValue(output.Uploads[n-1].Key)
+			uploadIDMarker = StringValue(output.Uploads[n-1].UploadID)
+		}
+		if n := len(output.CommonPrefixes); n > 0 && StringValue(output.CommonPrefixes[n-1]) > keyMarker {
+			keyMarker = StringValue(output.CommonPrefixes[n-1])
+			uploadIDMarker = ""
+		}
+	}
+	if !BoolValue(output.HasMore) || keyMarker == "" ||
+		(keyMarker == StringValue(p.input.KeyMarker) && uploadIDMarker == StringValue(p.input.UploadIDMarker)) {
+		p.done = true
+	}
+	p.input.KeyMarker = String(keyMarker)
+	p.input.UploadIDMarker = nil
+	if uploadIDMarker != "" {
+		p.input.UploadIDMarker = String(uploadIDMarker)
+	}
+	return output, nil
+}
+
+// CommonPrefixes returns the common prefixes of all pages returned so far.
+func (p *ListMultipartUploadsPaginator) CommonPrefixes() []string {
+	return p.prefixes
+}
+
+// EachUpload calls fn with the uploads of remaining pages, until fn returns false.
+func (p *ListMultipartUploadsPaginator) EachUpload(ctx context.Context, fn func(upload *UploadsType) bool) error {
+	for p.HasNext() {
+		output, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+		for _, upload := range output.Uploads {
+			if !fn(upload) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// ListMultipartPaginator pages through the parts of a multipart upload.
+//
+// ListMultipart doesn't tell whether there are more parts, so the pages end
+// when a page is smaller than the limit of input, or brings no new parts.
+type ListMultipartPaginator struct {
+	bucket    *Bucket
+	objectKey string
+	input     ListMultipartInput
+	done      bool
+	seen      map[int]bool
+}
+
+// NewListMultipartPaginator creates a paginator of ListMultipart, the
+// UploadID of input is required.
+func (s *Bucket) NewListMultipartPaginator(objectKey string, input *ListMultipartInput) *ListMultipartPaginator {
+	p := &ListMultipartPaginator{bucket: s, objectKey: objectKey, seen: map[int]bool{}}
+	if input != nil {
+		p.input = *input
+	}
+	return p
+}
+
+// HasNext checks whether there are more pages.
+func (p *ListMultipartPaginator) HasNext() bool {
+	return !p.done
+}
+
+// Next returns the next page, the parts returned by former pages are
+// removed from it.
+func (p *ListMultipartPaginator) Next(ctx context.Context) (*ListMultipartOutput, error) {
+	input := p.input
+	output, err := p.bucket.ListMultipartWithContext(ctx, p.objectKey, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	count := len(output.ObjectParts)
+	marker := IntValue(p.input.PartNumberMarker)
+	parts := make([]*ObjectPartType, 0, count)
+	for _, part := range output.ObjectParts {
+		n := IntValue(part.PartNumber)
+		if p.seen[n] {
+			continue
+		}
+		p.seen[n] = true
+		parts = append(parts, part)
+		if n > marker {
+			marker = n
+		}
+	}
+	output.ObjectParts = parts
+
+	if len(parts) == 0 || (p.input.Limit != nil && count < *p.input.Limit) {
+		p.done = true
+	}
+	p.input.PartNumberMarker = Int(marker)
+	return output, nil
+}
+
+// EachPart calls fn with the parts of remaining pages, until fn returns false.
+func (p *ListMultipartPaginator) EachPart(ctx context.Context, fn func(part *ObjectPartType) bool) error {
+	for p.HasNext() {
+		output, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+		for _, part := range output.ObjectParts {
+			if !fn(part) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// ListBucketsPaginator pages through the buckets by offset.
+type ListBucketsPaginator struct {
+	service *Service
+	input   ListBucketsInput
+	done    bool
+}
+
+// NewListBucketsPaginator creates a paginator of ListBuckets, input can be nil.
+func (s *Service) NewListBucketsPaginator(input *ListBucketsInput) *ListBucketsPaginator {
+	p := &ListBucketsPaginator{service: s}
+	if input != nil {
+		p.input = *input
+	}
+	return p
+}
+
+// HasNext checks whether there are more pages.
+func (p *ListBucketsPaginator) HasNext() bool {
+	return !p.done
+}
+
+// Next returns the next page.
+func (p *ListBucketsPaginator) Next(ctx context.Context) (*ListBucketsOutput, error) {
+	input := p.input
+	output, err := p.service.ListBucketsWithContext(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := IntValue(p.input.Offset) + len(output.Buckets)
+	if len(output.Buckets) == 0 || offset >= IntValue(output.Count) {
+		p.done = true
+	}
+	p.input.Offset = Int(offset)
+	return output, nil
+}
+
+// EachBucket calls fn with the buckets of remaining pages, until fn returns false.
+func (p *ListBucketsPaginator) EachBucket(ctx context.Context, fn func(bucket *BucketType) bool) error {
+	for p.HasNext() {
+		output, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+		for _, bucket := range output.Buckets {
+			if !fn(bucket) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/qingstortest"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// quirkTransport makes the list responses of qingstortest differ as the
+// paginators expect from some servers.
+type quirkTransport struct {
+	http.RoundTripper
+
+	// omitNextMarkers drops the next markers from the responses with more
+	// pages.
+	omitNextMarkers bool
+	// inclusiveMarker makes the part number markers inclusive, so the pages
+	// of parts overlap.
+	inclusiveMarker bool
+}
+
+func (t *quirkTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	if marker := query.Get("part_number_marker"); t.inclusiveMarker && marker != "" {
+		n, _ := strconv.Atoi(marker)
+		query.Set("part_number_marker", strconv.Itoa(n-1))
+		u := *req.URL
+		u.RawQuery = query.Encode()
+		req = req.WithContext(req.Context())
+		req.URL = &u
+	}
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil || !t.omitNextMarkers || req.Method != http.MethodGet {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	output := map[string]interface{}{}
+	if json.Unmarshal(body, &output) == nil {
+		delete(output, "next_marker")
+		delete(output, "next_key_marker")
+		delete(output, "next_upload_id_marker")
+		body, _ = json.Marshal(output)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return resp, nil
+}
+
+// wrapTransport sends the requests of bucket through the transport returned
+// by wrap, which sees the requests answered by the faults as well.
+func wrapTransport(bucket *service.Bucket, wrap func(transport http.RoundTripper) http.RoundTripper) {
+	client := *bucket.Config.Connection
+	client.Transport = wrap(client.Transport)
+	bucket.Config.Connection = &client
+}
+
+// listQueries returns the queries of the requests listing path.
+func listQueries(server *qingstortest.Server, path string) []url.Values {
+	var queries []url.Values
+	for _, r := range server.Requests() {
+		if r.Method == http.MethodGet && r.URL.Path == path {
+			queries = append(queries, r.URL.Query())
+		}
+	}
+	return queries
+}
+
+func TestListObjectsPaginator(t *testing.T) {
+	for _, omit := range []bool{false, true} {
+		server := qingstortest.NewServer()
+		var opts []qingstortest.BucketOption
+		for _, key := range []string{"a/1", "a/2", "b", "c", "d/1", "e", "f"} {
+			opts = append(opts, qingstortest.WithObject(key, []byte(key)))
+		}
+		bucket, _ := qingstortest.NewTestBucket(t, server, opts...)
+		wrapTransport(bucket, func(transport http.RoundTripper) http.RoundTripper {
+			return &quirkTransport{RoundTripper: transport, omitNextMarkers: omit}
+		})
+
+		p := bucket.NewListObjectsPaginator(&service.ListObjectsInput{
+			Limit:     service.Int(2),
+			Delimiter: service.String("/"),
+		})
+		var keys []string
+		err := p.EachKey(context.Background(), func(key *service.KeyType) bool {
+			keys = append(keys, *key.Key)
+			return true
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b", "c", "e", "f"}, keys)
+		assert.Equal(t, []string{"a/", "d/"}, p.CommonPrefixes())
+		queries := listQueries(server, "/bucket")
+		assert.Equal(t, 3, len(queries))
+		assert.Equal(t, "d/", queries[2].Get("marker"))
+		assert.False(t, p.HasNext())
+		server.Close()
+	}
+}
+
+func TestListObjectsPaginatorPrefix(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	var opts []qingstortest.BucketOption
+	for _, key := range []string{"a/1", "a/2", "a/3", "b"} {
+		opts = append(opts, qingstortest.WithObject(key, []byte(key)))
+	}
+	bucket, _ := qingstortest.NewTestBucket(t, server, opts...)
+
+	p := bucket.NewListObjectsPaginator(&service.ListObjectsInput{
+		Limit:  service.Int(2),
+		Prefix: service.String("a/"),
+	})
+	var keys []string
+	err := p.EachKey(context.Background(), func(key *service.KeyType) bool {
+		keys = append(keys, *key.Key)
+		return len(keys) < 3
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a/1", "a/2", "a/3"}, keys)
+	assert.Equal(t, "a/", listQueries(server, "/bucket")[1].Get("prefix"))
+}
+
+func TestListMultipartUploadsPaginator(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+	wrapTransport(bucket, func(transport http.RoundTripper) http.RoundTripper {
+		return &quirkTransport{RoundTripper: transport, omitNextMarkers: true}
+	})
+	var uploadIDs []string
+	for _, key := range []string{"a", "b", "c"} {
+		output, err := bucket.InitiateMultipartUpload(key, nil)
+		assert.Nil(t, err)
+		uploadIDs = append(uploadIDs, *output.UploadID)
+	}
+
+	p := bucket.NewListMultipartUploadsPaginator(&service.ListMultipartUploadsInput{
+		Limit: service.Int(2),
+	})
+	var uploads []string
+	err := p.EachUpload(context.Background(), func(upload *service.UploadsType) bool {
+		uploads = append(uploads, *upload.UploadID)
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uploadIDs, uploads)
+	queries := listQueries(server, "/bucket")
+	assert.Equal(t, "b", queries[1].Get("key_marker"))
+	assert.Equal(t, uploadIDs[1], queries[1].Get("upload_id_marker"))
+}
+
+func TestListMultipartPaginator(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+	wrapTransport(bucket, func(transport http.RoundTripper) http.RoundTripper {
+		return &quirkTransport{RoundTripper: transport, inclusiveMarker: true}
+	})
+	initiated, err := bucket.InitiateMultipartUpload("key", nil)
+	assert.Nil(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = bucket.UploadMultipart("key", &service.UploadMultipartInput{
+			UploadID:   initiated.UploadID,
+			PartNumber: service.Int(i),
+			Body:       bytes.NewReader(qingstortest.Content(10)),
+		})
+		assert.Nil(t, err)
+	}
+
+	p := bucket.NewListMultipartPaginator("key", &service.ListMultipartInput{
+		UploadID: initiated.UploadID,
+		Limit:    service.Int(2),
+	})
+	var parts []int
+	err = p.EachPart(context.Background(), func(part *service.ObjectPartType) bool {
+		parts = append(parts, *part.PartNumber)
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, parts)
+	assert.False(t, p.HasNext())
+}
+
+func TestListBucketsPaginator(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		qingstortest.NewTestBucket(t, server, qingstortest.WithBucketName(name))
+	}
+	conf, err := server.Config()
+	assert.Nil(t, err)
+	qs, err := service.Init(conf)
+	assert.Nil(t, err)
+
+	p := qs.NewListBucketsPaginator(&service.ListBucketsInput{
+		Limit: service.Int(2),
+	})
+	var buckets []string
+	err = p.EachBucket(context.Background(), func(bucket *service.BucketType) bool {
+		buckets = append(buckets, *bucket.Name)
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, buckets)
+	queries := listQueries(server, "/")
+	assert.Equal(t, 3, len(queries))
+	assert.Equal(t, "4", queries[2].Get("offset"))
+}
@@ -0,0 +1,240 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/credentials"
+	"github.com/qingstor/qingstor-sdk-go/v4/request"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/signer/v2"
+)
+
+// DefaultPresignExpires is the lifetime of presigned requests if no expiry was given.
+const DefaultPresignExpires = 15 * time.Minute
+
+// PresignedRequest is a request signed by query string,
+// it can be sent by anyone holding it without credentials before it expires.
+type PresignedRequest struct {
+	Method string
+	URL    string
+
+	// Header must be sent along with the URL, Content-Type, Content-MD5 and
+	// X-QS-* headers are covered by the signature.
+	Header http.Header
+
+	Expires time.Time
+}
+
+// PresignOption configures presigned requests.
+type PresignOption func(*presignOptions)
+
+type presignOptions struct {
+	expires time.Time
+}
+
+// WithExpiresIn makes the presigned request expire after d.
+func WithExpiresIn(d time.Duration) PresignOption {
+	return func(o *presignOptions) {
+		o.expires = time.Now().Add(d)
+	}
+}
+
+// WithExpiresAt makes the presigned request expire at t.
+func WithExpiresAt(t time.Time) PresignOption {
+	return func(o *presignOptions) {
+		o.expires = t
+	}
+}
+
+// PresignGetObject presigns GetObject, the response-* fields of input
+// override the headers of response.
+func (s *Bucket) PresignGetObject(objectKey string, input *GetObjectInput, opts ...PresignOption) (*PresignedRequest, error) {
+	r, _, err := s.GetObjectRequest(objectKey, input)
+	if err != nil {
+		return nil, err
+	}
+	return presign(r, opts)
+}
+
+// PresignPutObject presigns PutObject, the body of input is ignored.
+func (s *Bucket) PresignPutObject(objectKey string, input *PutObjectInput, opts ...PresignOption) (*PresignedRequest, error) {
+	if input != nil && input.Body != nil {
+		i := *input
+		i.Body = nil
+		input = &i
+	}
+	r, _, err := s.PutObjectRequest(objectKey, input)
+	if err != nil {
+		return nil, err
+	}
+	return presign(r, opts)
+}
+
+// PresignHeadObject presigns HeadObject.
+func (s *Bucket) PresignHeadObject(objectKey string, input *HeadObjectInput, opts ...PresignOption) (*PresignedRequest, error) {
+	r, _, err := s.HeadObjectRequest(objectKey, input)
+	if err != nil {
+		return nil, err
+	}
+	return presign(r, opts)
+}
+
+// PresignDeleteObject presigns DeleteObject.
+func (s *Bucket) PresignDeleteObject(objectKey string, opts ...PresignOption) (*PresignedRequest, error) {
+	r, _, err := s.DeleteObjectRequest(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	return presign(r, opts)
+}
+
+// PresignUploadMultipart presigns UploadMultipart, the body of input is ignored.
+func (s *Bucket) PresignUploadMultipart(objectKey string, input *UploadMultipartInput, opts ...PresignOption) (*PresignedRequest, error) {
+	if input != nil && input.Body != nil {
+		i := *input
+		i.Body = nil
+		input = &i
+	}
+	r, _, err := s.UploadMultipartRequest(objectKey, input)
+	if err != nil {
+		return nil, err
+	}
+	return presign(r, opts)
+}
+
+// PresignImageProcess presigns ImageProcess, the response-* fields of input
+// override the headers of response.
+func (s *Bucket) PresignImageProcess(objectKey string, input *ImageProcessInput, opts ...PresignOption) (*PresignedRequest, error) {
+	r, _, err := s.ImageProcessRequest(objectKey, input)
+	if err != nil {
+		return nil, err
+	}
+	return presign(r, opts)
+}
+
+// PostPolicyForm is the form of browser POST upload.
+type PostPolicyForm struct {
+	// URL is the action of the form, the method must be POST.
+	URL string
+
+	// Fields are the fields of the form, the file must follow them as the last field.
+	Fields map[string]string
+
+	Expires time.Time
+}
+
+// NewPostPolicy creates a policy for browser POST upload into this bucket.
+func (s *Bucket) NewPostPolicy(expires time.Time) *signer.PostPolicy {
+	return signer.NewPostPolicy(StringValue(s.Properties.BucketName), expires)
+}
+
+// PresignPostPolicy signs policy and returns the form to embed in web pages.
+func (s *Bucket) PresignPostPolicy(policy *signer.PostPolicy) (*PostPolicyForm, error) {
+	v, err := s.Config.Credentials(context.Background())
+	if err != nil {
+		return nil, errors.NewSDKError(
+			errors.WithAction("retrieve credentials in presign post policy"),
+			errors.WithError(err),
+		)
+	}
+	if !v.HasKeys() {
+		return nil, errors.NewSDKError(
+			errors.WithAction("presign post policy"),
+			errors.WithError(fmt.Errorf("credentials not provided")),
+		)
+	}
+	if policy.Bucket() != StringValue(s.Properties.BucketName) {
+		return nil, errors.NewSDKError(
+			errors.WithAction("presign post policy"),
+			errors.WithError(fmt.Errorf("policy is for bucket %s", policy.Bucket())),
+		)
+	}
+
+	qss := &signer.QingStorSigner{
+		AccessKeyID:     v.AccessKeyID,
+		SecretAccessKey: v.SecretAccessKey,
+	}
+	fields, err := qss.SignPostPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	if v.SessionToken != "" {
+		fields[credentials.SessionTokenHeader] = v.SessionToken
+	}
+
+	// The form is posted to the url of bucket.
+	r, _, err := s.HeadRequest()
+	if err != nil {
+		return nil, err
+	}
+	err = r.BuildWithContext(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostPolicyForm{
+		URL:     r.HTTPRequest.URL.String(),
+		Fields:  fields,
+		Expires: policy.Expiration(),
+	}, nil
+}
+
+// unsignedHeaders are set by the client sending the presigned request itself.
+var unsignedHeaders = []string{"Authorization", "Content-Length", "Date", "User-Agent"}
+
+func presign(r *request.Request, opts []PresignOption) (*PresignedRequest, error) {
+	o := &presignOptions{expires: time.Now().Add(DefaultPresignExpires)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if !o.expires.After(time.Now()) {
+		return nil, errors.NewSDKError(
+			errors.WithAction("presign request"),
+			errors.WithError(fmt.Errorf("expires %s is not in the future", o.expires)),
+		)
+	}
+
+	err := r.BuildWithContext(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	err = r.SignQueryUntil(o.expires)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	for k, v := range r.HTTPRequest.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	for _, k := range unsignedHeaders {
+		header.Del(k)
+	}
+
+	return &PresignedRequest{
+		Method:  r.HTTPRequest.Method,
+		URL:     r.HTTPRequest.URL.String(),
+		Header:  header,
+		Expires: time.Unix(o.expires.Unix(), 0),
+	}, nil
+}
@@ -0,0 +1,157 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package service
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/signer/v2"
+	"github.com/qingstor/qingstor-sdk-go/v4/utils"
+)
+
+func newTestBucket(t *testing.T) *Bucket {
+	conf, err := config.New("ACCESS_KEY_ID", "SECRET_ACCESS_KEY")
+	assert.Nil(t, err)
+	qs, err := Init(conf)
+	assert.Nil(t, err)
+	bucket, err := qs.Bucket("bucket", "pek3b")
+	assert.Nil(t, err)
+	return bucket
+}
+
+// verifyPresigned checks the signature of presigned request by sending it with its headers.
+func verifyPresigned(t *testing.T, p *PresignedRequest) url.Values {
+	u, err := url.Parse(p.URL)
+	assert.Nil(t, err)
+	req, err := http.NewRequest(p.Method, p.URL, nil)
+	assert.Nil(t, err)
+	req.Header = p.Header
+
+	query := u.Query()
+	expires, err := strconv.Atoi(query.Get("expires"))
+	assert.Nil(t, err)
+	s := &signer.QingStorSigner{AccessKeyID: "ACCESS_KEY_ID", SecretAccessKey: "SECRET_ACCESS_KEY"}
+	expected, err := s.BuildQuerySignature(signer.CanonicalReqByPath(req), expires)
+	assert.Nil(t, err)
+	assert.Contains(t, expected, "signature="+utils.URLQueryEscape(query.Get("signature")))
+	return query
+}
+
+func TestPresignGetObject(t *testing.T) {
+	bucket := newTestBucket(t)
+	expires := time.Now().Add(time.Hour)
+
+	p, err := bucket.PresignGetObject("dir/key.jpg", &GetObjectInput{
+		ResponseContentDisposition: String("attachment; filename=key.jpg"),
+	}, WithExpiresAt(expires))
+	assert.Nil(t, err)
+	assert.Equal(t, "GET", p.Method)
+	assert.Equal(t, expires.Unix(), p.Expires.Unix())
+	assert.Equal(t, "", p.Header.Get("Date"))
+	assert.Equal(t, "", p.Header.Get("Authorization"))
+
+	query := verifyPresigned(t, p)
+	assert.Equal(t, "ACCESS_KEY_ID", query.Get("access_key_id"))
+	assert.Equal(t, strconv.FormatInt(expires.Unix(), 10), query.Get("expires"))
+	assert.Equal(t, "attachment; filename=key.jpg", query.Get("response-content-disposition"))
+}
+
+func TestPresignPutObject(t *testing.T) {
+	bucket := newTestBucket(t)
+
+	p, err := bucket.PresignPutObject("key", &PutObjectInput{
+		ContentType:     String("image/jpeg"),
+		XQSMetaData:     &map[string]string{"x-qs-meta-user": "test"},
+		XQSStorageClass: String("STANDARD_IA"),
+	}, WithExpiresIn(time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT", p.Method)
+	assert.Equal(t, "image/jpeg", p.Header.Get("Content-Type"))
+	assert.Equal(t, "STANDARD_IA", p.Header.Get("X-QS-Storage-Class"))
+	assert.Equal(t, "test", p.Header.Get("X-QS-Meta-User"))
+	assert.True(t, p.Expires.Before(time.Now().Add(time.Minute+time.Second)))
+	verifyPresigned(t, p)
+}
+
+func TestPresignOtherOperations(t *testing.T) {
+	bucket := newTestBucket(t)
+
+	p, err := bucket.PresignHeadObject("key", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "HEAD", p.Method)
+	verifyPresigned(t, p)
+
+	p, err = bucket.PresignDeleteObject("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE", p.Method)
+	verifyPresigned(t, p)
+
+	p, err = bucket.PresignUploadMultipart("key", &UploadMultipartInput{
+		UploadID:   String("upload-id"),
+		PartNumber: Int(1),
+	})
+	assert.Nil(t, err)
+	query := verifyPresigned(t, p)
+	assert.Equal(t, "upload-id", query.Get("upload_id"))
+	assert.Equal(t, "1", query.Get("part_number"))
+
+	p, err = bucket.PresignImageProcess("key", &ImageProcessInput{
+		Action: String("resize:w_100"),
+	})
+	assert.Nil(t, err)
+	query = verifyPresigned(t, p)
+	assert.Equal(t, "resize:w_100", query.Get("action"))
+}
+
+func TestPresignError(t *testing.T) {
+	bucket := newTestBucket(t)
+
+	_, err := bucket.PresignGetObject("key", nil, WithExpiresIn(-time.Minute))
+	assert.NotNil(t, err)
+
+	_, err = bucket.PresignUploadMultipart("key", &UploadMultipartInput{})
+	assert.NotNil(t, err)
+
+	bucket.Config.AccessKeyID = ""
+	bucket.Config.SecretAccessKey = ""
+	_, err = bucket.PresignGetObject("key", nil)
+	assert.NotNil(t, err)
+}
+
+func TestPresignPostPolicy(t *testing.T) {
+	bucket := newTestBucket(t)
+	expires := time.Now().Add(time.Hour)
+
+	policy := bucket.NewPostPolicy(expires)
+	assert.Nil(t, policy.SetKeyStartsWith("uploads/"))
+	form, err := bucket.PresignPostPolicy(policy)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://pek3b.qingstor.com:443/bucket", form.URL)
+	assert.Equal(t, "uploads/${filename}", form.Fields["key"])
+	assert.NotEqual(t, "", form.Fields["signature"])
+	assert.Equal(t, expires, form.Expires)
+
+	_, err = bucket.PresignPostPolicy(signer.NewPostPolicy("other", expires))
+	assert.NotNil(t, err)
+}
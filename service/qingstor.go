@@ -21,7 +21,10 @@ import (
 	"context"
 	"net/http"
 
+	"go.uber.org/zap"
+
 	"github.com/qingstor/qingstor-sdk-go/v4/config"
+	"github.com/qingstor/qingstor-sdk-go/v4/log"
 	"github.com/qingstor/qingstor-sdk-go/v4/request"
 	"github.com/qingstor/qingstor-sdk-go/v4/request/data"
 )
@@ -31,11 +34,23 @@ var _ http.Header
 // Service QingStor provides low-cost and reliable online storage service with unlimited storage space, high read and write performance, high reliability and data safety, fine-grained access control, and easy to use API.
 type Service struct {
 	Config *config.Config
+
+	// Handlers are copied into every bucket and request created by this service,
+	// they include the handlers installed by Config.HandlerInstallers.
+	Handlers *request.Handlers
+
+	// Logger is shared by every bucket and request created by this service,
+	// it's built from Config.LogLevel and can be replaced by your own logger.
+	Logger *zap.Logger
 }
 
 // Init initializes a new service.
 func Init(c *config.Config) (*Service, error) {
-	return &Service{Config: c}, nil
+	return &Service{
+		Config:   c,
+		Handlers: request.NewHandlersWithConfig(c),
+		Logger:   log.New(c.LogLevel),
+	}, nil
 }
 
 // ListBuckets does Retrieve the bucket list.
@@ -89,6 +104,10 @@ func (s *Service) ListBucketsRequest(input *ListBucketsInput) (*request.Request,
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.Handlers != nil {
+		r.Handlers = s.Handlers.Copy()
+	}
+	r.Logger = s.Logger
 
 	return r, x, nil
 }
@@ -0,0 +1,206 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+)
+
+// Defaults of waiters.
+const (
+	DefaultWaiterDelay       = 5 * time.Second
+	DefaultWaiterMaxDelay    = time.Minute
+	DefaultWaiterMaxAttempts = 20
+)
+
+// WaiterOption configures waiters.
+type WaiterOption func(*waiterOptions)
+
+type waiterOptions struct {
+	delay       time.Duration
+	maxDelay    time.Duration
+	backoff     float64
+	maxAttempts int
+}
+
+// WithWaiterDelay sets the delay between attempts.
+func WithWaiterDelay(d time.Duration) WaiterOption {
+	return func(o *waiterOptions) {
+		o.delay = d
+	}
+}
+
+// WithWaiterBackoff multiplies the delay by factor after every attempt, until
+// it reaches maxDelay.
+func WithWaiterBackoff(factor float64, maxDelay time.Duration) WaiterOption {
+	return func(o *waiterOptions) {
+		o.backoff = factor
+		o.maxDelay = maxDelay
+	}
+}
+
+// WithWaiterMaxAttempts sets the max number of attempts.
+func WithWaiterMaxAttempts(n int) WaiterOption {
+	return func(o *waiterOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WaitUntilBucketExists polls Head until the bucket exists.
+func (s *Bucket) WaitUntilBucketExists(ctx context.Context, opts ...WaiterOption) error {
+	return wait(ctx, "BucketExists", opts, func(ctx context.Context) (bool, error) {
+		_, err := s.HeadWithContext(ctx)
+		return existsResult(err)
+	})
+}
+
+// WaitUntilBucketNotExists polls Head until the bucket doesn't exist.
+func (s *Bucket) WaitUntilBucketNotExists(ctx context.Context, opts ...WaiterOption) error {
+	return wait(ctx, "BucketNotExists", opts, func(ctx context.Context) (bool, error) {
+		_, err := s.HeadWithContext(ctx)
+		return notExistsResult(err)
+	})
+}
+
+// WaitUntilObjectExists polls HeadObject until the object exists.
+func (s *Bucket) WaitUntilObjectExists(ctx context.Context, objectKey string, opts ...WaiterOption) error {
+	return wait(ctx, "ObjectExists", opts, func(ctx context.Context) (bool, error) {
+		_, err := s.HeadObjectWithContext(ctx, objectKey, nil)
+		return existsResult(err)
+	})
+}
+
+// WaitUntilObjectNotExists polls HeadObject until the object doesn't exist.
+func (s *Bucket) WaitUntilObjectNotExists(ctx context.Context, objectKey string, opts ...WaiterOption) error {
+	return wait(ctx, "ObjectNotExists", opts, func(ctx context.Context) (bool, error) {
+		_, err := s.HeadObjectWithContext(ctx, objectKey, nil)
+		return notExistsResult(err)
+	})
+}
+
+// WaitUntilObjectETag polls HeadObject until the object exists with etag,
+// the quotes around ETags are ignored.
+func (s *Bucket) WaitUntilObjectETag(ctx context.Context, objectKey string, etag string, opts ...WaiterOption) error {
+	etag = strings.Trim(etag, `"`)
+	return wait(ctx, "ObjectETag", opts, func(ctx context.Context) (bool, error) {
+		// HeadObjectOutput doesn't carry the ETag, read it from the response.
+		r, _, err := s.HeadObjectRequest(objectKey, nil)
+		if err != nil {
+			return false, err
+		}
+		if err = r.SendWithContext(ctx); err != nil {
+			return existsResult(err)
+		}
+		return strings.Trim(r.HTTPResponse.Header.Get(http.CanonicalHeaderKey("ETag")), `"`) == etag, nil
+	})
+}
+
+// wait calls check until it returns true or an error, or the attempts are
+// used up. The error of check is returned directly, unless it's wrapped in
+// waitableError, like 404 of exists waiters, which is expected to go away.
+func wait(ctx context.Context, name string, opts []WaiterOption, check func(ctx context.Context) (bool, error)) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	o := &waiterOptions{
+		delay:       DefaultWaiterDelay,
+		maxDelay:    DefaultWaiterMaxDelay,
+		backoff:     1,
+		maxAttempts: DefaultWaiterMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	delay := o.delay
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		done, err := check(ctx)
+		if done {
+			return nil
+		}
+		if w, ok := err.(*waitableError); ok {
+			err = w.err
+		} else if err != nil {
+			return err
+		}
+		lastErr = err
+		if attempt >= o.maxAttempts {
+			return errors.WaiterTimeoutError{Waiter: name, Attempts: attempt, LastErr: lastErr}
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		if o.backoff > 1 {
+			delay = time.Duration(float64(delay) * o.backoff)
+			if delay > o.maxDelay {
+				delay = o.maxDelay
+			}
+		}
+	}
+}
+
+// existsResult converts the result of Head into the result of exists waiters.
+func existsResult(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if isNotFound(err) {
+		return false, &waitableError{err}
+	}
+	return false, err
+}
+
+// notExistsResult converts the result of Head into the result of not exists waiters.
+func notExistsResult(err error) (bool, error) {
+	if err == nil {
+		return false, nil
+	}
+	if isNotFound(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+func isNotFound(err error) bool {
+	switch e := err.(type) {
+	case *errors.QingStorError:
+		return e.StatusCode == http.StatusNotFound
+	case errors.QingStorError:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// waitableError marks the errors which don't stop waiters.
+type waitableError struct {
+	err error
+}
+
+func (e *waitableError) Error() string {
+	return e.err.Error()
+}
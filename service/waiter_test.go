@@ -0,0 +1,200 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qingstor/qingstor-sdk-go/v4/qingstortest"
+	"github.com/qingstor/qingstor-sdk-go/v4/request/errors"
+	"github.com/qingstor/qingstor-sdk-go/v4/service"
+)
+
+// headHook calls before with the number of HEAD request, counting from 1,
+// before sending it.
+type headHook struct {
+	http.RoundTripper
+
+	mu       sync.Mutex
+	attempts int
+	before   func(attempt int)
+}
+
+func (h *headHook) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodHead {
+		h.mu.Lock()
+		h.attempts++
+		attempt := h.attempts
+		h.mu.Unlock()
+		if h.before != nil {
+			h.before(attempt)
+		}
+	}
+	return h.RoundTripper.RoundTrip(req)
+}
+
+// reset clears the attempts, and calls before for the next HEAD requests.
+func (h *headHook) reset(before func(attempt int)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.attempts = 0
+	h.before = before
+}
+
+// hookHeads sends the HEAD requests of bucket through a headHook.
+func hookHeads(bucket *service.Bucket) *headHook {
+	hook := &headHook{}
+	wrapTransport(bucket, func(transport http.RoundTripper) http.RoundTripper {
+		hook.RoundTripper = transport
+		return hook
+	})
+	return hook
+}
+
+var fastWaiter = []service.WaiterOption{service.WithWaiterDelay(time.Millisecond), service.WithWaiterMaxAttempts(5)}
+
+func TestWaitUntilExists(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+	hook := hookHeads(bucket)
+
+	hook.reset(func(attempt int) {
+		if attempt == 3 {
+			_, err := bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader([]byte("content"))})
+			assert.Nil(t, err)
+		}
+	})
+	err := bucket.WaitUntilObjectExists(context.Background(), "key", fastWaiter...)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, hook.attempts)
+
+	conf, err := server.Config()
+	assert.Nil(t, err)
+	conf.Connection = bucket.Config.Connection
+	qs, err := service.Init(conf)
+	assert.Nil(t, err)
+	other, err := qs.Bucket("other", qingstortest.Zone)
+	assert.Nil(t, err)
+	hook.reset(func(attempt int) {
+		if attempt == 3 {
+			_, err := other.Put()
+			assert.Nil(t, err)
+		}
+	})
+	err = other.WaitUntilBucketExists(context.Background(), fastWaiter...)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, hook.attempts)
+}
+
+func TestWaitUntilNotExists(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server, qingstortest.WithObject("key", []byte("content")))
+	hook := hookHeads(bucket)
+
+	hook.reset(func(attempt int) {
+		if attempt == 3 {
+			_, err := bucket.DeleteObject("key")
+			assert.Nil(t, err)
+		}
+	})
+	err := bucket.WaitUntilObjectNotExists(context.Background(), "key", fastWaiter...)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, hook.attempts)
+
+	hook.reset(func(attempt int) {
+		if attempt == 3 {
+			_, err := bucket.Delete()
+			assert.Nil(t, err)
+		}
+	})
+	err = bucket.WaitUntilBucketNotExists(context.Background(), fastWaiter...)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, hook.attempts)
+}
+
+func TestWaitUntilObjectETag(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+	hook := hookHeads(bucket)
+
+	// The object is created by the second attempt, and changed by the third.
+	hook.reset(func(attempt int) {
+		if attempt == 2 || attempt == 3 {
+			_, err := bucket.PutObject("key", &service.PutObjectInput{Body: bytes.NewReader(qingstortest.Content(attempt))})
+			assert.Nil(t, err)
+		}
+	})
+	sum := md5.Sum(qingstortest.Content(3))
+	err := bucket.WaitUntilObjectETag(context.Background(), "key", hex.EncodeToString(sum[:]), fastWaiter...)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, hook.attempts)
+}
+
+func TestWaiterTimeout(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+	hook := hookHeads(bucket)
+
+	err := bucket.WaitUntilObjectExists(context.Background(), "key",
+		service.WithWaiterDelay(time.Millisecond), service.WithWaiterBackoff(2, 4*time.Millisecond), service.WithWaiterMaxAttempts(4))
+	e, ok := err.(errors.WaiterTimeoutError)
+	assert.True(t, ok)
+	assert.Equal(t, "ObjectExists", e.Waiter)
+	assert.Equal(t, 4, e.Attempts)
+	assert.Equal(t, 404, e.LastErr.(*errors.QingStorError).StatusCode)
+	assert.Equal(t, 4, hook.attempts)
+}
+
+func TestWaiterStopsOnError(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, transport := qingstortest.NewTestBucket(t, server)
+	hook := hookHeads(bucket)
+	transport.SetFaults(qingstortest.Fault{
+		Kind:       qingstortest.FaultStatus,
+		Operation:  "HEAD Object",
+		StatusCode: http.StatusForbidden,
+	})
+
+	err := bucket.WaitUntilObjectExists(context.Background(), "key", fastWaiter...)
+	assert.Equal(t, 403, err.(*errors.QingStorError).StatusCode)
+	assert.Equal(t, 1, hook.attempts)
+}
+
+func TestWaiterCanceled(t *testing.T) {
+	server := qingstortest.NewServer()
+	defer server.Close()
+	bucket, _ := qingstortest.NewTestBucket(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := bucket.WaitUntilObjectExists(ctx, "key", service.WithWaiterDelay(time.Hour))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,155 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RecordedSpan is the snapshot of a span recorded by Recorder.
+type RecordedSpan struct {
+	ID       int
+	ParentID int // 0 if the span is a root span
+	Name     string
+
+	Attributes map[string]interface{}
+	Err        error
+
+	Start time.Time
+	End   time.Time // zero if the span is not ended
+}
+
+// Recorder is a Tracer which keeps all spans in memory, it's useful in tests.
+type Recorder struct {
+	mu     sync.Mutex
+	nextID int
+	spans  []*RecordedSpan
+	byID   map[int]*RecordedSpan
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{byID: map[int]*RecordedSpan{}}
+}
+
+// Start implements Tracer.
+func (r *Recorder) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.byID == nil {
+		r.byID = map[int]*RecordedSpan{}
+	}
+	r.nextID++
+	s := &RecordedSpan{
+		ID:         r.nextID,
+		Name:       name,
+		Attributes: map[string]interface{}{},
+		Start:      time.Now(),
+	}
+	if parent, ok := SpanFromContext(ctx).(*recorderSpan); ok && parent.recorder == r {
+		s.ParentID = parent.id
+	}
+	for _, a := range attrs {
+		s.Attributes[a.Key] = a.Value
+	}
+	r.spans = append(r.spans, s)
+	r.byID[s.ID] = s
+
+	span := &recorderSpan{recorder: r, id: s.ID}
+	return ContextWithSpan(ctx, span), span
+}
+
+// Spans returns snapshots of all recorded spans in the order they started.
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	spans := make([]RecordedSpan, 0, len(r.spans))
+	for _, s := range r.spans {
+		snapshot := *s
+		snapshot.Attributes = make(map[string]interface{}, len(s.Attributes))
+		for k, v := range s.Attributes {
+			snapshot.Attributes[k] = v
+		}
+		spans = append(spans, snapshot)
+	}
+	return spans
+}
+
+// Children returns snapshots of spans whose parent is the span with given id.
+func (r *Recorder) Children(id int) []RecordedSpan {
+	children := []RecordedSpan{}
+	for _, s := range r.Spans() {
+		if s.ParentID == id {
+			children = append(children, s)
+		}
+	}
+	return children
+}
+
+// Reset drops all recorded spans.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = nil
+	r.byID = map[int]*RecordedSpan{}
+}
+
+type recorderSpan struct {
+	recorder *Recorder
+	id       int
+}
+
+func (s *recorderSpan) span() *RecordedSpan {
+	span, ok := s.recorder.byID[s.id]
+	if !ok {
+		// The span has been dropped by Reset.
+		return &RecordedSpan{Attributes: map[string]interface{}{}}
+	}
+	return span
+}
+
+func (s *recorderSpan) SetAttributes(attrs ...Attribute) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+
+	span := s.span()
+	for _, a := range attrs {
+		span.Attributes[a.Key] = a.Value
+	}
+}
+
+func (s *recorderSpan) SetError(err error) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+
+	s.span().Err = err
+}
+
+func (s *recorderSpan) End() {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+
+	span := s.span()
+	if span.End.IsZero() {
+		span.End = time.Now()
+	}
+}
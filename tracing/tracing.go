@@ -0,0 +1,107 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+// Package tracing provides a small tracer abstraction for tracing API calls,
+// adapt it to your tracing system by implementing Tracer.
+package tracing
+
+import (
+	"context"
+)
+
+// Keys of attributes set by this sdk.
+const (
+	AttrOperation  = "qingstor.operation"
+	AttrBucket     = "qingstor.bucket"
+	AttrZone       = "qingstor.zone"
+	AttrKey        = "qingstor.key"
+	AttrRequestID  = "qingstor.request_id"
+	AttrAttempts   = "qingstor.attempts"
+	AttrPartNumber = "qingstor.part_number"
+	AttrUploadID   = "qingstor.upload_id"
+	AttrStatusCode = "http.status_code"
+)
+
+// Attribute is a key-value pair attached to a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String creates a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int creates an int attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is a traced operation.
+type Span interface {
+	// SetAttributes sets attributes on the span.
+	SetAttributes(attrs ...Attribute)
+	// SetError records the error which the operation failed with.
+	SetError(err error)
+	// End completes the span.
+	End()
+}
+
+// Tracer starts spans.
+//
+// Start must create the span as a child of the span carried by ctx if any,
+// and return a context carrying the new span, so that spans of nested calls
+// can be linked through context.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Start starts a span with given tracer, it returns a span doing nothing if tracer is nil.
+func Start(ctx context.Context, t Tracer, name string, attrs ...Attribute) (context.Context, Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t == nil {
+		return ctx, noopSpan{}
+	}
+	return t.Start(ctx, name, attrs...)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan set span into given context and return.
+func ContextWithSpan(ctx context.Context, s Span) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext get span from context, it returns nil if no span was set before.
+func SpanFromContext(ctx context.Context) Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(Span)
+	return s
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...Attribute) {}
+func (noopSpan) SetError(err error)               {}
+func (noopSpan) End()                             {}
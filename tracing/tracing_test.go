@@ -0,0 +1,70 @@
+// +-------------------------------------------------------------------------
+// | Copyright (C) 2016 Yunify, Inc.
+// +-------------------------------------------------------------------------
+// | Licensed under the Apache License, Version 2.0 (the "License");
+// | you may not use this work except in compliance with the License.
+// | You may obtain a copy of the License in the LICENSE file, or at:
+// |
+// | http://www.apache.org/licenses/LICENSE-2.0
+// |
+// | Unless required by applicable law or agreed to in writing, software
+// | distributed under the License is distributed on an "AS IS" BASIS,
+// | WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// | See the License for the specific language governing permissions and
+// | limitations under the License.
+// +-------------------------------------------------------------------------
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartWithoutTracer(t *testing.T) {
+	ctx, span := Start(nil, nil, "test")
+	assert.NotNil(t, ctx)
+	assert.Nil(t, SpanFromContext(ctx))
+
+	span.SetAttributes(String("key", "value"))
+	span.SetError(fmt.Errorf("error"))
+	span.End()
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+
+	ctx, parent := Start(context.Background(), r, "parent", String(AttrKey, "key"))
+	assert.Equal(t, parent, SpanFromContext(ctx))
+	_, child := Start(ctx, r, "child", Int(AttrPartNumber, 1))
+	child.SetError(fmt.Errorf("failed"))
+	child.End()
+	parent.SetAttributes(Int(AttrAttempts, 2))
+	parent.End()
+
+	spans := r.Spans()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "parent", spans[0].Name)
+	assert.Equal(t, 0, spans[0].ParentID)
+	assert.Equal(t, "key", spans[0].Attributes[AttrKey])
+	assert.Equal(t, 2, spans[0].Attributes[AttrAttempts])
+	assert.False(t, spans[0].End.IsZero())
+
+	children := r.Children(spans[0].ID)
+	assert.Equal(t, 1, len(children))
+	assert.Equal(t, "child", children[0].Name)
+	assert.Equal(t, 1, children[0].Attributes[AttrPartNumber])
+	assert.EqualError(t, children[0].Err, "failed")
+
+	r.Reset()
+	assert.Equal(t, 0, len(r.Spans()))
+	parent.SetAttributes(String("late", "value"))
+	_, span := Start(context.Background(), r, "next")
+	span.End()
+	spans = r.Spans()
+	assert.Equal(t, 1, len(spans))
+	assert.NotEqual(t, children[0].ID, spans[0].ID)
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the value of sensitive headers and query parameters.
+const Redacted = "REDACTED"
+
+// IsSensitiveHeader checks whether the value of given header must not be logged,
+// which are Authorization, X-QS-Security-Token and all X-QS-*-Customer-Key headers.
+func IsSensitiveHeader(key string) bool {
+	key = strings.ToLower(key)
+	if key == "authorization" || key == "x-qs-security-token" {
+		return true
+	}
+	return strings.HasPrefix(key, "x-qs-") && strings.HasSuffix(key, "-customer-key")
+}
+
+// RedactHeader returns a copy of header with sensitive values redacted.
+func RedactHeader(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for k, v := range header {
+		if IsSensitiveHeader(k) {
+			redacted[k] = []string{Redacted}
+			continue
+		}
+		redacted[k] = append([]string(nil), v...)
+	}
+	return redacted
+}
+
+// RedactURL returns the string of u with the signature query parameter redacted.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	query := u.Query()
+	if _, ok := query["signature"]; !ok {
+		return u.String()
+	}
+	query.Set("signature", Redacted)
+
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSensitiveHeader(t *testing.T) {
+	assert.True(t, IsSensitiveHeader("Authorization"))
+	assert.True(t, IsSensitiveHeader("X-QS-Security-Token"))
+	assert.True(t, IsSensitiveHeader("X-QS-Encryption-Customer-Key"))
+	assert.True(t, IsSensitiveHeader("x-qs-copy-source-encryption-customer-key"))
+	assert.False(t, IsSensitiveHeader("X-QS-Encryption-Customer-Key-MD5"))
+	assert.False(t, IsSensitiveHeader("Content-Type"))
+}
+
+func TestRedactHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "QS ACCESS_KEY_ID:signature")
+	header.Set("X-QS-Encryption-Customer-Key", "key")
+	header.Set("X-QS-Encryption-Customer-Key-MD5", "md5")
+	header.Set("X-QS-Security-Token", "token")
+
+	redacted := RedactHeader(header)
+	assert.Equal(t, Redacted, redacted.Get("Authorization"))
+	assert.Equal(t, Redacted, redacted.Get("X-QS-Security-Token"))
+	assert.Equal(t, Redacted, redacted.Get("X-QS-Encryption-Customer-Key"))
+	assert.Equal(t, "md5", redacted.Get("X-QS-Encryption-Customer-Key-MD5"))
+	assert.Equal(t, "key", header.Get("X-QS-Encryption-Customer-Key"))
+}
+
+func TestRedactURL(t *testing.T) {
+	u, err := url.Parse("https://qingstor.com/bucket/key?access_key_id=ak&expires=1&signature=sig")
+	assert.Nil(t, err)
+	assert.Equal(t,
+		"https://qingstor.com/bucket/key?access_key_id=ak&expires=1&signature=REDACTED",
+		RedactURL(u),
+	)
+	assert.Equal(t, "sig", u.Query().Get("signature"))
+
+	u, err = url.Parse("https://qingstor.com/bucket/key?acl")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://qingstor.com/bucket/key?acl", RedactURL(u))
+	assert.Equal(t, "", RedactURL(nil))
+}